@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"image/color"
+	"strings"
 
 	"fyne.io/fyne/v2"
 
@@ -24,15 +26,37 @@ func createAuthUI(onSuccess func()) fyne.CanvasObject {
 	passEntry.TextStyle = fyne.TextStyle{Bold: true}
 	passEntry.Resize(fyne.NewSize(300, 40))
 
+	// Подсказка с невыполненными требованиями к паролю
+	policyHint := widget.NewLabel("")
+	policyHint.Wrapping = fyne.TextWrapWord
+	passEntry.OnChanged = func(p string) {
+		if problems := passwordProblems(p, passwordPolicy); p != "" && len(problems) > 0 {
+			policyHint.SetText("Пароль должен содержать: " + strings.Join(problems, ", "))
+		} else {
+			policyHint.SetText("")
+		}
+	}
+
 	title := canvas.NewText("MUSIC MANAGER", color.NRGBA{30, 215, 96, 255})
 	title.TextSize = 32
 	title.TextStyle = fyne.TextStyle{Bold: true}
 
+	rememberCheck := widget.NewCheck("Запомнить меня", nil)
+
 	loginBtn := widget.NewButton("Войти", func() {
-		err := loginUser(userEntry.Text, passEntry.Text)
-		if err != nil {
+		err := loginUser(userEntry.Text, passEntry.Text, "", rememberCheck.Checked)
+		switch {
+		case errors.Is(err, errTOTPRequired):
+			askSecondFactor(func(code string) {
+				if err := loginUser(userEntry.Text, passEntry.Text, code, rememberCheck.Checked); err != nil {
+					dialog.ShowError(err, mainWindow)
+				} else {
+					onSuccess()
+				}
+			})
+		case err != nil:
 			dialog.ShowError(err, mainWindow)
-		} else {
+		default:
 			onSuccess()
 		}
 	})
@@ -50,9 +74,24 @@ func createAuthUI(onSuccess func()) fyne.CanvasObject {
 		container.NewCenter(title),
 		userEntry,
 		passEntry,
+		policyHint,
+		rememberCheck,
 		loginBtn,
 		regBtn,
 	)
 
 	return container.NewCenter(form)
 }
+
+// Запрос кода из приложения-аутентификатора (или резервного кода)
+func askSecondFactor(onCode func(code string)) {
+	codeEntry := widget.NewEntry()
+	codeEntry.SetPlaceHolder("123456 или резервный код")
+	dialog.ShowForm("Двухфакторная аутентификация", "Подтвердить", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("Код", codeEntry)},
+		func(ok bool) {
+			if ok {
+				onCode(codeEntry.Text)
+			}
+		}, mainWindow)
+}
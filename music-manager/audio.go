@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Декодирование аудиофайлов для отпечатков и анализа громкости.
+// WAV читается сам, остальные форматы декодирует ffmpeg, если он установлен.
+
+// audioExtensions — файлы, которые берём при обходе папки
+var audioExtensions = map[string]bool{
+	".wav": true, ".mp3": true, ".flac": true, ".ogg": true, ".m4a": true, ".aac": true, ".opus": true, ".wma": true,
+}
+
+var ffmpegPath = envString("FFMPEG_PATH", "ffmpeg")
+
+// pcmAudio — звук по каналам, отсчёты в [-1, 1]
+type pcmAudio struct {
+	Rate     int
+	Channels [][]float64
+}
+
+func (a *pcmAudio) mono() []float64 {
+	if len(a.Channels) == 1 {
+		return a.Channels[0]
+	}
+	out := make([]float64, len(a.Channels[0]))
+	for _, ch := range a.Channels {
+		for i, v := range ch {
+			out[i] += v
+		}
+	}
+	for i := range out {
+		out[i] /= float64(len(a.Channels))
+	}
+	return out
+}
+
+// decodePCM декодирует файл с исходной частотой и каналами; maxSeconds > 0 — только начало
+func decodePCM(path string, maxSeconds int) (*pcmAudio, error) {
+	if strings.EqualFold(filepath.Ext(path), ".wav") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if a, err := readWAV(f, maxSeconds); err == nil {
+			return a, nil
+		}
+		// Сжатый WAV (не PCM) отдаём ffmpeg
+	}
+	args := []string{"-v", "error", "-i", path}
+	if maxSeconds > 0 {
+		args = append(args, "-t", fmt.Sprint(maxSeconds))
+	}
+	args = append(args, "-f", "wav", "-acodec", "pcm_f32le", "-")
+	out, err := exec.Command(ffmpegPath, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось декодировать %s (нужен ffmpeg): %w", filepath.Base(path), err)
+	}
+	return readWAV(bytes.NewReader(out), maxSeconds)
+}
+
+// readWAV читает PCM (от 1 до 4 байт на отсчёт) и float32 WAV. Размер данных 0 или 0xFFFFFFFF
+// (так пишет ffmpeg в канал) означает "до конца файла".
+func readWAV(r io.Reader, maxSeconds int) (*pcmAudio, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil || string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, fmt.Errorf("не WAV-файл")
+	}
+	var format, channels, bitsPerSample uint16
+	var rate uint32
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, fmt.Errorf("в WAV-файле нет данных")
+		}
+		size := int64(binary.LittleEndian.Uint32(hdr[4:]))
+		switch string(hdr[:4]) {
+		case "fmt ":
+			buf := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, buf); err != nil || size < 16 {
+				return nil, fmt.Errorf("повреждён заголовок WAV")
+			}
+			format = binary.LittleEndian.Uint16(buf[0:])
+			channels = binary.LittleEndian.Uint16(buf[2:])
+			rate = binary.LittleEndian.Uint32(buf[4:])
+			bitsPerSample = binary.LittleEndian.Uint16(buf[14:])
+			if format == 0xFFFE && size >= 26 { // WAVE_FORMAT_EXTENSIBLE: настоящий формат в GUID
+				format = binary.LittleEndian.Uint16(buf[24:])
+			}
+		case "data":
+			if channels == 0 || rate == 0 {
+				return nil, fmt.Errorf("в WAV-файле нет заголовка формата")
+			}
+			if format != 1 && !(format == 3 && bitsPerSample == 32) {
+				return nil, fmt.Errorf("формат WAV %d не поддерживается", format)
+			}
+			// Отсчёт занимает целое число байт: 12 бит хранятся в двух, 20 и 24 — в трёх
+			width := (int64(bitsPerSample) + 7) / 8
+			if bitsPerSample < 8 || width > 4 {
+				return nil, fmt.Errorf("WAV с %d битами на отсчёт не поддерживается", bitsPerSample)
+			}
+			frame := width * int64(channels)
+			limit := int64(math.MaxInt64)
+			if maxSeconds > 0 {
+				limit = frame * int64(rate) * int64(maxSeconds)
+			}
+			if size != 0 && size != 0xFFFFFFFF {
+				limit = min(limit, size)
+			}
+			data, err := io.ReadAll(io.LimitReader(r, limit))
+			if err != nil {
+				return nil, err
+			}
+			n := int64(len(data)) / frame
+			a := &pcmAudio{Rate: int(rate), Channels: make([][]float64, channels)}
+			for c := range a.Channels {
+				ch := make([]float64, n)
+				for i := range ch {
+					ch[i] = pcmSample(data[int64(i)*frame+int64(c)*width:], int(width), format == 3)
+				}
+				a.Channels[c] = ch
+			}
+			return a, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, fmt.Errorf("повреждён WAV-файл")
+			}
+		}
+	}
+}
+
+func pcmSample(b []byte, width int, float bool) float64 {
+	switch {
+	case float:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case width == 1:
+		return (float64(b[0]) - 128) / 128
+	case width == 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case width == 3:
+		return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)) / math.MaxInt32
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / math.MaxInt32
+	}
+}
+
+// resample — линейная интерполяция; для хромы до 3,5 кГц её точности достаточно
+func resample(samples []float64, from, to int) []float64 {
+	if from == to || len(samples) == 0 {
+		return samples
+	}
+	n := int(int64(len(samples)) * int64(to) / int64(from))
+	out := make([]float64, n)
+	step := float64(from) / float64(to)
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		if j+1 >= len(samples) {
+			out[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := pos - float64(j)
+		out[i] = samples[j]*(1-frac) + samples[j+1]*frac
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// wavFile собирает WAV из заголовка формата и данных как есть, без проверок
+func wavFile(format, channels uint16, rate uint32, bits uint16, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	blockAlign := channels * ((bits + 7) / 8)
+	for _, v := range []any{format, channels, rate, rate * uint32(blockAlign), blockAlign, bits} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func TestReadWAV(t *testing.T) {
+	// Два стереокадра по 16 бит: (0.5, -0.5), (0, 0)
+	pcm16 := []byte{0x00, 0x40, 0x00, 0xC0, 0, 0, 0, 0}
+	tests := []struct {
+		name     string
+		file     []byte
+		frames   int
+		first    float64 // первый отсчёт левого канала
+		wantErr  bool
+		channels int
+	}{
+		{"16 бит стерео", wavFile(1, 2, 8000, 16, pcm16), 2, 0.5, false, 2},
+		{"8 бит моно", wavFile(1, 1, 8000, 8, []byte{192, 128, 64}), 3, 0.5, false, 1},
+		{"12 бит в двух байтах", wavFile(1, 1, 8000, 12, []byte{0x00, 0x40}), 1, 0.5, false, 1},
+		{"4 бита на отсчёт", wavFile(1, 1, 8000, 4, []byte{1, 2, 3, 4}), 0, 0, true, 0},
+		{"0 бит на отсчёт", wavFile(1, 2, 8000, 0, []byte{1, 2, 3, 4}), 0, 0, true, 0},
+		{"64 бита на отсчёт", wavFile(1, 1, 8000, 64, make([]byte, 16)), 0, 0, true, 0},
+		{"нет каналов", wavFile(1, 0, 8000, 16, pcm16), 0, 0, true, 0},
+		{"float64", wavFile(3, 1, 8000, 64, make([]byte, 16)), 0, 0, true, 0},
+		{"не WAV", []byte("ID3 это mp3"), 0, 0, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := readWAV(bytes.NewReader(tt.file), 0)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readWAV без ошибки, %d каналов", len(a.Channels))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readWAV: %v", err)
+			}
+			if len(a.Channels) != tt.channels || len(a.Channels[0]) != tt.frames {
+				t.Fatalf("каналов %d, кадров %d, want %d и %d", len(a.Channels), len(a.Channels[0]), tt.channels, tt.frames)
+			}
+			if a.Channels[0][0] != tt.first {
+				t.Errorf("первый отсчёт %v, want %v", a.Channels[0][0], tt.first)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- AUDIT LOG ---
+
+// Сколько дней хранится журнал изменений; 0 — хранить без ограничения
+var auditRetentionDays = envInt("AUDIT_RETENTION_DAYS", 365)
+
+const (
+	auditDateLayout  = "2006-01-02"
+	auditViewLimit   = 500
+	auditExportLimit = 100000
+)
+
+var auditOperationLabels = map[string]string{
+	"insert": "создание",
+	"update": "изменение",
+	"delete": "удаление",
+}
+
+// auditEntityLabels — названия таблиц журнала для фильтра и списка
+var auditEntityLabels = map[string]string{
+	"users":                  "пользователи",
+	"artists":                "артисты",
+	"albums":                 "альбомы",
+	"tracks":                 "треки",
+	"track_tags":             "теги треков",
+	"playlists":              "плейлисты",
+	"playlist_tracks":        "треки плейлистов",
+	"playlist_collaborators": "участники плейлистов",
+	"playlist_follows":       "подписки на плейлисты",
+	"user_follows":           "подписки на пользователей",
+	"playlist_folders":       "папки плейлистов",
+	"track_ratings":          "оценки треков",
+	"track_plays":            "прослушивания",
+	"track_fingerprints":     "акустические отпечатки",
+	"user_settings":          "настройки пользователей",
+}
+
+// auditEntityLabel — название таблицы по-русски; неизвестная показывается как есть
+func auditEntityLabel(entityType string) string {
+	if l, ok := auditEntityLabels[entityType]; ok {
+		return l
+	}
+	return entityType
+}
+
+// purgeAuditLog удаляет записи старше срока хранения; вызывается при запуске приложения
+func purgeAuditLog() {
+	if auditRetentionDays <= 0 {
+		return
+	}
+	repo.PurgeAuditLog(nowFunc().AddDate(0, 0, -auditRetentionDays))
+}
+
+// parseAuditDate разбирает дату ГГГГ-ММ-ДД; пустая строка — без ограничения
+func parseAuditDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(auditDateLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("дата %q должна быть в формате ГГГГ-ММ-ДД", s)
+	}
+	return t, nil
+}
+
+// auditFilter собирает фильтр из полей формы; дата "по" включается целиком
+func auditFilter(username, operation, entityType, entityID, from, to string) (AuditFilter, error) {
+	f := AuditFilter{
+		Username:   strings.TrimSpace(username),
+		Operation:  operation,
+		EntityType: entityType,
+	}
+	if id := strings.TrimSpace(entityID); id != "" {
+		n, err := strconv.Atoi(id)
+		if err != nil || n <= 0 {
+			return f, fmt.Errorf("номер записи должен быть положительным числом")
+		}
+		f.EntityID = n
+	}
+	var err error
+	if f.From, err = parseAuditDate(from); err != nil {
+		return f, err
+	}
+	if f.To, err = parseAuditDate(to); err != nil {
+		return f, err
+	}
+	if !f.To.IsZero() {
+		f.To = f.To.AddDate(0, 0, 1)
+	}
+	return f, nil
+}
+
+func getAuditLog(f AuditFilter) ([]AuditEntry, error) {
+	if err := requireAdmin(); err != nil {
+		return nil, err
+	}
+	return repo.GetAuditLog(f)
+}
+
+// auditLabel — строка журнала в списке
+func auditLabel(e AuditEntry) string {
+	who := e.Username
+	if who == "" {
+		who = "система"
+	}
+	return fmt.Sprintf("%s  %s  %s %s #%d", e.CreatedAt.Local().Format("2006-01-02 15:04:05"), who,
+		auditOperationLabels[e.Operation], auditEntityLabel(e.EntityType), e.EntityID)
+}
+
+// exportAuditCSV выгружает записи журнала в CSV
+func exportAuditCSV(w io.Writer, entries []AuditEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "created_at", "user_id", "username", "operation", "entity_type", "entity_id", "before", "after"})
+	for _, e := range entries {
+		cw.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.Format(time.RFC3339),
+			strconv.Itoa(e.UserID),
+			e.Username,
+			e.Operation,
+			e.EntityType,
+			strconv.Itoa(e.EntityID),
+			e.Before,
+			e.After,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// purgeAuditOlderThan вручную удаляет записи старше days дней
+func purgeAuditOlderThan(days int) (int, error) {
+	if err := requireAdmin(); err != nil {
+		return 0, err
+	}
+	if days <= 0 {
+		return 0, fmt.Errorf("срок должен быть больше нуля")
+	}
+	return repo.PurgeAuditLog(nowFunc().AddDate(0, 0, -days))
+}
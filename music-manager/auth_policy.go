@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Источник текущего времени; подменяется, когда нужны фиксированные часы
+var nowFunc = time.Now
+
+// PASSWORD POLICY
+
+type PasswordPolicy struct {
+	MinLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSpecial bool
+}
+
+// THROTTLE POLICY
+
+type ThrottlePolicy struct {
+	FreeAttempts    int           // сколько неудач допускается без задержки
+	BaseDelay       time.Duration // первая задержка, далее удваивается
+	MaxFailures     int           // после стольких неудач — блокировка
+	LockoutDuration time.Duration // длительность блокировки (и потолок задержки)
+	FailureWindow   time.Duration // через сколько счётчик неудач сбрасывается
+}
+
+var (
+	passwordPolicy = PasswordPolicy{
+		MinLength:      envInt("PASSWORD_MIN_LENGTH", 8),
+		RequireUpper:   envBool("PASSWORD_REQUIRE_UPPER", true),
+		RequireLower:   envBool("PASSWORD_REQUIRE_LOWER", true),
+		RequireDigit:   envBool("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSpecial: envBool("PASSWORD_REQUIRE_SPECIAL", false),
+	}
+	throttlePolicy = ThrottlePolicy{
+		FreeAttempts:    envInt("LOGIN_FREE_ATTEMPTS", 3),
+		BaseDelay:       time.Duration(envInt("LOGIN_BASE_DELAY_SEC", 2)) * time.Second,
+		MaxFailures:     envInt("LOGIN_MAX_FAILURES", 10),
+		LockoutDuration: time.Duration(envInt("LOGIN_LOCKOUT_MIN", 15)) * time.Minute,
+		FailureWindow:   time.Duration(envInt("LOGIN_FAILURE_WINDOW_MIN", 60)) * time.Minute,
+	}
+)
+
+// Имена, которые нельзя занять при регистрации
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true,
+	"support": true, "moderator": true, "null": true, "guest": true,
+}
+
+const (
+	usernameMinLength = 3
+	usernameMaxLength = 32
+)
+
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil {
+		return v
+	}
+	return def
+}
+
+func envBool(name string, def bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(name)); err == nil {
+		return v
+	}
+	return def
+}
+
+// passwordProblems возвращает список невыполненных требований к паролю
+func passwordProblems(p string, policy PasswordPolicy) []string {
+	var upper, lower, digit, special bool
+	for _, r := range p {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			special = true
+		}
+	}
+
+	var problems []string
+	if len([]rune(p)) < policy.MinLength {
+		problems = append(problems, fmt.Sprintf("не короче %d символов", policy.MinLength))
+	}
+	if policy.RequireUpper && !upper {
+		problems = append(problems, "заглавная буква")
+	}
+	if policy.RequireLower && !lower {
+		problems = append(problems, "строчная буква")
+	}
+	if policy.RequireDigit && !digit {
+		problems = append(problems, "цифра")
+	}
+	if policy.RequireSpecial && !special {
+		problems = append(problems, "спецсимвол")
+	}
+	return problems
+}
+
+func validatePassword(p string) error {
+	if problems := passwordProblems(p, passwordPolicy); len(problems) > 0 {
+		return fmt.Errorf("пароль не соответствует требованиям: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func validateUsername(u string) error {
+	n := len([]rune(u))
+	if n < usernameMinLength || n > usernameMaxLength {
+		return fmt.Errorf("логин должен быть от %d до %d символов", usernameMinLength, usernameMaxLength)
+	}
+	for _, r := range u {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-') {
+			return fmt.Errorf("логин может содержать только латинские буквы, цифры и символы _ . -")
+		}
+	}
+	if reservedUsernames[strings.ToLower(u)] {
+		return fmt.Errorf("логин %q зарезервирован", u)
+	}
+	return nil
+}
+
+// lockoutDuration — на сколько блокируется вход после failures неудач подряд
+func lockoutDuration(failures int, p ThrottlePolicy) time.Duration {
+	if failures >= p.MaxFailures {
+		return p.LockoutDuration
+	}
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && d < p.LockoutDuration; i++ {
+		d *= 2
+	}
+	if d > p.LockoutDuration {
+		d = p.LockoutDuration
+	}
+	return d
+}
+
+// Источник попытки входа — имя машины, с которой запущено приложение
+func loginSource() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+	return host
+}
+
+func userThrottleKey(u string) string   { return "user:" + strings.ToLower(u) }
+func sourceThrottleKey(s string) string { return "source:" + s }
+
+// registerLoginFailure увеличивает счётчики по аккаунту и источнику и при необходимости блокирует их
+func registerLoginFailure(keys ...string) {
+	now := nowFunc()
+	for _, key := range keys {
+		failures, err := repo.IncrementLoginFailures(key, now, now.Add(-throttlePolicy.FailureWindow))
+		if err != nil {
+			continue
+		}
+		if d := lockoutDuration(failures, throttlePolicy); d > 0 {
+			repo.SetLockedUntil(key, now.Add(d))
+		}
+	}
+}
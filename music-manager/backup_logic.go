@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// --- BACKUP & RESTORE ---
+// Функции без проверки прав используются из командной строки, где доступ
+// к базе уже означает полномочия администратора; интерфейс вызывает обёртки с requireAdmin.
+
+func validRestoreMode(mode string) bool {
+	return mode == RestoreMerge || mode == RestoreReplace
+}
+
+// writeBackup выгружает все данные в JSON; хеши паролей — только по явному запросу
+func writeBackup(w io.Writer, withHashes bool) error {
+	b, err := repo.ExportBackup(withHashes)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// readBackup разбирает архив и проверяет формат и версию
+func readBackup(r io.Reader) (*Backup, error) {
+	var b Backup
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("файл не является резервной копией: %w", err)
+	}
+	if b.Format != backupFormat {
+		return nil, fmt.Errorf("неизвестный формат архива %q", b.Format)
+	}
+	if b.Version < 1 || b.Version > backupVersion {
+		return nil, fmt.Errorf("версия архива %d не поддерживается (поддерживается до %d)", b.Version, backupVersion)
+	}
+	for _, u := range b.Users {
+		if !validRole(u.Role) {
+			return nil, fmt.Errorf("у пользователя %q неизвестная роль %q", u.Username, u.Role)
+		}
+	}
+	return &b, nil
+}
+
+// restoreBackup восстанавливает архив; пользователям без хеша выдаются временные пароли
+func restoreBackup(b *Backup, mode string) (*RestoreReport, error) {
+	if !validRestoreMode(mode) {
+		return nil, fmt.Errorf("неизвестный режим восстановления %q", mode)
+	}
+	temp := map[string]string{}
+	for _, u := range b.Users {
+		if u.PasswordHash == "" {
+			token, err := newToken()
+			if err != nil {
+				return nil, err
+			}
+			temp[u.Username] = token[:12]
+		}
+	}
+	return repo.ImportBackup(b, mode, temp)
+}
+
+// describeRestore — итог восстановления для пользователя
+func describeRestore(rep *RestoreReport) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "Создано: пользователей %d, артистов %d, альбомов %d, треков %d, папок %d, плейлистов %d, записей в плейлистах %d, оценок %d, прослушиваний %d.",
+		rep.Users, rep.Artists, rep.Albums, rep.Tracks, rep.Folders, rep.Playlists, rep.Entries, rep.Ratings, rep.Plays)
+	if rep.ArchiveVersion < 2 {
+		fmt.Fprintf(&s, "\n\nАрхив версии %d: в нём нет громкости, темпа и тональности, участников альбомов, оценок и прослушиваний — эти данные остались пустыми.", rep.ArchiveVersion)
+	}
+	if len(rep.TempPasswords) > 0 {
+		s.WriteString("\n\nВременные пароли новых пользователей (передайте их владельцам и попросите сменить):")
+		names := make([]string, 0, len(rep.TempPasswords))
+		for name := range rep.TempPasswords {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&s, "\n%s: %s", name, rep.TempPasswords[name])
+		}
+	}
+	return s.String()
+}
+
+func exportBackup(w io.Writer, withHashes bool) error {
+	if err := requireAdmin(); err != nil {
+		return err
+	}
+	return writeBackup(w, withHashes)
+}
+
+// importBackup восстанавливает архив из интерфейса; история отмены после этого
+// сбрасывается, потому что ссылается на прежние ID
+func importBackup(r io.Reader, mode string) (*RestoreReport, error) {
+	if err := requireAdmin(); err != nil {
+		return nil, err
+	}
+	b, err := readBackup(r)
+	if err != nil {
+		return nil, err
+	}
+	rep, err := restoreBackup(b, mode)
+	if err != nil {
+		return nil, err
+	}
+	clearHistory()
+	notifyDataChanged()
+	return rep, nil
+}
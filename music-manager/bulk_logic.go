@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+var kindLabels = map[string]string{
+	KindArtist: "артистов",
+	KindAlbum:  "альбомов",
+	KindTrack:  "треков",
+}
+
+// Запрет удалять записи каталога, треки которых есть в чужих плейлистах
+var blockForeignPlaylistDeletes = envBool("BLOCK_DELETE_IN_FOREIGN_PLAYLISTS", false)
+
+// describeDeleteImpact — текст подтверждения удаления с перечнем каскадных последствий
+func describeDeleteImpact(kind string, n int, impact DeleteImpact) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Будет удалено %s: %d.", kindLabels[kind], n)
+	if kind == KindArtist {
+		fmt.Fprintf(&b, "\nВместе с ними альбомов: %d", impact.Albums)
+	}
+	if kind != KindTrack {
+		fmt.Fprintf(&b, "\nТреков: %d", impact.Tracks)
+	}
+	if impact.PlaylistEntries > 0 {
+		fmt.Fprintf(&b, "\nТреки исчезнут из плейлистов: %d записей в %d плейлистах у %d пользователей",
+			impact.PlaylistEntries, impact.Playlists, impact.Users)
+	}
+	if impact.OtherUsersEntries > 0 {
+		fmt.Fprintf(&b, "\nИз них в чужих плейлистах: %d записей", impact.OtherUsersEntries)
+	}
+	return b.String()
+}
+
+// catalogDeleteImpact возвращает последствия удаления и ошибку, если удаление запрещено
+func catalogDeleteImpact(kind string, ids []int) (DeleteImpact, error) {
+	impact, err := repo.CatalogDeleteImpact(kind, ids, currentUser.ID)
+	if err != nil {
+		return impact, err
+	}
+	return impact, checkDeleteAllowed(impact)
+}
+
+func checkDeleteAllowed(impact DeleteImpact) error {
+	if blockForeignPlaylistDeletes && impact.OtherUsersEntries > 0 {
+		return fmt.Errorf("удаление запрещено: треки есть в плейлистах других пользователей (%d записей)", impact.OtherUsersEntries)
+	}
+	return nil
+}
+
+func bulkDelete(kind string, ids []int) error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("ничего не выбрано")
+	}
+	return runCommand(&deleteCatalogCmd{Kind: kind, IDs: ids})
+}
+
+// BulkAdd — треки, которые стоит добавить в плейлист, после отсева совпадающих по звучанию
+type BulkAdd struct {
+	TrackIDs []int
+	Skipped  int // совпали по звучанию с треками плейлиста или друг с другом
+}
+
+// planBulkAdd отсеивает треки, которые звучат как уже добавленные. Сравнение отпечатков
+// долгое, поэтому вызывается вне потока интерфейса; добавляет bulkAddToPlaylist.
+func planBulkAdd(pID int, trackIDs []int) (*BulkAdd, error) {
+	if err := requirePlaylistPermission(pID, PermAdd); err != nil {
+		return nil, err
+	}
+	if len(trackIDs) == 0 {
+		return nil, fmt.Errorf("не выбраны треки")
+	}
+	keep, err := skipAcousticDuplicates(pID, trackIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(keep) == 0 {
+		return nil, fmt.Errorf("ничего не добавлено: все выбранные треки (%d) звучат как уже добавленные в плейлист", len(trackIDs))
+	}
+	return &BulkAdd{TrackIDs: keep, Skipped: len(trackIDs) - len(keep)}, nil
+}
+
+// bulkAddToPlaylist добавляет отобранные треки; возвращает число добавленных и признак чужих правок.
+// Треки, которые уже есть в плейлисте, не добавляются повторно.
+func bulkAddToPlaylist(p *Playlist, add *BulkAdd) (int, bool, error) {
+	cmd := &addEntriesCmd{PlaylistID: p.ID, TrackIDs: add.TrackIDs}
+	if err := runCommand(cmd); err != nil {
+		return 0, false, err
+	}
+	return len(cmd.Added), applyPlaylistVersion(p, cmd.version), nil
+}
+
+func bulkRemoveFromPlaylist(p *Playlist, trackIDs []int) (bool, error) {
+	if err := requirePlaylistPermission(p.ID, PermEdit); err != nil {
+		return false, err
+	}
+	if len(trackIDs) == 0 {
+		return false, fmt.Errorf("не выбраны треки")
+	}
+	cmd := &removeEntriesCmd{PlaylistID: p.ID, TrackIDs: trackIDs}
+	if err := runCommand(cmd); err != nil {
+		return false, err
+	}
+	return applyPlaylistVersion(p, cmd.version), nil
+}
+
+// parseTags разбирает теги через запятую, убирая пустые и повторы
+func parseTags(s string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// bulkTagTracks задаёт жанр (если setGenre) и добавляет теги выбранным трекам
+func bulkTagTracks(trackIDs []int, setGenre bool, genre, tags string) error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	if len(trackIDs) == 0 {
+		return fmt.Errorf("не выбраны треки")
+	}
+	var g *string
+	if setGenre {
+		genre = strings.TrimSpace(genre)
+		g = &genre
+	}
+	return repo.SetTracksGenreAndTags(trackIDs, g, parseTags(tags))
+}
+
+func bulkMoveTracks(trackIDs []int, albumID int) error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	if len(trackIDs) == 0 {
+		return fmt.Errorf("не выбраны треки")
+	}
+	if albumID == 0 {
+		return fmt.Errorf("выберите альбом")
+	}
+	return repo.MoveTracksToAlbum(trackIDs, albumID)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// Команды для запуска без графического интерфейса:
+//
+//	music-app backup  [-o файл] [-with-hashes]
+//	music-app restore -i файл [-mode merge|replace]
+//	music-app metadata-standin [-addr адрес] -data файл
+//	music-app playlist-summary -id N [-json]
+//	music-app set-role -user имя -role admin|editor|listener
+//
+// Изменения из командной строки записываются в журнал без автора.
+
+const cliUsage = `Использование:
+  music-app                                      запуск приложения
+  music-app backup  [-o файл] [-with-hashes]     резервная копия в JSON (по умолчанию в stdout)
+  music-app restore -i файл [-mode merge|replace] восстановление из копии
+  music-app metadata-standin [-addr адрес] -data файл
+                                                 локальная подмена сервиса метаданных
+  music-app playlist-summary -id N [-json]       сводка плейлиста: треки, длительность, артисты, годы
+  music-app set-role -user имя -role admin|editor|listener
+                                                 смена роли, например назначение администратора`
+
+// runCLI выполняет команду и возвращает код завершения
+func runCLI(args []string) int {
+	var err error
+	switch args[0] {
+	case "backup":
+		err = cliBackup(args[1:])
+	case "restore":
+		err = cliRestore(args[1:])
+	case "metadata-standin":
+		err = cliMetadataStandin(args[1:])
+	case "playlist-summary":
+		err = cliPlaylistSummary(args[1:])
+	case "set-role":
+		err = cliSetRole(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Ошибка:", err)
+		return 1
+	}
+	return 0
+}
+
+func cliBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("o", "-", "файл архива (- — стандартный вывод)")
+	withHashes := fs.Bool("with-hashes", false, "сохранить хеши паролей")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return writeBackup(w, *withHashes)
+}
+
+func cliRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := fs.String("i", "", "файл архива")
+	mode := fs.String("mode", RestoreMerge, "merge — дополнить, replace — заменить каталог и плейлисты")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("укажите файл архива: -i файл")
+	}
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := readBackup(f)
+	if err != nil {
+		return err
+	}
+	rep, err := restoreBackup(b, *mode)
+	if err != nil {
+		return err
+	}
+	fmt.Println(describeRestore(rep))
+	return nil
+}
+
+func cliMetadataStandin(args []string) error {
+	fs := flag.NewFlagSet("metadata-standin", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8089", "адрес для запросов")
+	data := fs.String("data", "", "JSON-файл с релизами")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *data == "" {
+		return fmt.Errorf("укажите файл релизов: -data файл")
+	}
+	s, err := loadMetadataStandin(*data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Релизов: %d. Адрес для METADATA_URL: http://%s/ws/2\n", len(s.releases), *addr)
+	return http.ListenAndServe(*addr, s)
+}
+
+func cliPlaylistSummary(args []string) error {
+	fs := flag.NewFlagSet("playlist-summary", flag.ContinueOnError)
+	id := fs.Int("id", 0, "ID плейлиста")
+	asJSON := fs.Bool("json", false, "вывести в JSON (длительность в секундах)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		return fmt.Errorf("укажите плейлист: -id N")
+	}
+	s, err := repo.GetPlaylistSummary(*id)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	fmt.Printf("%s\n%s\n", s.Title, describePlaylistSummary(s))
+	return nil
+}
+
+// cliSetRole назначает роль без входа в приложение — так появляется администратор,
+// если его нет или единственный администратор потерял доступ
+func cliSetRole(args []string) error {
+	fs := flag.NewFlagSet("set-role", flag.ContinueOnError)
+	user := fs.String("user", "", "имя пользователя")
+	role := fs.String("role", RoleAdmin, "роль: admin, editor или listener")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *user == "" {
+		return fmt.Errorf("укажите пользователя: -user имя")
+	}
+	if !validRole(*role) {
+		return fmt.Errorf("неизвестная роль %q", *role)
+	}
+	if err := repo.SetUserRoleByName(*user, *role); err != nil {
+		return err
+	}
+	fmt.Printf("%s: роль %s\n", *user, *role)
+	return nil
+}
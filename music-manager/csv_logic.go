@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// --- CSV IMPORT & EXPORT ---
+
+// Поля каталога, которые можно сопоставить столбцам CSV
+const (
+	CSVArtist   = "artist"
+	CSVAlbum    = "album"
+	CSVYear     = "year"
+	CSVTrack    = "track"
+	CSVDuration = "duration"
+	CSVGenre    = "genre"
+)
+
+var csvFields = []string{CSVArtist, CSVAlbum, CSVYear, CSVTrack, CSVDuration, CSVGenre}
+
+var csvFieldLabels = map[string]string{
+	CSVArtist:   "Артист",
+	CSVAlbum:    "Альбом",
+	CSVYear:     "Год",
+	CSVTrack:    "Трек",
+	CSVDuration: "Длительность",
+	CSVGenre:    "Жанр",
+}
+
+// Заголовки, по которым столбец сопоставляется полю автоматически
+var csvFieldAliases = map[string][]string{
+	CSVArtist:   {"artist", "артист", "исполнитель", "band", "группа"},
+	CSVAlbum:    {"album", "альбом", "release", "релиз"},
+	CSVYear:     {"year", "год"},
+	CSVTrack:    {"track", "трек", "title", "название", "песня", "song"},
+	CSVDuration: {"duration", "длительность", "length", "время", "time"},
+	CSVGenre:    {"genre", "жанр"},
+}
+
+// CSVMapping — номер столбца для каждого поля; отсутствующее поле не импортируется
+type CSVMapping map[string]int
+
+// readCSV читает файл целиком. Разделитель (запятая, точка с запятой или табуляция)
+// определяется по строке заголовка, как его сохраняют табличные редакторы.
+func readCSV(r io.Reader) (header []string, records [][]string, err error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, nil, err
+	}
+	line := string(first)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	sep := ','
+	for _, c := range []rune{';', '\t'} {
+		if strings.Count(line, string(c)) > strings.Count(line, string(sep)) {
+			sep = c
+		}
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = sep
+	cr.FieldsPerRecord = -1
+	all, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось прочитать CSV: %w", err)
+	}
+	if len(all) == 0 {
+		return nil, nil, fmt.Errorf("файл пуст")
+	}
+	header = all[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	return header, all[1:], nil
+}
+
+// guessMapping сопоставляет столбцы полям по названиям заголовков
+func guessMapping(header []string) CSVMapping {
+	m := CSVMapping{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		for _, f := range csvFields {
+			if _, taken := m[f]; taken {
+				continue
+			}
+			for _, alias := range csvFieldAliases[f] {
+				if h == alias {
+					m[f] = i
+				}
+			}
+		}
+	}
+	return m
+}
+
+// buildCatalogRows проверяет строки и переводит их в записи каталога.
+// Номера строк считаются от начала файла, заголовок — строка 1.
+func buildCatalogRows(records [][]string, m CSVMapping) ([]CatalogRow, []ImportRowError) {
+	var rows []CatalogRow
+	var errs []ImportRowError
+	get := func(rec []string, field string) string {
+		i, ok := m[field]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	for n, rec := range records {
+		line := n + 2
+		row := CatalogRow{
+			Line:   line,
+			Artist: get(rec, CSVArtist),
+			Album:  get(rec, CSVAlbum),
+			Track:  get(rec, CSVTrack),
+			Genre:  get(rec, CSVGenre),
+		}
+		if row.Artist == "" && row.Album == "" && row.Track == "" {
+			continue // пустая строка
+		}
+
+		var problems []string
+		if row.Artist == "" {
+			problems = append(problems, "не указан артист")
+		}
+		if row.Track != "" && row.Album == "" {
+			problems = append(problems, "для трека не указан альбом")
+		}
+		year, err := parseYear(get(rec, CSVYear))
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		row.Year = year
+		d, err := parseDuration(get(rec, CSVDuration))
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		row.Duration = d
+
+		if len(problems) > 0 {
+			errs = append(errs, ImportRowError{Line: line, Message: strings.Join(problems, "; ")})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, errs
+}
+
+// importCatalogCSV импортирует строки. В режиме "всё или ничего" любая ошибка
+// проверки отменяет импорт, и результат содержит только ошибки.
+func importCatalogCSV(records [][]string, m CSVMapping, atomic bool) (*ImportResult, error) {
+	if err := requireCatalogEditor(); err != nil {
+		return nil, err
+	}
+	if _, ok := m[CSVArtist]; !ok {
+		return nil, fmt.Errorf("укажите столбец с артистом")
+	}
+	rows, errs := buildCatalogRows(records, m)
+	if atomic && len(errs) > 0 {
+		return &ImportResult{Errors: errs}, nil
+	}
+	res, err := repo.ImportCatalogRows(rows, atomic)
+	if err != nil {
+		return nil, err
+	}
+	res.Errors = append(errs, res.Errors...)
+	return res, nil
+}
+
+func describeImport(res *ImportResult, atomic bool) string {
+	if atomic && len(res.Errors) > 0 {
+		return fmt.Sprintf("Импорт отменён: ошибок в строках — %d. Ничего не изменено.", len(res.Errors))
+	}
+	s := fmt.Sprintf("Создано артистов: %d, альбомов: %d, треков: %d. Уже были в каталоге: %d строк.",
+		res.Artists, res.Albums, res.Tracks, res.Skipped)
+	if len(res.Errors) > 0 {
+		s += fmt.Sprintf("\nПропущено строк с ошибками: %d.", len(res.Errors))
+	}
+	return s
+}
+
+// writeImportErrors сохраняет отчёт: номер строки, ошибка и исходные значения строки
+func writeImportErrors(w io.Writer, header []string, records [][]string, errs []ImportRowError) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"line", "error"}, header...))
+	for _, e := range errs {
+		rec := []string{strconv.Itoa(e.Line), e.Message}
+		if i := e.Line - 2; i >= 0 && i < len(records) {
+			rec = append(rec, records[i]...)
+		}
+		cw.Write(rec)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// --- EXPORT ---
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+func exportArtistsCSV(w io.Writer, items []Artist) error {
+	var rows [][]string
+	for _, a := range items {
+		rows = append(rows, []string{strconv.Itoa(a.ID), a.Name})
+	}
+	return writeCSV(w, []string{"id", "artist"}, rows)
+}
+
+func exportAlbumsCSV(w io.Writer, items []Album) error {
+	artists, _ := getArtists()
+	names := map[int]string{}
+	for _, a := range artists {
+		names[a.ID] = a.Name
+	}
+	var rows [][]string
+	for _, a := range items {
+		year := ""
+		if a.Year != 0 {
+			year = strconv.Itoa(a.Year)
+		}
+		rows = append(rows, []string{strconv.Itoa(a.ID), names[a.ArtistID], a.Title, year})
+	}
+	return writeCSV(w, []string{"id", "artist", "album", "year"}, rows)
+}
+
+// exportTracksCSV выгружает треки в том же формате, что понимает импорт
+func exportTracksCSV(w io.Writer, items []Track) error {
+	infos, err := repo.GetTrackInfos(trackIDs(items))
+	if err != nil {
+		return err
+	}
+	// Неизвестные год и длительность выгружаются пустыми — так их и прочитает импорт
+	var rows [][]string
+	for _, t := range infos {
+		year, duration := "", ""
+		if t.Year != 0 {
+			year = strconv.Itoa(t.Year)
+		}
+		if t.Duration != 0 {
+			duration = formatDuration(t.Duration)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(t.ID), t.ArtistName, t.AlbumTitle, year, t.Title, duration, t.Genre,
+		})
+	}
+	return writeCSV(w, []string{"id", "artist", "album", "year", "track", "duration", "genre"}, rows)
+}
@@ -10,5 +10,7 @@ var (
 	db          *sql.DB // Соединение с базой данных
 	repo        *Repository
 	currentUser *User // Текущий авторизованный пользователь
-	mainWindow  fyne.Window
+	// Сеанс "запомнить меня" текущего входа (0 — вход без запоминания)
+	currentSessionID int
+	mainWindow       fyne.Window
 )
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// --- DUPLICATES ---
+// Уникальные индексы ловят только точные совпадения названий, поэтому
+// "The Beatles", "Beatles" и "the beatles " живут рядом. Здесь ищем похожие записи
+// по нормализованным названиям, а треки — ещё и по близкой длительности.
+
+// Порог сходства названий в процентах и допуск по длительности треков в секундах
+var (
+	duplicateSimilarity        = envInt("DUPLICATE_SIMILARITY", 85)
+	duplicateDurationTolerance = envInt("DUPLICATE_DURATION_TOLERANCE", 3)
+)
+
+type DuplicateItem struct {
+	ID    int
+	Label string
+	Usage int // зависимых записей: альбомов у артиста, треков у альбома, вхождений в плейлисты у трека
+}
+
+// DuplicateGroup — записи, которые, скорее всего, обозначают одно и то же.
+// Первой идёт запись с наибольшим числом зависимых — её предлагаем оставить.
+type DuplicateGroup struct {
+	Kind       string
+	Items      []DuplicateItem
+	Similarity int // наименьшее сходство среди пар, связавших группу, в процентах
+}
+
+// normalizeName приводит название к виду для сравнения: нижний регистр,
+// без знаков препинания и лишних пробелов, "&" как "and", без начального артикля "the"
+func normalizeName(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, "&", " and "))
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	if rest := strings.TrimPrefix(s, "the "); rest != "" {
+		s = rest
+	}
+	return s
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// trigrams — множество триграмм слов, как в pg_trgm: слово дополняется двумя пробелами слева и одним справа
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			set[string(r[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// similarity — сходство нормализованных названий в процентах: лучшее из
+// расстояния Левенштейна и доли общих триграмм
+func similarity(a, b string) int {
+	if a == b {
+		return 100
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+	best := 100 - 100*levenshtein(ra, rb)/longest
+
+	ta, tb := trigrams(a), trigrams(b)
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+	if union := len(ta) + len(tb) - common; union > 0 {
+		best = max(best, 100*common/union)
+	}
+	return best
+}
+
+// unionFind объединяет похожие записи в группы
+type unionFind struct {
+	parent map[int]int
+	score  map[int]int // наименьшее сходство внутри группы, по корню
+}
+
+func newUnionFind() *unionFind {
+	return &unionFind{parent: map[int]int{}, score: map[int]int{}}
+}
+
+func (u *unionFind) find(id int) int {
+	p, ok := u.parent[id]
+	if !ok || p == id {
+		return id
+	}
+	root := u.find(p)
+	u.parent[id] = root
+	return root
+}
+
+func (u *unionFind) union(a, b, score int) {
+	ra, rb := u.find(a), u.find(b)
+	s := score
+	if v, ok := u.score[ra]; ok {
+		s = min(s, v)
+	}
+	if v, ok := u.score[rb]; ok {
+		s = min(s, v)
+	}
+	if ra != rb {
+		u.parent[rb] = ra
+		delete(u.score, rb)
+	}
+	u.score[ra] = s
+}
+
+// dedupeItem — запись для сравнения: block — записи сравниваются только внутри одного блока
+type dedupeItem struct {
+	id       int
+	label    string
+	name     string // нормализованное название
+	block    int
+	duration int
+}
+
+// pairDuplicates сравнивает записи попарно внутри блоков; match дополнительно проверяет пару
+func pairDuplicates(items []dedupeItem, match func(a, b dedupeItem) bool) *unionFind {
+	u := newUnionFind()
+	blocks := map[int][]dedupeItem{}
+	for _, it := range items {
+		blocks[it.block] = append(blocks[it.block], it)
+	}
+	for _, block := range blocks {
+		for i := range block {
+			for j := i + 1; j < len(block); j++ {
+				a, b := block[i], block[j]
+				if match != nil && !match(a, b) {
+					continue
+				}
+				if s := similarity(a.name, b.name); s >= duplicateSimilarity {
+					u.union(a.id, b.id, s)
+				}
+			}
+		}
+	}
+	return u
+}
+
+// findDuplicates ищет группы похожих записей вида kind. Альбомы и треки сравниваются
+// только в пределах одного артиста или группы похожих артистов.
+func findDuplicates(kind string) ([]DuplicateGroup, error) {
+	if err := requireCatalogEditor(); err != nil {
+		return nil, err
+	}
+	artists, err := repo.GetArtists()
+	if err != nil {
+		return nil, err
+	}
+	var artistItems []dedupeItem
+	artistNames := map[int]string{}
+	for _, a := range artists {
+		artistItems = append(artistItems, dedupeItem{id: a.ID, label: a.Name, name: normalizeName(a.Name)})
+		artistNames[a.ID] = a.Name
+	}
+	artistGroups := pairDuplicates(artistItems, nil)
+
+	var items []dedupeItem
+	var groups *unionFind
+	switch kind {
+	case KindArtist:
+		items, groups = artistItems, artistGroups
+	case KindAlbum:
+		albums, err := repo.GetAlbums()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range albums {
+			title := a.Title
+			if a.Year != 0 {
+				title += fmt.Sprintf(" (%d)", a.Year)
+			}
+			items = append(items, dedupeItem{
+				id:    a.ID,
+				label: title + " — " + artistNames[a.ArtistID],
+				name:  normalizeName(a.Title),
+				block: artistGroups.find(a.ArtistID),
+			})
+		}
+		groups = pairDuplicates(items, nil)
+	case KindTrack:
+		// Артист трека берётся из того же запроса, что и подпись, — без отдельного чтения альбомов
+		tracks, err := repo.GetTracks()
+		if err != nil {
+			return nil, err
+		}
+		infos, err := repo.GetTrackInfos(trackIDs(tracks))
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range infos {
+			items = append(items, dedupeItem{
+				id: t.ID,
+				label: fmt.Sprintf("%s (%s) — %s / %s",
+					t.Title, formatDuration(t.Duration), t.ArtistName, t.AlbumTitle),
+				name:     normalizeName(t.Title),
+				block:    artistGroups.find(t.ArtistID),
+				duration: t.Duration,
+			})
+		}
+		// Неизвестная длительность не мешает совпадению
+		groups = pairDuplicates(items, func(a, b dedupeItem) bool {
+			if a.duration == 0 || b.duration == 0 {
+				return true
+			}
+			d := a.duration - b.duration
+			return d <= duplicateDurationTolerance && d >= -duplicateDurationTolerance
+		})
+	default:
+		return nil, fmt.Errorf("неизвестный вид записей %q", kind)
+	}
+	return collectGroups(kind, items, groups)
+}
+
+func collectGroups(kind string, items []dedupeItem, u *unionFind) ([]DuplicateGroup, error) {
+	byRoot := map[int]*DuplicateGroup{}
+	var ids []int
+	for _, it := range items {
+		_, linked := u.parent[it.id]
+		_, isRoot := u.score[it.id]
+		if !linked && !isRoot {
+			continue // похожих записей нет
+		}
+		root := u.find(it.id)
+		g, ok := byRoot[root]
+		if !ok {
+			g = &DuplicateGroup{Kind: kind, Similarity: u.score[root]}
+			byRoot[root] = g
+		}
+		g.Items = append(g.Items, DuplicateItem{ID: it.id, Label: it.label})
+		ids = append(ids, it.id)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	usage, err := repo.CatalogUsage(kind, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []DuplicateGroup
+	for _, g := range byRoot {
+		for i := range g.Items {
+			g.Items[i].Usage = usage[g.Items[i].ID]
+		}
+		sort.SliceStable(g.Items, func(i, j int) bool { return g.Items[i].Usage > g.Items[j].Usage })
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Items[0].Label) < strings.ToLower(groups[j].Items[0].Label)
+	})
+	return groups, nil
+}
+
+var usageLabels = map[string]string{
+	KindArtist: "альбомов",
+	KindAlbum:  "треков",
+	KindTrack:  "в плейлистах",
+}
+
+func duplicateItemLabel(kind string, it DuplicateItem) string {
+	return fmt.Sprintf("%s — %s: %d", it.Label, usageLabels[kind], it.Usage)
+}
+
+// mergeDuplicates сливает записи ids в keepID. Слияние переписывает ссылки
+// в чужих плейлистах и не отменяется, поэтому история отмены сбрасывается.
+func mergeDuplicates(kind string, keepID int, ids []int) (int, error) {
+	if err := requireCatalogEditor(); err != nil {
+		return 0, err
+	}
+	var merge []int
+	for _, id := range ids {
+		if id != keepID {
+			merge = append(merge, id)
+		}
+	}
+	if len(merge) == 0 {
+		return 0, fmt.Errorf("отметьте хотя бы одну запись для слияния")
+	}
+	n, err := repo.MergeCatalog(kind, keepID, merge)
+	if err != nil {
+		return 0, err
+	}
+	clearHistory()
+	notifyDataChanged()
+	return n, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- CATALOG PAGES ---
+
+const artistTopTracks = 10
+
+type ArtistDetails struct {
+	Artist    Artist
+	Albums    []AlbumRow // по годам
+	TopTracks []TrackPlays
+	Playlists []ArtistPlaylist
+}
+
+type AlbumDetails struct {
+	AlbumRow
+	Tracks    []Track // по номерам
+	CoverPath string  // "" — обложка не найдена
+}
+
+func getArtistDetails(id int) (*ArtistDetails, error) {
+	a, err := repo.GetArtist(id)
+	if err != nil {
+		return nil, err
+	}
+	d := &ArtistDetails{Artist: *a}
+	if d.Albums, err = repo.GetArtistAlbums(id); err != nil {
+		return nil, err
+	}
+	if d.TopTracks, err = repo.GetArtistTopTracks(id, artistTopTracks); err != nil {
+		return nil, err
+	}
+	if d.Playlists, err = repo.GetArtistPlaylists(id, currentUser.ID); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func getAlbumDetails(id int) (*AlbumDetails, error) {
+	a, err := repo.GetAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+	d := &AlbumDetails{AlbumRow: *a}
+	if d.Tracks, err = repo.GetAlbumTracks(id); err != nil {
+		return nil, err
+	}
+	d.CoverPath = findAlbumCover(trackIDs(d.Tracks))
+	return d, nil
+}
+
+// PlaylistDetails — плейлист на странице каталога: сводка и треки с альбомом и артистом
+type PlaylistDetails struct {
+	PlaylistSummary
+	Tracks []TrackInfo
+}
+
+func getPlaylistDetails(id int) (*PlaylistDetails, error) {
+	if err := requirePlaylistPermission(id, PermView); err != nil {
+		return nil, err
+	}
+	s, err := repo.GetPlaylistSummary(id)
+	if err != nil {
+		return nil, err
+	}
+	d := &PlaylistDetails{PlaylistSummary: *s}
+	if d.Tracks, err = repo.GetPlaylistTrackInfos(id); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// albumsByYear делит дискографию на группы одного года, сохраняя порядок
+func albumsByYear(albums []AlbumRow) [][]AlbumRow {
+	var groups [][]AlbumRow
+	for i, a := range albums {
+		if i == 0 || a.Year != albums[i-1].Year {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], a)
+	}
+	return groups
+}
+
+// Обложка ищется в папках с файлами треков альбома: сначала по привычным именам, потом любая картинка
+var coverNames = []string{"cover", "folder", "front", "albumart", "album"}
+
+var imageExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
+
+func findAlbumCover(trackIDs []int) string {
+	infos, err := repo.GetAudioInfo(trackIDs)
+	if err != nil {
+		return ""
+	}
+	seen := map[string]bool{}
+	for _, id := range trackIDs {
+		path := infos[id].FilePath
+		if path == "" || seen[filepath.Dir(path)] {
+			continue
+		}
+		seen[filepath.Dir(path)] = true
+		if cover := findCover(filepath.Dir(path)); cover != "" {
+			return cover
+		}
+	}
+	return ""
+}
+
+func findCover(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	best, bestRank := "", len(coverNames)+1
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || !imageExtensions[ext] {
+			continue
+		}
+		rank := len(coverNames)
+		name := strings.ToLower(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())))
+		for i, n := range coverNames {
+			if name == n {
+				rank = i
+				break
+			}
+		}
+		if rank < bestRank {
+			best, bestRank = filepath.Join(dir, e.Name()), rank
+		}
+	}
+	return best
+}
+
+// setAlbumCredits меняет участников альбома отменяемой командой
+func setAlbumCredits(a Album, credits string) error {
+	credits = strings.TrimSpace(credits)
+	if credits == a.Credits {
+		return nil
+	}
+	return runCommand(&albumCreditsCmd{AlbumID: a.ID, Title: a.Title, Before: a.Credits, After: credits})
+}
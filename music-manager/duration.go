@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Длительности везде хранятся в секундах, показываются как "м:сс",
+// а от часа — как "ч:мм:сс"
+
+func formatDuration(sec int) string {
+	if sec < 0 {
+		return "-" + formatDuration(-sec)
+	}
+	if sec >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", sec/3600, sec/60%60, sec%60)
+	}
+	return fmt.Sprintf("%d:%02d", sec/60, sec%60)
+}
+
+// parseDuration понимает секунды ("245"), "м:сс" ("4:05") и "ч:мм:сс" ("1:02:03");
+// пустая строка — ноль
+func parseDuration(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("длительность %q: ожидаются секунды, м:сс или ч:мм:сс", s)
+	}
+	total := 0
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 0 || (i > 0 && n > 59) {
+			return 0, fmt.Errorf("длительность %q: ожидаются секунды, м:сс или ч:мм:сс", s)
+		}
+		total = total*60 + n
+	}
+	return total, nil
+}
+
+// durationPlaceholder — подсказка для полей ввода длительности
+const durationPlaceholder = "м:сс или ч:мм:сс"
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"path/filepath"
+)
+
+// Акустический отпечаток в духе Chromaprint: звук приводится к моно 11025 Гц,
+// режется на перекрывающиеся кадры, для каждого кадра считается хрома-вектор
+// (энергия по 12 полутонам), а из изменений хромы между соседними полутонами
+// и кадрами собирается 32-битное слово. Отпечатки одной записи из разных рипов
+// (другой битрейт, формат, теги, тишина в начале) почти совпадают побитно.
+
+const (
+	fpSampleRate = 11025
+	fpFrameSize  = 4096
+	fpHop        = fpFrameSize / 3
+	fpMaxSeconds = 120 // как у fpcalc: первых двух минут хватает для опознания
+	fpMinFreq    = 28.0
+	fpMaxFreq    = 3520.0
+	fpMaxOffset  = 80 // сдвиг при сравнении, в кадрах (~10 секунд)
+)
+
+// fft — итеративное БПФ по основанию 2, len(a) — степень двойки
+func fft(a []complex128) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u, v := a[start+k], a[start+k+size/2]*wk
+				a[start+k], a[start+k+size/2] = u+v, u-v
+				wk *= w
+			}
+		}
+	}
+}
+
+// chromagram считает нормированные хрома-векторы кадров, сглаженные по трём соседним кадрам
+func chromagram(samples []float64) [][12]float64 {
+	window := make([]float64, fpFrameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(fpFrameSize-1))
+	}
+	// Полутон каждого частотного отсчёта; -1 — вне диапазона
+	note := make([]int, fpFrameSize/2)
+	for k := range note {
+		freq := float64(k) * fpSampleRate / fpFrameSize
+		note[k] = -1
+		if freq >= fpMinFreq && freq <= fpMaxFreq {
+			n := int(math.Round(12*math.Log2(freq/27.5))) % 12
+			note[k] = (n + 12) % 12
+		}
+	}
+
+	var frames [][12]float64
+	buf := make([]complex128, fpFrameSize)
+	for start := 0; start+fpFrameSize <= len(samples); start += fpHop {
+		for i := range buf {
+			buf[i] = complex(samples[start+i]*window[i], 0)
+		}
+		fft(buf)
+		var c [12]float64
+		for k, n := range note {
+			if n >= 0 {
+				m := cmplx.Abs(buf[k])
+				c[n] += m * m
+			}
+		}
+		frames = append(frames, c)
+	}
+
+	smooth := make([][12]float64, len(frames))
+	for t := range frames {
+		var c [12]float64
+		for d := -1; d <= 1; d++ {
+			if u := t + d; u >= 0 && u < len(frames) {
+				for b := range c {
+					c[b] += frames[u][b]
+				}
+			}
+		}
+		var norm float64
+		for _, v := range c {
+			norm += v * v
+		}
+		if norm = math.Sqrt(norm); norm > 1e-9 {
+			for b := range c {
+				c[b] /= norm
+			}
+		}
+		smooth[t] = c
+	}
+	return smooth
+}
+
+// computeFingerprint — по слову на кадр: 24 бита — знак изменения разности соседних
+// полутонов относительно кадров t-1 и t-2, 8 бит — сравнение полутонов через большую терцию
+func computeFingerprint(samples []float64) []uint32 {
+	chroma := chromagram(samples)
+	if len(chroma) < 3 {
+		return nil
+	}
+	diff := func(c [12]float64, b int) float64 { return c[b] - c[(b+1)%12] }
+	fp := make([]uint32, 0, len(chroma)-2)
+	for t := 2; t < len(chroma); t++ {
+		var w uint32
+		for b := 0; b < 12; b++ {
+			if diff(chroma[t], b)-diff(chroma[t-1], b) > 0 {
+				w |= 1 << b
+			}
+			if diff(chroma[t], b)-diff(chroma[t-2], b) > 0 {
+				w |= 1 << (12 + b)
+			}
+		}
+		for b := 0; b < 8; b++ {
+			if chroma[t][b] > chroma[t][b+4] {
+				w |= 1 << (24 + b)
+			}
+		}
+		fp = append(fp, w)
+	}
+	return fp
+}
+
+func fingerprintFile(path string) ([]uint32, error) {
+	pcm, err := decodePCM(path, fpMaxSeconds)
+	if err != nil {
+		return nil, err
+	}
+	samples := resample(pcm.mono(), pcm.Rate, fpSampleRate)
+	// Тишина в начале у разных рипов разная — отрезаем её, чтобы кадры совпали
+	start := 0
+	for start < len(samples) && math.Abs(samples[start]) < 1e-3 {
+		start++
+	}
+	fp := computeFingerprint(samples[start:])
+	if len(fp) < 16 {
+		return nil, fmt.Errorf("%s: запись слишком короткая для отпечатка", filepath.Base(path))
+	}
+	return fp, nil
+}
+
+// compareFingerprints возвращает сходство в процентах: долю совпавших бит при лучшем сдвиге.
+// Считаются только сдвиги, при которых перекрывается хотя бы половина более короткого отпечатка.
+func compareFingerprints(a, b []uint32) int {
+	shorter := min(len(a), len(b))
+	if shorter == 0 {
+		return 0
+	}
+	best := 0
+	for off := -fpMaxOffset; off <= fpMaxOffset; off++ {
+		var errBits, n int
+		for i := range a {
+			j := i + off
+			if j < 0 || j >= len(b) {
+				continue
+			}
+			errBits += bits.OnesCount32(a[i] ^ b[j])
+			n++
+		}
+		if n*2 < shorter {
+			continue
+		}
+		if s := 100 - 100*errBits/(32*n); s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+func encodeFingerprint(fp []uint32) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, fp)
+	return buf.Bytes()
+}
+
+func decodeFingerprint(b []byte) []uint32 {
+	fp := make([]uint32, len(b)/4)
+	for i := range fp {
+		fp[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return fp
+}
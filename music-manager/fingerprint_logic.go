@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// --- FINGERPRINTS ---
+// Отпечатки снимаются с файлов из папки: файл сопоставляется треку каталога
+// по имени и папкам (Артист/Альбом/NN Название.ext), а дальше треки сравниваются
+// уже только по звучанию — теги и названия на это не влияют.
+
+// Порог сходства отпечатков в процентах: у разных рипов одной записи — за 90,
+// у разных записей — около 50
+var fingerprintSimilarity = envInt("FINGERPRINT_SIMILARITY", 85)
+
+// Слова отпечатка, которые встречаются у многих треков (тишина, шум), не годятся для поиска пар
+const fpCommonWordLimit = 50
+
+// Сколько слов отпечатка должно совпасть целиком, чтобы пару стоило сравнивать полностью
+const fpMinSharedWords = 3
+
+type FingerprintReport struct {
+	Files     int
+	Matched   int
+	Unmatched []string // файлы, для которых не нашёлся трек
+	Failed    []string // файлы, которые не удалось декодировать, с причиной
+}
+
+// Ведущий номер трека: "01 ", "1. ", "01 - ", "A1 "
+var leadingTrackNumber = regexp.MustCompile(`^[A-Da-d]?\d{1,3}\s*[-._)]?\s+`)
+
+// fileTrackHints разбирает путь: название трека из имени файла, альбом и артист — из папок.
+// Имя вида "Артист - Название" тоже понимается.
+func fileTrackHints(path string) (artist, album, title string) {
+	title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	title = leadingTrackNumber.ReplaceAllString(title, "")
+	dir := filepath.Dir(path)
+	album = filepath.Base(dir)
+	artist = filepath.Base(filepath.Dir(dir))
+	if i := strings.LastIndex(title, " - "); i >= 0 {
+		artist, title = title[:i], title[i+3:]
+	}
+	return normalizeName(artist), normalizeName(album), normalizeName(title)
+}
+
+// matchFileToTrack выбирает трек с похожим названием; при равенстве решают совпадения альбома и артиста
+func matchFileToTrack(path string, infos []TrackInfo) (TrackInfo, bool) {
+	artist, album, title := fileTrackHints(path)
+	var best TrackInfo
+	bestScore := -1
+	for _, t := range infos {
+		s := similarity(title, normalizeName(t.Title))
+		if s < duplicateSimilarity {
+			continue
+		}
+		score := 4*s + similarity(album, normalizeName(t.AlbumTitle)) + similarity(artist, normalizeName(t.ArtistName))
+		if score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// fingerprintFolder снимает отпечатки со всех аудиофайлов папки и сохраняет их трекам.
+// progress вызывается после каждого файла.
+func fingerprintFolder(root string, progress func(done, total int)) (*FingerprintReport, error) {
+	if err := requireCatalogEditor(); err != nil {
+		return nil, err
+	}
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && audioExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	tracks, err := repo.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := repo.GetTrackInfos(trackIDs(tracks))
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &FingerprintReport{Files: len(files)}
+	for i, path := range files {
+		rel, _ := filepath.Rel(root, path)
+		if t, ok := matchFileToTrack(path, infos); !ok {
+			rep.Unmatched = append(rep.Unmatched, rel)
+		} else if fp, err := fingerprintFile(path); err != nil {
+			rep.Failed = append(rep.Failed, fmt.Sprintf("%s: %v", rel, err))
+		} else if err := repo.SaveFingerprint(t.ID, path, fp); err != nil {
+			return rep, err
+		} else {
+			rep.Matched++
+		}
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+	}
+	return rep, nil
+}
+
+func describeFingerprintReport(rep *FingerprintReport) string {
+	s := fmt.Sprintf("Файлов: %d. Отпечатки сохранены: %d.", rep.Files, rep.Matched)
+	if len(rep.Unmatched) > 0 {
+		s += fmt.Sprintf("\nНе найден трек в каталоге (%d):\n  %s", len(rep.Unmatched), strings.Join(rep.Unmatched, "\n  "))
+	}
+	if len(rep.Failed) > 0 {
+		s += fmt.Sprintf("\nНе удалось обработать (%d):\n  %s", len(rep.Failed), strings.Join(rep.Failed, "\n  "))
+	}
+	return s
+}
+
+// acousticPairs находит пары треков с похожими отпечатками. Полное сравнение со сдвигом
+// дорогое, поэтому сравниваем только пары, у которых совпало несколько слов отпечатка целиком.
+func acousticPairs(fps map[int][]uint32, visit func(a, b, score int)) {
+	index := map[uint32][]int{}
+	for id, fp := range fps {
+		seen := map[uint32]bool{}
+		for _, w := range fp {
+			if !seen[w] {
+				seen[w] = true
+				index[w] = append(index[w], id)
+			}
+		}
+	}
+	shared := map[[2]int]int{}
+	for _, ids := range index {
+		if len(ids) < 2 || len(ids) > fpCommonWordLimit {
+			continue
+		}
+		for i := range ids {
+			for j := i + 1; j < len(ids); j++ {
+				a, b := min(ids[i], ids[j]), max(ids[i], ids[j])
+				shared[[2]int{a, b}]++
+			}
+		}
+	}
+	for pair, n := range shared {
+		if n < fpMinSharedWords {
+			continue
+		}
+		if s := compareFingerprints(fps[pair[0]], fps[pair[1]]); s >= fingerprintSimilarity {
+			visit(pair[0], pair[1], s)
+		}
+	}
+}
+
+// findAcousticDuplicates группирует треки, звучащие одинаково, независимо от названий и альбомов
+func findAcousticDuplicates() ([]DuplicateGroup, error) {
+	if err := requireCatalogEditor(); err != nil {
+		return nil, err
+	}
+	fps, err := repo.GetFingerprints(nil)
+	if err != nil {
+		return nil, err
+	}
+	u := newUnionFind()
+	acousticPairs(fps, u.union)
+
+	var ids []int
+	for id := range fps {
+		ids = append(ids, id)
+	}
+	infos, err := repo.GetTrackInfos(ids)
+	if err != nil {
+		return nil, err
+	}
+	var items []dedupeItem
+	for _, t := range infos {
+		items = append(items, dedupeItem{
+			id: t.ID,
+			label: fmt.Sprintf("%s (%s) — %s / %s",
+				t.Title, formatDuration(t.Duration), t.ArtistName, t.AlbumTitle),
+		})
+	}
+	return collectGroups(KindTrack, items, u)
+}
+
+// --- PLAYLIST BUILDING ---
+
+// fingerprintIndex — отпечатки треков плейлиста с индексом по словам. Полное сравнение
+// выполняется только с треками, у которых совпало несколько слов, как в acousticPairs.
+type fingerprintIndex struct {
+	fps   map[int][]uint32
+	words map[uint32][]int
+}
+
+func newFingerprintIndex(fps map[int][]uint32, ids []int) *fingerprintIndex {
+	x := &fingerprintIndex{fps: fps, words: map[uint32][]int{}}
+	for _, id := range ids {
+		x.add(id)
+	}
+	return x
+}
+
+func (x *fingerprintIndex) add(id int) {
+	seen := map[uint32]bool{}
+	for _, w := range x.fps[id] {
+		if !seen[w] {
+			seen[w] = true
+			x.words[w] = append(x.words[w], id)
+		}
+	}
+}
+
+// soundsLike возвращает проиндексированные треки, звучащие как трек id (кроме него самого)
+func (x *fingerprintIndex) soundsLike(id int) []int {
+	fp, ok := x.fps[id]
+	if !ok {
+		return nil
+	}
+	shared := map[int]int{}
+	seen := map[uint32]bool{}
+	for _, w := range fp {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		if ids := x.words[w]; len(ids) <= fpCommonWordLimit {
+			for _, c := range ids {
+				shared[c]++
+			}
+		}
+	}
+	var same []int
+	for c, n := range shared {
+		if c != id && n >= fpMinSharedWords && compareFingerprints(fp, x.fps[c]) >= fingerprintSimilarity {
+			same = append(same, c)
+		}
+	}
+	return same
+}
+
+// acousticDuplicateInPlaylist возвращает трек плейлиста, который звучит как trackID
+func acousticDuplicateInPlaylist(pID, trackID int) (*Track, error) {
+	entries, err := repo.GetTracksFromPlaylist(pID)
+	if err != nil {
+		return nil, err
+	}
+	fps, err := repo.GetFingerprints(append(trackIDs(entries), trackID))
+	if err != nil {
+		return nil, err
+	}
+	same := newFingerprintIndex(fps, trackIDs(entries)).soundsLike(trackID)
+	if len(same) == 0 {
+		return nil, nil
+	}
+	for _, t := range entries {
+		if t.ID == same[0] {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+// skipAcousticDuplicates убирает из ids треки, которые звучат как уже добавленные
+// в плейлист или как стоящие раньше в том же списке. Долгая при больших плейлистах —
+// интерфейс вызывает её вне основного потока.
+func skipAcousticDuplicates(pID int, ids []int) ([]int, error) {
+	entries, err := repo.GetTracksFromPlaylist(pID)
+	if err != nil {
+		return nil, err
+	}
+	present := trackIDs(entries)
+	fps, err := repo.GetFingerprints(append(present, ids...))
+	if err != nil {
+		return nil, err
+	}
+	index := newFingerprintIndex(fps, present)
+	var keep []int
+	for _, id := range ids {
+		if len(index.soundsLike(id)) == 0 {
+			keep = append(keep, id)
+			index.add(id)
+		}
+	}
+	return keep, nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"math/rand"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Узлы дерева плейлистов: "" — корень, "folder:<id>", "playlist:<id>",
+// разделы чужих плейлистов "section:<имя>" с узлами "<имя>:<id>"
+const (
+	sectionShared   = "shared"
+	sectionFollowed = "followed"
+)
+
+func folderNode(id int) string   { return "folder:" + strconv.Itoa(id) }
+func playlistNode(id int) string { return "playlist:" + strconv.Itoa(id) }
+
+type playlistTree struct {
+	children  map[string][]string
+	labels    map[string]string
+	playlists map[string]Playlist
+	folders   map[int]PlaylistFolder
+}
+
+// buildPlaylistTree раскладывает свои плейлисты по папкам, а доступные и отслеживаемые — по разделам
+func buildPlaylistTree(owned []Playlist, folders []PlaylistFolder, sections map[string][]Playlist) *playlistTree {
+	t := &playlistTree{
+		children:  map[string][]string{},
+		labels:    map[string]string{},
+		playlists: map[string]Playlist{},
+		folders:   map[int]PlaylistFolder{},
+	}
+	for _, f := range folders {
+		t.folders[f.ID] = f
+	}
+	for _, f := range folders {
+		parent := ""
+		if _, ok := t.folders[f.ParentID]; ok {
+			parent = folderNode(f.ParentID)
+		}
+		node := folderNode(f.ID)
+		t.children[parent] = append(t.children[parent], node)
+		t.labels[node] = f.Name
+	}
+	for _, p := range owned {
+		parent := ""
+		if _, ok := t.folders[p.FolderID]; ok {
+			parent = folderNode(p.FolderID)
+		}
+		node := playlistNode(p.ID)
+		t.children[parent] = append(t.children[parent], node)
+		t.labels[node] = p.Title
+		t.playlists[node] = p
+	}
+	for _, name := range []string{sectionShared, sectionFollowed} {
+		items := sections[name]
+		if len(items) == 0 {
+			continue
+		}
+		section := "section:" + name
+		t.children[""] = append(t.children[""], section)
+		t.labels[section] = sectionLabels[name]
+		for _, p := range items {
+			node := name + ":" + strconv.Itoa(p.ID)
+			t.children[section] = append(t.children[section], node)
+			t.labels[node] = playlistLabel(p)
+			t.playlists[node] = p
+		}
+	}
+	return t
+}
+
+var sectionLabels = map[string]string{
+	sectionShared:   "Доступные мне",
+	sectionFollowed: "Подписки",
+}
+
+func (t *playlistTree) isBranch(node string) bool {
+	return node == "" || strings.HasPrefix(node, "folder:") || strings.HasPrefix(node, "section:")
+}
+
+// folderID возвращает ID папки узла (0 и false — не папка)
+func (t *playlistTree) folderID(node string) (int, bool) {
+	if !strings.HasPrefix(node, "folder:") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(node, "folder:"))
+	return id, err == nil
+}
+
+// folderPath — путь папки от корня, например "Рок / 80-е"
+func (t *playlistTree) folderPath(id int) string {
+	var parts []string
+	for seen := map[int]bool{}; id != 0 && !seen[id]; {
+		seen[id] = true
+		f, ok := t.folders[id]
+		if !ok {
+			break
+		}
+		parts = append([]string{f.Name}, parts...)
+		id = f.ParentID
+	}
+	return strings.Join(parts, " / ")
+}
+
+// isDescendant — лежит ли папка id внутри ancestor (или совпадает с ней)
+func (t *playlistTree) isDescendant(id, ancestor int) bool {
+	for seen := map[int]bool{}; id != 0 && !seen[id]; id = t.folders[id].ParentID {
+		if id == ancestor {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
+}
+
+// folderChoices — все папки для выбора места назначения (0 — корень), кроме exclude и её потомков
+func (t *playlistTree) folderChoices(exclude int) ([]int, []string) {
+	type choice struct {
+		id   int
+		name string
+	}
+	var choices []choice
+	for id := range t.folders {
+		if exclude != 0 && t.isDescendant(id, exclude) {
+			continue
+		}
+		choices = append(choices, choice{id, "/ " + t.folderPath(id)})
+	}
+	sort.Slice(choices, func(i, j int) bool { return choices[i].name < choices[j].name })
+
+	ids := []int{0}
+	names := []string{"/ (корень)"}
+	for _, c := range choices {
+		ids = append(ids, c.id)
+		names = append(names, c.name)
+	}
+	return ids, names
+}
+
+// folderPlaylists собирает плейлисты папки и всех вложенных папок в порядке обхода дерева
+// вместе с относительным путём папки
+func (t *playlistTree) folderPlaylists(folderID int) ([]Playlist, []string) {
+	var items []Playlist
+	var dirs []string
+	var walk func(node, dir string)
+	walk = func(node, dir string) {
+		for _, child := range t.children[node] {
+			if p, ok := t.playlists[child]; ok && strings.HasPrefix(child, "playlist:") {
+				items = append(items, p)
+				dirs = append(dirs, dir)
+			} else if id, ok := t.folderID(child); ok {
+				walk(child, path.Join(dir, safeFileName(t.folders[id].Name)))
+			}
+		}
+	}
+	walk(folderNode(folderID), "")
+	return items, dirs
+}
+
+// --- FOLDERS ---
+
+func getFolders() []PlaylistFolder {
+	items, err := repo.GetFolders(currentUser.ID)
+	if err != nil {
+		return nil
+	}
+	return items
+}
+
+func createFolder(name string, parentID int) error {
+	if name == "" {
+		return fmt.Errorf("название папки не может быть пустым")
+	}
+	return repo.CreateFolder(currentUser.ID, name, parentID)
+}
+
+func renameFolder(id int, name string) error {
+	if name == "" {
+		return fmt.Errorf("название папки не может быть пустым")
+	}
+	return repo.RenameFolder(currentUser.ID, id, name)
+}
+
+func deleteFolder(id int) error {
+	return repo.DeleteFolder(currentUser.ID, id)
+}
+
+func moveFolder(t *playlistTree, id, parentID int) error {
+	if parentID != 0 && t.isDescendant(parentID, id) {
+		return fmt.Errorf("нельзя переместить папку внутрь самой себя")
+	}
+	return repo.MoveFolder(currentUser.ID, id, parentID)
+}
+
+func movePlaylistToFolder(pID, folderID int) error {
+	if err := requirePlaylistPermission(pID, PermOwner); err != nil {
+		return err
+	}
+	return repo.MovePlaylistToFolder(currentUser.ID, pID, folderID)
+}
+
+// Порядок воспроизведения папки целиком
+const (
+	PlayOrderSequential = "По порядку"
+	PlayOrderShuffle    = "Перемешать"
+	PlayOrderUnique     = "По порядку, без повторов"
+)
+
+var playOrderOptions = []string{PlayOrderSequential, PlayOrderShuffle, PlayOrderUnique}
+
+// folderPlayQueue — очередь "воспроизвести всё": треки всех плейлистов папки с учётом вложенности
+func folderPlayQueue(t *playlistTree, folderID int, order string) ([]TrackInfo, error) {
+	playlists, _ := t.folderPlaylists(folderID)
+	var queue []TrackInfo
+	seen := map[int]bool{}
+	for _, p := range playlists {
+		tracks, err := repo.GetPlaylistTrackInfos(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, tr := range tracks {
+			if order == PlayOrderUnique && seen[tr.ID] {
+				continue
+			}
+			seen[tr.ID] = true
+			queue = append(queue, tr)
+		}
+	}
+	if order == PlayOrderShuffle {
+		rand.Shuffle(len(queue), func(i, j int) { queue[i], queue[j] = queue[j], queue[i] })
+	}
+	return queue, nil
+}
+
+// exportFolderZip пишет zip-архив с M3U-файлом на каждый плейлист, сохраняя структуру папок
+func exportFolderZip(t *playlistTree, folderID int, w io.Writer) error {
+	playlists, dirs := t.folderPlaylists(folderID)
+	if len(playlists) == 0 {
+		return fmt.Errorf("в папке нет плейлистов")
+	}
+	zw := zip.NewWriter(w)
+	used := map[string]bool{}
+	for i, p := range playlists {
+		tracks, err := repo.GetPlaylistTrackInfos(p.ID)
+		if err != nil {
+			return err
+		}
+		if err := attachAudioInfo(tracks); err != nil {
+			return err
+		}
+		name := path.Join(dirs[i], safeFileName(p.Title)+".m3u")
+		for n := 2; used[name]; n++ {
+			name = path.Join(dirs[i], fmt.Sprintf("%s (%d).m3u", safeFileName(p.Title), n))
+		}
+		used[name] = true
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if err := writeM3U(f, p.Title, tracks); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Форматы экспорта одного плейлиста
+const (
+	ExportM3U  = "m3u"
+	ExportXSPF = "xspf"
+)
+
+// exportPlaylist пишет плейлист в M3U или XSPF с путями к файлам и значениями ReplayGain
+func exportPlaylist(p Playlist, format string, w io.Writer) error {
+	tracks, err := repo.GetPlaylistTrackInfos(p.ID)
+	if err != nil {
+		return err
+	}
+	if err := attachAudioInfo(tracks); err != nil {
+		return err
+	}
+	if format == ExportXSPF {
+		return writeXSPF(w, p.Title, tracks)
+	}
+	return writeM3U(w, p.Title, tracks)
+}
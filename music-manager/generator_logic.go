@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// --- PLAYLIST BY DURATION ---
+// Генератор набирает треки случайными проходами по подходящим трекам: каждый проход
+// добавляет треки, пока сумма не войдёт в допуск, а если перескочил — пробует
+// заменить последний трек подходящим по длительности. Лучший из проходов — результат.
+
+const generatorAttempts = 300
+
+// GenerateOptions — условия генерации; пустые списки и нули не ограничивают
+type GenerateOptions struct {
+	Target    int // секунды
+	Tolerance int // допустимое отклонение, секунды
+	Genres    []string
+	ArtistIDs []int
+	MinLength int // длительность трека, секунды
+	MaxLength int
+	ArtistGap int // один артист не повторяется в пределах стольких треков
+}
+
+type genCandidate struct {
+	Track
+	ArtistID int
+}
+
+type GeneratedPlaylist struct {
+	Tracks []TrackInfo
+	Total  int
+	Fits   bool // сумма в пределах допуска
+}
+
+// generatorPool отбирает треки, подходящие под жанры, артистов и длительность
+func generatorPool(tracks []Track, artists map[int]int, opts GenerateOptions) []genCandidate {
+	genres := map[string]bool{}
+	for _, g := range opts.Genres {
+		genres[strings.ToLower(g)] = true
+	}
+	allowed := map[int]bool{}
+	for _, id := range opts.ArtistIDs {
+		allowed[id] = true
+	}
+	var pool []genCandidate
+	for _, t := range tracks {
+		switch {
+		case t.Duration <= 0,
+			len(genres) > 0 && !genres[strings.ToLower(t.Genre)],
+			len(allowed) > 0 && !allowed[artists[t.ID]],
+			opts.MinLength > 0 && t.Duration < opts.MinLength,
+			opts.MaxLength > 0 && t.Duration > opts.MaxLength:
+			continue
+		}
+		pool = append(pool, genCandidate{Track: t, ArtistID: artists[t.ID]})
+	}
+	return pool
+}
+
+// artistAllowed — не встречается ли артист среди последних gap треков
+func artistAllowed(seq []genCandidate, artistID, gap int) bool {
+	for i := len(seq) - 1; i >= 0 && i >= len(seq)-gap; i-- {
+		if seq[i].ArtistID == artistID {
+			return false
+		}
+	}
+	return true
+}
+
+// fillToDuration — один проход генератора по пулу в порядке order
+func fillToDuration(pool []genCandidate, order []int, opts GenerateOptions) ([]genCandidate, int) {
+	lo, hi := opts.Target-opts.Tolerance, opts.Target+opts.Tolerance
+	used := map[int]bool{}
+	var seq []genCandidate
+	total := 0
+	for _, i := range order {
+		c := pool[i]
+		if total >= lo {
+			break
+		}
+		if total+c.Duration > hi || !artistAllowed(seq, c.ArtistID, opts.ArtistGap) {
+			continue
+		}
+		seq = append(seq, c)
+		used[i] = true
+		total += c.Duration
+	}
+	if total >= lo || len(seq) == 0 {
+		return seq, total
+	}
+	// Недобрали: меняем последний трек на более длинный, который закрывает разрыв
+	last := seq[len(seq)-1]
+	rest := seq[:len(seq)-1]
+	for _, i := range order {
+		c := pool[i]
+		t := total - last.Duration + c.Duration
+		if !used[i] && t >= lo && t <= hi && artistAllowed(rest, c.ArtistID, opts.ArtistGap) {
+			return append(rest, c), t
+		}
+	}
+	return seq, total
+}
+
+// generateToDuration возвращает лучший из generatorAttempts проходов: сначала в допуске,
+// затем с наименьшим отклонением
+func generateToDuration(pool []genCandidate, opts GenerateOptions, rng *rand.Rand) ([]genCandidate, int) {
+	var best []genCandidate
+	bestTotal, bestDiff := 0, -1
+	for a := 0; a < generatorAttempts && bestDiff != 0; a++ {
+		seq, total := fillToDuration(pool, rng.Perm(len(pool)), opts)
+		diff := abs(total - opts.Target)
+		if diff <= opts.Tolerance {
+			diff = 0 // в допуске все варианты равны — берём первый, он случайный
+		}
+		if bestDiff < 0 || diff < bestDiff {
+			best, bestTotal, bestDiff = seq, total, diff
+		}
+	}
+	return best, bestTotal
+}
+
+// generatePlaylist подбирает треки каталога под длительность; сохраняет результат saveGeneratedPlaylist
+func generatePlaylist(opts GenerateOptions) (*GeneratedPlaylist, error) {
+	if opts.Target <= 0 {
+		return nil, fmt.Errorf("укажите длительность плейлиста")
+	}
+	if opts.Tolerance < 0 {
+		return nil, fmt.Errorf("допуск не может быть отрицательным")
+	}
+	if opts.MaxLength > 0 && opts.MinLength > opts.MaxLength {
+		return nil, fmt.Errorf("минимальная длина трека больше максимальной")
+	}
+	tracks, err := repo.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+	artists, err := repo.GetTrackArtists()
+	if err != nil {
+		return nil, err
+	}
+	pool := generatorPool(tracks, artists, opts)
+	if len(pool) == 0 {
+		return nil, fmt.Errorf("нет треков, подходящих под условия")
+	}
+	seq, total := generateToDuration(pool, opts, rand.New(rand.NewSource(nowFunc().UnixNano())))
+	ids := make([]int, len(seq))
+	for i, c := range seq {
+		ids[i] = c.ID
+	}
+	infos, err := repo.GetTrackInfos(ids)
+	if err != nil {
+		return nil, err
+	}
+	return &GeneratedPlaylist{Tracks: infos, Total: total, Fits: abs(total-opts.Target) <= opts.Tolerance}, nil
+}
+
+// saveGeneratedPlaylist сохраняет сгенерированные треки новым плейлистом; сохранение можно отменить
+func saveGeneratedPlaylist(title string, g *GeneratedPlaylist) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", fmt.Errorf("название плейлиста не может быть пустым")
+	}
+	ids := make([]int, len(g.Tracks))
+	for i, t := range g.Tracks {
+		ids[i] = t.ID
+	}
+	title = uniquePlaylistTitle(title)
+	return title, runCommand(&createPlaylistCmd{Title: title, TrackIDs: ids})
+}
+
+// trackGenres — жанры каталога без повторов, по алфавиту
+func trackGenres(tracks []Track) []string {
+	seen := map[string]bool{}
+	var genres []string
+	for _, t := range tracks {
+		if g := strings.TrimSpace(t.Genre); g != "" && !seen[strings.ToLower(g)] {
+			seen[strings.ToLower(g)] = true
+			genres = append(genres, g)
+		}
+	}
+	sort.Strings(genres)
+	return genres
+}
@@ -7,6 +7,7 @@ toolchain go1.24.11
 require (
 	fyne.io/fyne/v2 v2.7.1
 	github.com/lib/pq v1.10.9
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	golang.org/x/crypto v0.46.0
 )
 
@@ -32,6 +32,38 @@ func listRowWithDelete(title string, onDelete func()) fyne.CanvasObject {
 	return container.NewHBox(label, layout.NewSpacer(), deleteBtn)
 }
 
+func artistIDs(items []Artist) []int {
+	ids := make([]int, len(items))
+	for i, a := range items {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func albumIDs(items []Album) []int {
+	ids := make([]int, len(items))
+	for i, a := range items {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func trackIDs(items []Track) []int {
+	ids := make([]int, len(items))
+	for i, t := range items {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func setEnabled(w fyne.Disableable, enabled bool) {
+	if enabled {
+		w.Enable()
+	} else {
+		w.Disable()
+	}
+}
+
 func execInsert(q string, args ...interface{}) error { // функция для insertзапросов
 	_, err := db.Exec(q, args...)
 	return err
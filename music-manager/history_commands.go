@@ -0,0 +1,413 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Команды истории правок. Поля экспортируются, чтобы команда сохранялась в JSON;
+// снимки удалённых строк заполняются при выполнении и нужны для отката.
+
+func init() {
+	registerCommand(func() command { return &createCatalogCmd{} })
+	registerCommand(func() command { return &deleteCatalogCmd{} })
+	registerCommand(func() command { return &editCatalogCmd{} })
+	registerCommand(func() command { return &metadataCmd{} })
+	registerCommand(func() command { return &albumCreditsCmd{} })
+	registerCommand(func() command { return &createPlaylistCmd{} })
+	registerCommand(func() command { return &deletePlaylistCmd{} })
+	registerCommand(func() command { return &addEntriesCmd{} })
+	registerCommand(func() command { return &removeEntriesCmd{} })
+	registerCommand(func() command { return &reorderEntriesCmd{} })
+}
+
+var kindNames = map[string]string{
+	KindArtist: "артиста",
+	KindAlbum:  "альбома",
+	KindTrack:  "трека",
+}
+
+// deleteCatalog удаляет записи без проверок — их делают команды
+func deleteCatalog(kind string, ids []int) error {
+	switch kind {
+	case KindArtist:
+		return repo.DeleteArtists(ids)
+	case KindAlbum:
+		return repo.DeleteAlbums(ids)
+	case KindTrack:
+		return repo.DeleteTracks(ids)
+	}
+	return fmt.Errorf("неизвестный вид записей %q", kind)
+}
+
+// --- CATALOG ---
+
+// createCatalogCmd создаёт артиста, альбом или трек. ParentID — артист альбома
+// или альбом трека, Number — год альбома или длительность трека.
+type createCatalogCmd struct {
+	Kind     string
+	Title    string
+	ParentID int
+	Number   int
+	ID       int
+	Snap     *Snapshot `json:",omitempty"` // удалённое при отмене, вместе с тем, что успели добавить
+}
+
+func (c *createCatalogCmd) op() string { return "catalog.create" }
+func (c *createCatalogCmd) label() string {
+	return fmt.Sprintf("создание %s «%s»", kindNames[c.Kind], c.Title)
+}
+
+func (c *createCatalogCmd) do() error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	if c.Snap != nil {
+		if err := repo.RestoreSnapshot(c.Snap); err != nil {
+			return err
+		}
+		c.Snap = nil
+		return nil
+	}
+	var err error
+	switch c.Kind {
+	case KindArtist:
+		c.ID, err = repo.CreateArtist(c.Title)
+	case KindAlbum:
+		c.ID, err = repo.CreateAlbum(c.Title, c.ParentID, c.Number)
+	case KindTrack:
+		c.ID, err = repo.CreateTrack(c.Title, c.ParentID, c.Number)
+	default:
+		err = fmt.Errorf("неизвестный вид записей %q", c.Kind)
+	}
+	return err
+}
+
+func (c *createCatalogCmd) undo() error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	snap, err := repo.SnapshotCatalog(c.Kind, []int{c.ID})
+	if err != nil {
+		return err
+	}
+	if err := deleteCatalog(c.Kind, []int{c.ID}); err != nil {
+		return err
+	}
+	c.Snap = snap
+	return nil
+}
+
+// deleteCatalogCmd удаляет записи каталога; откат возвращает их со всеми зависимыми строками
+type deleteCatalogCmd struct {
+	Kind string
+	IDs  []int
+	Snap *Snapshot `json:",omitempty"`
+}
+
+func (c *deleteCatalogCmd) op() string { return "catalog.delete" }
+func (c *deleteCatalogCmd) label() string {
+	return fmt.Sprintf("удаление %s: %d", kindLabels[c.Kind], len(c.IDs))
+}
+
+func (c *deleteCatalogCmd) do() error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	// Проверяем ещё раз: за время подтверждения треки могли добавить в чужие плейлисты
+	if blockForeignPlaylistDeletes {
+		if _, err := catalogDeleteImpact(c.Kind, c.IDs); err != nil {
+			return err
+		}
+	}
+	snap, err := repo.SnapshotCatalog(c.Kind, c.IDs)
+	if err != nil {
+		return err
+	}
+	if err := deleteCatalog(c.Kind, c.IDs); err != nil {
+		return err
+	}
+	c.Snap = snap
+	return nil
+}
+
+func (c *deleteCatalogCmd) undo() error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	if c.Snap == nil {
+		return fmt.Errorf("нет сохранённой копии удалённых записей")
+	}
+	return repo.RestoreSnapshot(c.Snap)
+}
+
+// CatalogFields — изменяемые поля записи: название и год альбома или длительность трека
+type CatalogFields struct {
+	Title  string
+	Number int
+}
+
+type editCatalogCmd struct {
+	Kind          string
+	ID            int
+	Before, After CatalogFields
+}
+
+func (c *editCatalogCmd) op() string { return "catalog.edit" }
+func (c *editCatalogCmd) label() string {
+	return fmt.Sprintf("изменение %s «%s»", kindNames[c.Kind], c.After.Title)
+}
+
+func (c *editCatalogCmd) apply(f CatalogFields) error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	switch c.Kind {
+	case KindArtist:
+		return repo.UpdateArtist(c.ID, f.Title)
+	case KindAlbum:
+		return repo.UpdateAlbum(c.ID, f.Title, f.Number)
+	case KindTrack:
+		return repo.UpdateTrack(c.ID, f.Title, f.Number)
+	}
+	return fmt.Errorf("неизвестный вид записей %q", c.Kind)
+}
+
+func (c *editCatalogCmd) do() error   { return c.apply(c.After) }
+func (c *editCatalogCmd) undo() error { return c.apply(c.Before) }
+
+// albumCreditsCmd меняет участников альбома
+type albumCreditsCmd struct {
+	AlbumID       int
+	Title         string
+	Before, After string
+}
+
+func (c *albumCreditsCmd) op() string { return "catalog.credits" }
+func (c *albumCreditsCmd) label() string {
+	return fmt.Sprintf("участники альбома «%s»", c.Title)
+}
+
+func (c *albumCreditsCmd) apply(credits string) error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	return repo.SetAlbumCredits(c.AlbumID, credits)
+}
+
+func (c *albumCreditsCmd) do() error   { return c.apply(c.After) }
+func (c *albumCreditsCmd) undo() error { return c.apply(c.Before) }
+
+// metadataCmd применяет правки, принятые из сервиса метаданных
+type metadataCmd struct {
+	Changes []MetadataChange
+}
+
+func (c *metadataCmd) op() string { return "catalog.metadata" }
+func (c *metadataCmd) label() string {
+	return fmt.Sprintf("уточнение метаданных: %d правок", len(c.Changes))
+}
+
+func (c *metadataCmd) do() error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	return repo.ApplyMetadata(c.Changes, false)
+}
+
+func (c *metadataCmd) undo() error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	return repo.ApplyMetadata(c.Changes, true)
+}
+
+// --- PLAYLISTS ---
+
+// createPlaylistCmd создаёт плейлист; с TrackIDs — сразу с треками (радио, генератор)
+type createPlaylistCmd struct {
+	Title    string
+	TrackIDs []int `json:",omitempty"`
+	ID       int
+	Snap     *Snapshot `json:",omitempty"`
+}
+
+func (c *createPlaylistCmd) op() string { return "playlist.create" }
+func (c *createPlaylistCmd) label() string {
+	return fmt.Sprintf("создание плейлиста «%s»", c.Title)
+}
+
+func (c *createPlaylistCmd) do() error {
+	if c.Snap != nil {
+		if err := repo.RestoreSnapshot(c.Snap); err != nil {
+			return err
+		}
+		c.Snap = nil
+		return nil
+	}
+	var err error
+	if len(c.TrackIDs) > 0 {
+		c.ID, err = repo.CreatePlaylistWithTracks(currentUser.ID, c.Title, c.TrackIDs)
+		return err
+	}
+	c.ID, err = repo.CreatePlaylist(c.Title, currentUser.ID)
+	return err
+}
+
+func (c *createPlaylistCmd) undo() error {
+	if err := requirePlaylistPermission(c.ID, PermOwner); err != nil {
+		return err
+	}
+	snap, err := repo.SnapshotPlaylists([]int{c.ID})
+	if err != nil {
+		return err
+	}
+	if err := repo.DeletePlaylist(c.ID); err != nil {
+		return err
+	}
+	c.Snap = snap
+	return nil
+}
+
+// deletePlaylistCmd удаляет плейлист; откат возвращает треки, участников и подписчиков
+type deletePlaylistCmd struct {
+	ID    int
+	Title string
+	Snap  *Snapshot `json:",omitempty"`
+}
+
+func (c *deletePlaylistCmd) op() string { return "playlist.delete" }
+func (c *deletePlaylistCmd) label() string {
+	return fmt.Sprintf("удаление плейлиста «%s»", c.Title)
+}
+
+func (c *deletePlaylistCmd) do() error {
+	if err := requirePlaylistPermission(c.ID, PermOwner); err != nil {
+		return err
+	}
+	snap, err := repo.SnapshotPlaylists([]int{c.ID})
+	if err != nil {
+		return err
+	}
+	if len(snap.Playlists) > 0 {
+		c.Title = snap.Playlists[0].Title
+	}
+	if err := repo.DeletePlaylist(c.ID); err != nil {
+		return err
+	}
+	c.Snap = snap
+	return nil
+}
+
+func (c *deletePlaylistCmd) undo() error {
+	if c.Snap == nil {
+		return fmt.Errorf("нет сохранённой копии плейлиста")
+	}
+	for _, p := range c.Snap.Playlists {
+		if p.UserID != currentUser.ID {
+			return errForbidden
+		}
+	}
+	return repo.RestoreSnapshot(c.Snap)
+}
+
+// addEntriesCmd добавляет треки в плейлист; откат убирает только те, что действительно добавились
+type addEntriesCmd struct {
+	PlaylistID int
+	TrackIDs   []int
+	Added      []int
+	version    int
+}
+
+func (c *addEntriesCmd) op() string { return "entries.add" }
+func (c *addEntriesCmd) label() string {
+	return fmt.Sprintf("добавление треков в плейлист: %d", len(c.TrackIDs))
+}
+
+func (c *addEntriesCmd) do() error {
+	if err := requirePlaylistPermission(c.PlaylistID, PermAdd); err != nil {
+		return err
+	}
+	added, version, err := repo.AddTracksToPlaylist(c.PlaylistID, c.TrackIDs, currentUser.ID)
+	if err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		if len(c.TrackIDs) == 1 {
+			return fmt.Errorf("трек уже есть в плейлисте")
+		}
+		return fmt.Errorf("все выбранные треки уже есть в плейлисте")
+	}
+	c.Added, c.version = added, version
+	return nil
+}
+
+// Убрать собственные добавления можно и с правом только на добавление
+func (c *addEntriesCmd) undo() error {
+	if err := requirePlaylistPermission(c.PlaylistID, PermAdd); err != nil {
+		return err
+	}
+	var err error
+	c.version, err = repo.RemoveTracksFromPlaylist(c.PlaylistID, c.Added)
+	return err
+}
+
+// removeEntriesCmd убирает треки из плейлиста; откат возвращает их на прежние места и с прежним автором
+type removeEntriesCmd struct {
+	PlaylistID int
+	TrackIDs   []int
+	Removed    []EntryRow
+	version    int
+}
+
+func (c *removeEntriesCmd) op() string { return "entries.remove" }
+func (c *removeEntriesCmd) label() string {
+	return fmt.Sprintf("удаление треков из плейлиста: %d", len(c.TrackIDs))
+}
+
+func (c *removeEntriesCmd) do() error {
+	if err := requirePlaylistPermission(c.PlaylistID, PermEdit); err != nil {
+		return err
+	}
+	removed, err := repo.SnapshotEntries(c.PlaylistID, c.TrackIDs)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return fmt.Errorf("треки уже удалены из плейлиста")
+	}
+	version, err := repo.RemoveTracksFromPlaylist(c.PlaylistID, c.TrackIDs)
+	if err != nil {
+		return err
+	}
+	c.Removed, c.version = removed, version
+	return nil
+}
+
+func (c *removeEntriesCmd) undo() error {
+	if err := requirePlaylistPermission(c.PlaylistID, PermEdit); err != nil {
+		return err
+	}
+	return repo.RestoreSnapshot(&Snapshot{Entries: c.Removed})
+}
+
+// reorderEntriesCmd меняет порядок треков, переставляя их позиции
+type reorderEntriesCmd struct {
+	PlaylistID    int
+	Before, After []EntryRow
+	version       int
+}
+
+func (c *reorderEntriesCmd) op() string    { return "entries.reorder" }
+func (c *reorderEntriesCmd) label() string { return "изменение порядка треков" }
+
+func (c *reorderEntriesCmd) apply(order []EntryRow) error {
+	if err := requirePlaylistPermission(c.PlaylistID, PermEdit); err != nil {
+		return err
+	}
+	var err error
+	c.version, err = repo.SetEntryOrder(c.PlaylistID, order)
+	return err
+}
+
+func (c *reorderEntriesCmd) do() error   { return c.apply(c.After) }
+func (c *reorderEntriesCmd) undo() error { return c.apply(c.Before) }
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- UNDO HISTORY ---
+// Каждая правка каталога или плейлиста выполняется как команда: она умеет
+// выполниться (и повториться после отмены) и откатиться. Стеки сохраняются
+// в базе по ключу сеанса, поэтому история переживает перезапуск приложения
+// при входе с "запомнить меня".
+
+type command interface {
+	op() string    // имя типа для сохранения в базе
+	label() string // описание для кнопок "Отменить"/"Повторить"
+	do() error
+	undo() error
+}
+
+// Конструкторы команд по имени типа — для восстановления сохранённой истории
+var commandTypes = map[string]func() command{}
+
+func registerCommand(f func() command) {
+	commandTypes[f().op()] = f
+}
+
+const historyLimit = 100
+
+// История хранится, пока сеанс используется; заброшенная удаляется при следующем входе
+var historyTTL = time.Duration(envInt("HISTORY_TTL_DAYS", 30)) * 24 * time.Hour
+
+type history struct {
+	undo, redo []command
+	sessionKey string
+}
+
+var cmdHistory = &history{}
+
+var (
+	historyListeners []func() // изменились стеки — обновить кнопки
+	dataListeners    []func() // отмена/повтор изменили данные — перечитать списки
+)
+
+func onHistoryChanged(f func()) { historyListeners = append(historyListeners, f) }
+func onDataChanged(f func())    { dataListeners = append(dataListeners, f) }
+
+func notifyDataChanged() {
+	for _, f := range dataListeners {
+		f()
+	}
+}
+
+func (h *history) changed() {
+	h.save()
+	for _, f := range historyListeners {
+		f()
+	}
+}
+
+// startHistory загружает историю текущего сеанса; вызывается при каждом показе основного экрана
+func startHistory() {
+	historyListeners, dataListeners = nil, nil
+	repo.PurgeHistory(nowFunc().Add(-historyTTL))
+
+	key := "session:" + strconv.Itoa(currentSessionID)
+	if currentSessionID == 0 {
+		// Без "запомнить меня" сеанс живёт до выхода или закрытия приложения
+		if strings.HasPrefix(cmdHistory.sessionKey, "run:") {
+			return
+		}
+		token, err := newToken()
+		if err != nil {
+			token = strconv.FormatInt(nowFunc().UnixNano(), 10)
+		}
+		key = "run:" + token[:16]
+	}
+	if cmdHistory.sessionKey == key {
+		return
+	}
+	cmdHistory = &history{sessionKey: key}
+	undo, redo, err := repo.LoadHistory(currentUser.ID, key)
+	if err != nil {
+		return
+	}
+	cmdHistory.undo = decodeCommands(undo)
+	cmdHistory.redo = decodeCommands(redo)
+}
+
+// stopHistory вызывается при выходе: сеанс закончен, и его история больше не нужна
+func stopHistory() {
+	if cmdHistory.sessionKey != "" && currentUser != nil {
+		repo.SaveHistory(currentUser.ID, cmdHistory.sessionKey, nil, nil)
+	}
+	cmdHistory = &history{}
+}
+
+func decodeCommands(records []HistoryRecord) []command {
+	var cmds []command
+	for _, rec := range records {
+		newCmd, ok := commandTypes[rec.Op]
+		if !ok {
+			continue
+		}
+		c := newCmd()
+		if err := json.Unmarshal(rec.Payload, c); err != nil {
+			continue
+		}
+		cmds = append(cmds, c)
+	}
+	return cmds
+}
+
+func encodeCommands(cmds []command) []HistoryRecord {
+	var records []HistoryRecord
+	for _, c := range cmds {
+		payload, err := json.Marshal(c)
+		if err != nil {
+			continue
+		}
+		records = append(records, HistoryRecord{Op: c.op(), Payload: payload})
+	}
+	return records
+}
+
+func (h *history) save() {
+	if h.sessionKey == "" || currentUser == nil {
+		return
+	}
+	repo.SaveHistory(currentUser.ID, h.sessionKey, encodeCommands(h.undo), encodeCommands(h.redo))
+}
+
+// runCommand выполняет команду и кладёт её в стек отмены; новая правка очищает стек повтора
+func runCommand(c command) error {
+	if err := c.do(); err != nil {
+		return err
+	}
+	h := cmdHistory
+	h.undo = append(h.undo, c)
+	if len(h.undo) > historyLimit {
+		h.undo = h.undo[len(h.undo)-historyLimit:]
+	}
+	h.redo = nil
+	h.changed()
+	return nil
+}
+
+func undoLabel() string {
+	if n := len(cmdHistory.undo); n > 0 {
+		return cmdHistory.undo[n-1].label()
+	}
+	return ""
+}
+
+func redoLabel() string {
+	if n := len(cmdHistory.redo); n > 0 {
+		return cmdHistory.redo[n-1].label()
+	}
+	return ""
+}
+
+// undoLast откатывает последнюю команду. Если откат невозможен (например, нет прав
+// или запись уже изменили другие), команда остаётся в стеке.
+func undoLast() error {
+	h := cmdHistory
+	n := len(h.undo)
+	if n == 0 {
+		return fmt.Errorf("нечего отменять")
+	}
+	c := h.undo[n-1]
+	if err := c.undo(); err != nil {
+		return fmt.Errorf("не удалось отменить %s: %w", c.label(), err)
+	}
+	h.undo = h.undo[:n-1]
+	h.redo = append(h.redo, c)
+	h.changed()
+	notifyDataChanged()
+	return nil
+}
+
+func redoLast() error {
+	h := cmdHistory
+	n := len(h.redo)
+	if n == 0 {
+		return fmt.Errorf("нечего повторять")
+	}
+	c := h.redo[n-1]
+	if err := c.do(); err != nil {
+		return fmt.Errorf("не удалось повторить %s: %w", c.label(), err)
+	}
+	h.redo = h.redo[:n-1]
+	h.undo = append(h.undo, c)
+	h.changed()
+	notifyDataChanged()
+	return nil
+}
+
+// clearHistory очищает стеки, когда данные изменились в обход команд (например, восстановление из архива)
+func clearHistory() {
+	cmdHistory.undo, cmdHistory.redo = nil, nil
+	cmdHistory.changed()
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Громкость по EBU R128 / ITU-R BS.1770-4: сигнал проходит K-фильтр, средняя мощность
+// считается блоками по 400 мс с шагом 100 мс, блоки тише -70 LUFS и тише среднего
+// на 10 LU отбрасываются. True peak — пик после четырёхкратной передискретизации.
+// ReplayGain 2.0 приводит запись к -18 LUFS.
+
+const (
+	r128AbsoluteGate = -70.0
+	r128RelativeGate = -10.0
+	replayGainTarget = -18.0
+)
+
+// biquad — фильтр второго порядка в прямой форме I
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting возвращает фильтры BS.1770 (верхняя полка и RLB), пересчитанные под частоту rate —
+// так же, как это делает libebur128
+func kWeighting(rate int) (shelf, highpass biquad) {
+	fs := float64(rate)
+
+	f0, g, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf = biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	highpass = biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highpass
+}
+
+// channelWeight — вес канала по BS.1770: для 5.1 LFE не учитывается, тыловые каналы ×1,41
+func channelWeight(channel, channels int) float64 {
+	if channels == 6 {
+		switch channel {
+		case 3:
+			return 0
+		case 4, 5:
+			return 1.41
+		}
+	}
+	if channels == 5 && (channel == 3 || channel == 4) {
+		return 1.41
+	}
+	return 1
+}
+
+// r128Blocks возвращает среднюю взвешенную мощность блоков 400 мс с шагом 100 мс
+func r128Blocks(a *pcmAudio) []float64 {
+	step := a.Rate / 10
+	n := len(a.Channels[0])
+	segments := make([]float64, n/step)
+	for c, ch := range a.Channels {
+		w := channelWeight(c, len(a.Channels))
+		if w == 0 {
+			continue
+		}
+		shelf, highpass := kWeighting(a.Rate)
+		for i := 0; i < len(segments)*step; i++ {
+			y := highpass.process(shelf.process(ch[i]))
+			segments[i/step] += w * y * y
+		}
+	}
+	var blocks []float64
+	for i := 3; i < len(segments); i++ {
+		sum := segments[i-3] + segments[i-2] + segments[i-1] + segments[i]
+		blocks = append(blocks, sum/float64(4*step))
+	}
+	return blocks
+}
+
+func powerToLUFS(p float64) float64 {
+	return -0.691 + 10*math.Log10(p)
+}
+
+// gatedLoudness — интегральная громкость блоков с абсолютным и относительным порогом
+func gatedLoudness(blocks []float64) (float64, error) {
+	gate := func(threshold float64) (float64, int) {
+		var sum float64
+		var n int
+		for _, p := range blocks {
+			if p > 0 && powerToLUFS(p) > threshold {
+				sum += p
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, 0
+		}
+		return sum / float64(n), n
+	}
+	mean, n := gate(r128AbsoluteGate)
+	if n == 0 {
+		return 0, fmt.Errorf("запись слишком тихая или короткая для измерения громкости")
+	}
+	mean, _ = gate(math.Max(r128AbsoluteGate, powerToLUFS(mean)+r128RelativeGate))
+	return powerToLUFS(mean), nil
+}
+
+// truePeakFilter — фазы интерполирующего фильтра для передискретизации ×4:
+// 48 отводов оконного sinc (окно Ханна), по 12 на фазу, как в примере из BS.1770
+var truePeakFilter = func() [4][12]float64 {
+	const taps, factor = 48, 4
+	var phases [4][12]float64
+	for i := 0; i < taps; i++ {
+		x := float64(i) - float64(taps-1)/2
+		sinc := 1.0
+		if x != 0 {
+			sinc = math.Sin(math.Pi*x/factor) / (math.Pi * x / factor)
+		}
+		window := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(taps-1))
+		phases[i%factor][i/factor] = sinc * window
+	}
+	return phases
+}()
+
+// truePeak — наибольшая амплитуда восстановленного сигнала по всем каналам, линейно
+func truePeak(a *pcmAudio) float64 {
+	var peak float64
+	for c, ch := range a.Channels {
+		if channelWeight(c, len(a.Channels)) == 0 {
+			continue
+		}
+		for n := range ch {
+			peak = math.Max(peak, math.Abs(ch[n]))
+			for _, phase := range truePeakFilter {
+				var y float64
+				for k, h := range phase {
+					if j := n - k; j >= 0 {
+						y += h * ch[j]
+					}
+				}
+				peak = math.Max(peak, math.Abs(y))
+			}
+		}
+	}
+	return peak
+}
+
+// LoudnessResult — громкость записи; blocks сохраняются, чтобы посчитать альбом целиком
+type LoudnessResult struct {
+	Loudness
+	blocks []float64
+}
+
+func analyzeLoudness(a *pcmAudio) (*LoudnessResult, error) {
+	if len(a.Channels) == 0 || a.Rate < 10 {
+		return nil, fmt.Errorf("нет звука")
+	}
+	blocks := r128Blocks(a)
+	lufs, err := gatedLoudness(blocks)
+	if err != nil {
+		return nil, err
+	}
+	return &LoudnessResult{
+		Loudness: Loudness{LUFS: lufs, TruePeak: amplitudeToDB(truePeak(a)), Gain: replayGainTarget - lufs},
+		blocks:   blocks,
+	}, nil
+}
+
+// albumLoudness — громкость альбома считается по блокам всех треков сразу, пик — наибольший из треков
+func albumLoudness(tracks []*LoudnessResult) (Loudness, error) {
+	var blocks []float64
+	peak := math.Inf(-1)
+	for _, t := range tracks {
+		blocks = append(blocks, t.blocks...)
+		peak = math.Max(peak, t.TruePeak)
+	}
+	lufs, err := gatedLoudness(blocks)
+	if err != nil {
+		return Loudness{}, err
+	}
+	return Loudness{LUFS: lufs, TruePeak: peak, Gain: replayGainTarget - lufs}, nil
+}
+
+func amplitudeToDB(a float64) float64 {
+	if a <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(a)
+}
+
+func dbToAmplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// --- LOUDNESS ---
+// Громкость измеряется по файлам, привязанным к трекам при снятии отпечатков.
+// Альбом считается по всем его трекам с файлами, поэтому при дозамере
+// альбом с хотя бы одним неизмеренным треком пересчитывается целиком.
+
+type LoudnessReport struct {
+	Tracks int
+	Albums int
+	Tagged int      // файлов с записанными тегами ReplayGain
+	Failed []string // файлы, которые не удалось обработать, с причиной
+}
+
+// analyzeLoudnessJob измеряет громкость треков и альбомов. onlyMissing — только альбомы,
+// где есть неизмеренные треки; writeTags — записать ReplayGain в теги файлов.
+func analyzeLoudnessJob(onlyMissing, writeTags bool, progress func(done, total int)) (*LoudnessReport, error) {
+	if err := requireCatalogEditor(); err != nil {
+		return nil, err
+	}
+	files, err := repo.GetTrackFiles()
+	if err != nil {
+		return nil, err
+	}
+	var albums []int
+	byAlbum := map[int][]TrackFile{}
+	missing := map[int]bool{}
+	for _, f := range files {
+		if byAlbum[f.AlbumID] == nil {
+			albums = append(albums, f.AlbumID)
+		}
+		byAlbum[f.AlbumID] = append(byAlbum[f.AlbumID], f)
+		if !f.HasLoudness {
+			missing[f.AlbumID] = true
+		}
+	}
+	total := 0
+	for _, id := range albums {
+		if !onlyMissing || missing[id] {
+			total += len(byAlbum[id])
+		}
+	}
+
+	rep := &LoudnessReport{}
+	done := 0
+	for _, albumID := range albums {
+		if onlyMissing && !missing[albumID] {
+			continue
+		}
+		var results []*LoudnessResult
+		var measured []TrackFile
+		for _, f := range byAlbum[albumID] {
+			res, err := measureFile(f.Path)
+			if err != nil {
+				rep.Failed = append(rep.Failed, fmt.Sprintf("%s: %v", f.Path, err))
+			} else if err := repo.SaveLoudness(KindTrack, f.TrackID, res.Loudness); err != nil {
+				return rep, err
+			} else {
+				results = append(results, res)
+				measured = append(measured, f)
+				rep.Tracks++
+			}
+			done++
+			if progress != nil {
+				progress(done, total)
+			}
+		}
+		if len(results) == 0 {
+			continue
+		}
+		album, err := albumLoudness(results)
+		if err != nil {
+			rep.Failed = append(rep.Failed, fmt.Sprintf("альбом №%d: %v", albumID, err))
+			continue
+		}
+		if err := repo.SaveLoudness(KindAlbum, albumID, album); err != nil {
+			return rep, err
+		}
+		rep.Albums++
+		if !writeTags {
+			continue
+		}
+		for i, f := range measured {
+			if err := writeReplayGainTags(f.Path, results[i].Loudness, album); err != nil {
+				rep.Failed = append(rep.Failed, fmt.Sprintf("%s: %v", f.Path, err))
+			} else {
+				rep.Tagged++
+			}
+		}
+	}
+	return rep, nil
+}
+
+func measureFile(path string) (*LoudnessResult, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("файл недоступен")
+	}
+	a, err := decodePCM(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	return analyzeLoudness(a)
+}
+
+func describeLoudnessReport(rep *LoudnessReport) string {
+	s := fmt.Sprintf("Измерено треков: %d, альбомов: %d.", rep.Tracks, rep.Albums)
+	if rep.Tagged > 0 {
+		s += fmt.Sprintf("\nТеги ReplayGain записаны в файлов: %d.", rep.Tagged)
+	}
+	if len(rep.Failed) > 0 {
+		s += fmt.Sprintf("\nНе удалось обработать (%d):\n  %s", len(rep.Failed), strings.Join(rep.Failed, "\n  "))
+	}
+	return s
+}
+
+// writeReplayGainTags записывает теги ReplayGain в файл через ffmpeg без перекодирования:
+// результат пишется во временный файл рядом и подменяет исходный
+func writeReplayGainTags(path string, track, album Loudness) error {
+	ext := filepath.Ext(path)
+	tmp := strings.TrimSuffix(path, ext) + ".replaygain" + ext
+	args := []string{"-v", "error", "-y", "-i", path, "-map", "0", "-map_metadata", "0", "-c", "copy"}
+	for _, tag := range replayGainTags(TrackInfo{TrackLoudness: &track, AlbumLoudness: &album}) {
+		args = append(args, "-metadata", tag[0]+"="+tag[1])
+	}
+	if strings.EqualFold(ext, ".m4a") {
+		args = append(args, "-movflags", "use_metadata_tags") // иначе MP4 теряет нестандартные теги
+	}
+	args = append(args, tmp)
+	if out, err := exec.Command(ffmpegPath, args...).CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("не удалось записать теги (нужен ffmpeg): %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.Rename(tmp, path)
+}
+
+// attachAudioInfo дополняет треки файлами и громкостью для экспорта плейлистов
+func attachAudioInfo(tracks []TrackInfo) error {
+	ids := make([]int, len(tracks))
+	for i, t := range tracks {
+		ids[i] = t.ID
+	}
+	infos, err := repo.GetAudioInfo(ids)
+	if err != nil {
+		return err
+	}
+	for i := range tracks {
+		info := infos[tracks[i].ID]
+		tracks[i].FilePath = info.FilePath
+		tracks[i].TrackLoudness = info.TrackLoudness
+		tracks[i].AlbumLoudness = info.AlbumLoudness
+	}
+	return nil
+}
+
+// trackAudioInfo — файл и громкость одного трека для карточки
+func trackAudioInfo(id int) (AudioInfo, error) {
+	infos, err := repo.GetAudioInfo([]int{id})
+	return infos[id], err
+}
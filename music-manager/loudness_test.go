@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+const testRate = 48000
+
+// sine — синус частоты freq с пиковой амплитудой dbfs (дБ от полной шкалы) длиной seconds
+func sine(freq, dbfs, phase, seconds float64) []float64 {
+	out := make([]float64, int(seconds*testRate))
+	amp := dbToAmplitude(dbfs)
+	for i := range out {
+		out[i] = amp * math.Sin(2*math.Pi*freq*float64(i)/testRate+phase)
+	}
+	return out
+}
+
+func concat(parts ...[]float64) []float64 {
+	var out []float64
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func stereo(ch []float64) *pcmAudio {
+	return &pcmAudio{Rate: testRate, Channels: [][]float64{ch, ch}}
+}
+
+// АЧХ K-фильтра в точках, которые приводит BS.1770: около +0,7 дБ на 1 кГц
+// (его компенсирует -0,691 в формуле громкости), +4 дБ полки на высоких, спад ниже 100 Гц
+func TestKWeighting(t *testing.T) {
+	tests := []struct {
+		freq, gain, tolerance float64
+	}{
+		{20, -13.3, 0.2},
+		{100, -1.1, 0.1},
+		{1000, 0.691, 0.02},
+		{10000, 4.0, 0.1},
+	}
+	for _, tt := range tests {
+		shelf, highpass := kWeighting(testRate)
+		in := sine(tt.freq, 0, 0, 2)
+		var pin, pout float64
+		for i, x := range in {
+			y := highpass.process(shelf.process(x))
+			if i >= testRate { // первая секунда — установление фильтра
+				pin += x * x
+				pout += y * y
+			}
+		}
+		if got := 10 * math.Log10(pout/pin); math.Abs(got-tt.gain) > tt.tolerance {
+			t.Errorf("%g Гц: усиление %.3f дБ, want %.3f ± %.2f", tt.freq, got, tt.gain, tt.tolerance)
+		}
+	}
+}
+
+// Громкость и пороги — по сигналам EBU Tech 3341: стереосинус 1 кГц с уровнем -23 дБFS
+// в каждом канале даёт -23 LUFS, а тихие участки отбрасываются порогами
+func TestGatedLoudness(t *testing.T) {
+	tests := []struct {
+		name  string
+		audio *pcmAudio
+		lufs  float64
+	}{
+		{"стерео -23 дБFS", stereo(sine(1000, -23, 0, 5)), -23},
+		{"стерео -33 дБFS", stereo(sine(1000, -33, 0, 5)), -33},
+		{"моно -20 дБFS", &pcmAudio{Rate: testRate, Channels: [][]float64{sine(1000, -20, 0, 5)}}, -23.01},
+		// Относительный порог: участки на 13 LU тише среднего не учитываются
+		{"тихие края", stereo(concat(sine(1000, -36, 0, 3), sine(1000, -23, 0, 20), sine(1000, -36, 0, 3))), -23},
+		// Абсолютный порог: тишина и звук тише -70 LUFS не учитываются
+		{"тишина", stereo(concat(make([]float64, 3*testRate), sine(1000, -23, 0, 20), sine(1000, -75, 0, 3))), -23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gatedLoudness(r128Blocks(tt.audio))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.lufs) > 0.1 {
+				t.Errorf("громкость %.2f LUFS, want %.2f", got, tt.lufs)
+			}
+		})
+	}
+
+	// Без порогов тихие края опустили бы громкость заметно ниже -23
+	blocks := r128Blocks(stereo(concat(sine(1000, -36, 0, 3), sine(1000, -23, 0, 20), sine(1000, -36, 0, 3))))
+	var sum float64
+	for _, p := range blocks {
+		sum += p
+	}
+	if ungated := powerToLUFS(sum / float64(len(blocks))); ungated > -23.3 {
+		t.Errorf("без порогов %.2f LUFS — тестовый сигнал не проверяет порог", ungated)
+	}
+
+	if _, err := gatedLoudness(r128Blocks(stereo(sine(1000, -80, 0, 2)))); err == nil {
+		t.Error("запись тише -70 LUFS измерена без ошибки")
+	}
+}
+
+// True peak: синус на четверти частоты дискретизации со сдвигом фазы 45° попадает
+// отсчётами только в ±0,707, а восстановленный пик — 0 дБ. Допуск +0,2/-0,4 дБ — как в EBU Tech 3341.
+func TestTruePeak(t *testing.T) {
+	tests := []struct {
+		name             string
+		samples          []float64
+		sampleDB, peakDB float64
+	}{
+		{"1 кГц -6 дБFS", sine(1000, -6, 0, 0.1), -6, -6},
+		{"12 кГц по отсчётам", sine(12000, 0, 0, 0.1), 0, 0},
+		{"12 кГц между отсчётами", sine(12000, 0, math.Pi/4, 0.1), -3.01, 0},
+	}
+	for _, tt := range tests {
+		var sample float64
+		for _, x := range tt.samples {
+			sample = math.Max(sample, math.Abs(x))
+		}
+		if got := amplitudeToDB(sample); math.Abs(got-tt.sampleDB) > 0.05 {
+			t.Errorf("%s: пик по отсчётам %.2f дБ, want %.2f", tt.name, got, tt.sampleDB)
+		}
+		got := amplitudeToDB(truePeak(&pcmAudio{Rate: testRate, Channels: [][]float64{tt.samples}}))
+		if got < tt.peakDB-0.4 || got > tt.peakDB+0.2 {
+			t.Errorf("%s: true peak %.2f дБTP, want %.2f (+0,2/-0,4)", tt.name, got, tt.peakDB)
+		}
+	}
+}
+
+func TestAnalyzeLoudnessGain(t *testing.T) {
+	r, err := analyzeLoudness(stereo(sine(1000, -23, 0, 5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ReplayGain 2.0 поднимает -23 LUFS до -18
+	if math.Abs(r.Gain-5) > 0.1 {
+		t.Errorf("поправка %.2f дБ, want 5", r.Gain)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// m3uLocation — путь к файлу трека в плейлисте; если файл не привязан,
+// используется условный путь "Артист/Альбом/Название"
+func m3uLocation(t TrackInfo) string {
+	if t.FilePath != "" {
+		return t.FilePath
+	}
+	return strings.Join([]string{safeFileName(t.ArtistName), safeFileName(t.AlbumTitle), safeFileName(t.Title)}, "/")
+}
+
+// writeM3U записывает плейлист в расширенном формате M3U
+func writeM3U(w io.Writer, title string, tracks []TrackInfo) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	fmt.Fprintf(bw, "#PLAYLIST:%s\n", title)
+	for _, t := range tracks {
+		fmt.Fprintf(bw, "#EXTINF:%d,%s - %s\n", t.Duration, t.ArtistName, t.Title)
+		fmt.Fprintf(bw, "#EXTALB:%s\n", t.AlbumTitle)
+		for _, tag := range replayGainTags(t) {
+			fmt.Fprintf(bw, "#%s:%s\n", tag[0], tag[1])
+		}
+		fmt.Fprintln(bw, m3uLocation(t))
+	}
+	return bw.Flush()
+}
+
+// replayGainTags — пары тег/значение ReplayGain в записи, как их пишут в файлы
+func replayGainTags(t TrackInfo) [][2]string {
+	var tags [][2]string
+	if l := t.TrackLoudness; l != nil {
+		tags = append(tags,
+			[2]string{"REPLAYGAIN_TRACK_GAIN", fmt.Sprintf("%.2f dB", l.Gain)},
+			[2]string{"REPLAYGAIN_TRACK_PEAK", fmt.Sprintf("%.6f", dbToAmplitude(l.TruePeak))})
+	}
+	if l := t.AlbumLoudness; l != nil {
+		tags = append(tags,
+			[2]string{"REPLAYGAIN_ALBUM_GAIN", fmt.Sprintf("%.2f dB", l.Gain)},
+			[2]string{"REPLAYGAIN_ALBUM_PEAK", fmt.Sprintf("%.6f", dbToAmplitude(l.TruePeak))})
+	}
+	return tags
+}
+
+// XSPF (https://xspf.org): ReplayGain передаётся в элементах meta
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version int         `xml:"version,attr"`
+	Title   string      `xml:"title"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string     `xml:"location"`
+	Title    string     `xml:"title"`
+	Creator  string     `xml:"creator"`
+	Album    string     `xml:"album"`
+	TrackNum int        `xml:"trackNum,omitempty"`
+	Duration int        `xml:"duration"` // в миллисекундах
+	Meta     []xspfMeta `xml:"meta"`
+}
+
+type xspfMeta struct {
+	Rel   string `xml:"rel,attr"`
+	Value string `xml:",chardata"`
+}
+
+// xspfLocation — URI файла; для условного пути — относительная ссылка
+func xspfLocation(t TrackInfo) string {
+	if t.FilePath != "" {
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(t.FilePath)}
+		if !strings.HasPrefix(u.Path, "/") {
+			u.Path = "/" + u.Path // C:/Music → file:///C:/Music
+		}
+		return u.String()
+	}
+	return (&url.URL{Path: m3uLocation(t)}).String()
+}
+
+// writeXSPF записывает плейлист в формате XSPF
+func writeXSPF(w io.Writer, title string, tracks []TrackInfo) error {
+	pl := xspfPlaylist{Version: 1, Title: title}
+	for _, t := range tracks {
+		xt := xspfTrack{
+			Location: xspfLocation(t),
+			Title:    t.Title,
+			Creator:  t.ArtistName,
+			Album:    t.AlbumTitle,
+			TrackNum: t.Number,
+			Duration: t.Duration * 1000,
+		}
+		for _, tag := range replayGainTags(t) {
+			xt.Meta = append(xt.Meta, xspfMeta{Rel: "urn:replaygain:" + strings.ToLower(strings.TrimPrefix(tag[0], "REPLAYGAIN_")), Value: tag[1]})
+		}
+		pl.Tracks = append(pl.Tracks, xt)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(pl); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// safeFileName убирает символы, недопустимые в именах файлов
+func safeFileName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(s))
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
@@ -29,22 +29,55 @@ func main() {
 	// 3. ИНИЦИАЛИЗИРУЕМ РЕПОЗИТОРИЙ (Важное изменение)
 	// Теперь переменная 'repo' из database.go заполнена и готова к работе
 	repo = NewRepository(db)
+	purgeAuditLog()
+
+	// Команды резервного копирования работают без окна
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
 
 	// 4. Создаем приложение и настраиваем тему
-	myApp := app.New()
+	// ID приложения нужен для хранения настроек (токена "запомнить меня")
+	myApp := app.NewWithID("ru.musicmanager.app")
 	myApp.Settings().SetTheme(&SpotifyTheme{})
 
 	// 5. Создаем главное окно
 	mainWindow = myApp.NewWindow("Music Manager")
+	// Сочетания отмены живут на холсте окна и переживают смену экранов — привязываем один раз
+	bindHistoryShortcuts()
 
-	// Устанавливаем стартовый экран (Авторизация)
-	mainWindow.SetContent(createAuthUI(func() {
-		// При успешном входе переключаемся на основной интерфейс
-		mainWindow.SetContent(container.NewAppTabs(
-			createPlaylistTab(),
-			createDatabaseTab(),
-		))
-	}))
+	// Стартовый экран: при сохранённом сеансе входим автоматически, иначе — авторизация
+	if resumeSession() {
+		showMainScreen()
+	} else {
+		showAuthScreen()
+	}
 
 	mainWindow.ShowAndRun()
 }
+
+// Экран входа; после успешного входа переключаемся на основной интерфейс
+func showAuthScreen() {
+	mainWindow.SetMainMenu(nil)
+	mainWindow.SetContent(createAuthUI(showMainScreen))
+}
+
+// Основной интерфейс пересоздаётся при каждом входе, чтобы не тянуть кэш прошлого пользователя
+func showMainScreen() {
+	startHistory()
+	tabs := container.NewAppTabs(
+		createPlaylistTab(),
+		createBrowseTab(),
+		createDatabaseTab(),
+		createAccountTab(showAuthScreen),
+	)
+	if canEditCatalog() {
+		tabs.Append(createDuplicatesTab())
+	}
+	if isAdmin() {
+		tabs.Append(createAdminTab())
+		tabs.Append(createAuditTab())
+	}
+	mainWindow.SetMainMenu(mainMenu())
+	mainWindow.SetContent(container.NewBorder(historyToolbar(), nil, nil, nil, tabs))
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- METADATA ENRICHMENT ---
+// Годы альбомов и длительности треков часто набраны вручную или пусты. Уточняем их
+// по сервису с API MusicBrainz: ищем релиз по названию альбома и артисту, берём
+// его треклист и предлагаем правки полей, которые пользователь принимает по одной.
+// Если релиза в сервисе нет, сверяем по отдельности артиста и записи (recordings).
+// Адрес сервиса настраивается, так что вместо MusicBrainz подойдёт локальная
+// подмена (см. metadata_standin.go).
+
+var (
+	metadataURL       = envString("METADATA_URL", "https://musicbrainz.org/ws/2")
+	metadataInterval  = time.Duration(envInt("METADATA_RATE_MS", 1100)) * time.Millisecond // MusicBrainz разрешает 1 запрос в секунду
+	metadataCacheTTL  = time.Duration(envInt("METADATA_CACHE_DAYS", 30)) * 24 * time.Hour
+	metadataUserAgent = "music-manager/1.0 ( " + envString("METADATA_CONTACT", "admin@localhost") + " )"
+	metadataHTTP      = &http.Client{Timeout: 15 * time.Second}
+)
+
+// responseCache — хранилище ответов сервиса
+type responseCache interface {
+	CachedResponse(url string, maxAge time.Duration) (string, bool, error)
+	SaveCachedResponse(url, body string) error
+}
+
+// metadataCache возвращает хранилище ответов; по умолчанию — таблица metadata_cache
+var metadataCache = func() responseCache { return repo }
+
+func envString(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// Ответы сервиса в формате MusicBrainz JSON (fmt=json); нужны только используемые поля
+
+type mbArtistCredit struct {
+	Name       string `json:"name"`
+	JoinPhrase string `json:"joinphrase"`
+}
+
+type mbTrack struct {
+	Position int    `json:"position"`
+	Title    string `json:"title"`
+	Length   int    `json:"length"` // миллисекунды
+}
+
+type mbMedium struct {
+	Position int       `json:"position"`
+	Tracks   []mbTrack `json:"tracks"`
+}
+
+type mbRelease struct {
+	ID           string           `json:"id"`
+	Score        int              `json:"score"`
+	Title        string           `json:"title"`
+	Date         string           `json:"date"`
+	ArtistCredit []mbArtistCredit `json:"artist-credit"`
+	Media        []mbMedium       `json:"media,omitempty"`
+}
+
+type mbReleaseSearch struct {
+	Releases []mbRelease `json:"releases"`
+}
+
+type mbArtist struct {
+	ID    string `json:"id"`
+	Score int    `json:"score"`
+	Name  string `json:"name"`
+}
+
+type mbArtistSearch struct {
+	Artists []mbArtist `json:"artists"`
+}
+
+type mbRecording struct {
+	ID           string           `json:"id"`
+	Score        int              `json:"score"`
+	Title        string           `json:"title"`
+	Length       int              `json:"length"` // миллисекунды
+	ArtistCredit []mbArtistCredit `json:"artist-credit"`
+}
+
+type mbRecordingSearch struct {
+	Recordings []mbRecording `json:"recordings"`
+}
+
+func creditedName(credits []mbArtistCredit) string {
+	var b strings.Builder
+	for _, c := range credits {
+		b.WriteString(c.Name + c.JoinPhrase)
+	}
+	return b.String()
+}
+
+func (r mbRelease) artistName() string   { return creditedName(r.ArtistCredit) }
+func (r mbRecording) artistName() string { return creditedName(r.ArtistCredit) }
+
+func (r mbRelease) year() int {
+	if len(r.Date) < 4 {
+		return 0
+	}
+	y, _ := strconv.Atoi(r.Date[:4])
+	return y
+}
+
+// rateLimiter пропускает не больше одного запроса за interval
+type rateLimiter struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+var metadataLimiter = &rateLimiter{}
+
+func (l *rateLimiter) wait(interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d := interval - time.Since(l.last); d > 0 {
+		time.Sleep(d)
+	}
+	l.last = time.Now()
+}
+
+// metadataGet запрашивает ресурс сервиса; ответы кэшируются в базе
+func metadataGet(path string, params url.Values, out interface{}) error {
+	params.Set("fmt", "json")
+	u := strings.TrimRight(metadataURL, "/") + path + "?" + params.Encode()
+
+	cache := metadataCache()
+	body, ok, err := cache.CachedResponse(u, metadataCacheTTL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		metadataLimiter.wait(metadataInterval)
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", metadataUserAgent)
+		req.Header.Set("Accept", "application/json")
+		resp, err := metadataHTTP.Do(req)
+		if err != nil {
+			return fmt.Errorf("сервис метаданных недоступен: %w", err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		switch {
+		case resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests:
+			return fmt.Errorf("сервис метаданных ограничил частоту запросов, повторите позже")
+		case resp.StatusCode == http.StatusNotFound:
+			return fmt.Errorf("сервис метаданных не нашёл %s", path)
+		case resp.StatusCode != http.StatusOK:
+			return fmt.Errorf("сервис метаданных ответил %s", resp.Status)
+		}
+		body = string(data)
+		if err := cache.SaveCachedResponse(u, body); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal([]byte(body), out); err != nil {
+		return fmt.Errorf("непонятный ответ сервиса метаданных: %w", err)
+	}
+	return nil
+}
+
+// luceneQuote экранирует значение для поискового запроса MusicBrainz
+func luceneQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// MetadataMatch — найденный релиз и предложенные по нему правки.
+// Без релиза (ReleaseID == "") правки собраны по артисту и отдельным записям.
+type MetadataMatch struct {
+	ReleaseID  string
+	Release    string // "Артист — Название (год)"
+	Recordings int    // сколько треков нашлось среди записей, если релиза нет
+	Changes    []MetadataChange
+}
+
+// source — откуда взяты правки, для заголовка в окне
+func (m *MetadataMatch) source() string {
+	if m.ReleaseID != "" {
+		return "Найден релиз: " + m.Release
+	}
+	return fmt.Sprintf("Релиз не найден, сверено с артистом и записями сервиса (треков найдено: %d)", m.Recordings)
+}
+
+func (m *MetadataMatch) propose(kind string, id int, field, label, old, new string) {
+	if new != "" && old != new {
+		m.Changes = append(m.Changes, MetadataChange{Kind: kind, ID: id, Field: field, Label: label, Old: old, New: new})
+	}
+}
+
+// optionalItoa — число для сравнения полей; 0 ("не указано") — пустая строка
+func optionalItoa(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// proposeDuration предлагает длительность из сервиса; расхождение в секунду — округление, не ошибка
+func (m *MetadataMatch) proposeDuration(t Track, label string, lengthMs int) {
+	if sec := (lengthMs + 500) / 1000; sec > 0 && (t.Duration == 0 || abs(sec-t.Duration) > 1) {
+		m.propose(KindTrack, t.ID, "duration", label, optionalItoa(t.Duration), optionalItoa(sec))
+	}
+}
+
+// findRelease ищет релиз альбома; из кандидатов с высокой оценкой берём самый похожий по названию
+func findRelease(artist, album string) (*mbRelease, error) {
+	var found mbReleaseSearch
+	q := url.Values{}
+	q.Set("query", "release:"+luceneQuote(album)+" AND artist:"+luceneQuote(artist))
+	q.Set("limit", "10")
+	if err := metadataGet("/release", q, &found); err != nil {
+		return nil, err
+	}
+	name, title := normalizeName(artist), normalizeName(album)
+	var best *mbRelease
+	bestScore := 0
+	for i, r := range found.Releases {
+		s := min(similarity(title, normalizeName(r.Title)), similarity(name, normalizeName(r.artistName())))
+		if s < duplicateSimilarity {
+			continue
+		}
+		// Оценка сервиса решает при равном сходстве
+		if score := s*100 + r.Score; score > bestScore {
+			best, bestScore = &found.Releases[i], score
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	var full mbRelease
+	if err := metadataGet("/release/"+url.PathEscape(best.ID), url.Values{"inc": {"recordings artist-credits"}}, &full); err != nil {
+		return nil, err
+	}
+	return &full, nil
+}
+
+// findArtist ищет артиста по имени; nil — похожего нет
+func findArtist(name string) (*mbArtist, error) {
+	var found mbArtistSearch
+	q := url.Values{}
+	q.Set("query", "artist:"+luceneQuote(name))
+	q.Set("limit", "10")
+	if err := metadataGet("/artist", q, &found); err != nil {
+		return nil, err
+	}
+	norm := normalizeName(name)
+	var best *mbArtist
+	bestScore := 0
+	for i, a := range found.Artists {
+		s := similarity(norm, normalizeName(a.Name))
+		if s < duplicateSimilarity {
+			continue
+		}
+		if score := s*100 + a.Score; score > bestScore {
+			best, bestScore = &found.Artists[i], score
+		}
+	}
+	return best, nil
+}
+
+// findRecording ищет запись артиста по названию трека; nil — похожей нет
+func findRecording(artist, title string) (*mbRecording, error) {
+	var found mbRecordingSearch
+	q := url.Values{}
+	q.Set("query", "recording:"+luceneQuote(title)+" AND artist:"+luceneQuote(artist))
+	q.Set("limit", "10")
+	if err := metadataGet("/recording", q, &found); err != nil {
+		return nil, err
+	}
+	name, norm := normalizeName(artist), normalizeName(title)
+	var best *mbRecording
+	bestScore := 0
+	for i, r := range found.Recordings {
+		s := min(similarity(norm, normalizeName(r.Title)), similarity(name, normalizeName(r.artistName())))
+		if s < duplicateSimilarity {
+			continue
+		}
+		if score := s*100 + r.Score; score > bestScore {
+			best, bestScore = &found.Recordings[i], score
+		}
+	}
+	return best, nil
+}
+
+// proposeAlbumMetadata сравнивает альбом, его артиста и треки с релизом из сервиса.
+// Возвращает nil, если подходящий релиз не найден.
+func proposeAlbumMetadata(albumID int) (*MetadataMatch, error) {
+	if err := requireCatalogEditor(); err != nil {
+		return nil, err
+	}
+	albums, err := repo.GetAlbums()
+	if err != nil {
+		return nil, err
+	}
+	var album *Album
+	for i := range albums {
+		if albums[i].ID == albumID {
+			album = &albums[i]
+		}
+	}
+	if album == nil {
+		return nil, fmt.Errorf("альбом не найден")
+	}
+	artists, err := repo.GetArtists()
+	if err != nil {
+		return nil, err
+	}
+	var artist Artist
+	for _, a := range artists {
+		if a.ID == album.ArtistID {
+			artist = a
+		}
+	}
+	// Без имени артиста сервис вернул бы случайные релизы с тем же названием
+	if artist.Name == "" {
+		return nil, fmt.Errorf("у альбома не найден артист")
+	}
+	tracks, err := repo.GetAlbumTracks(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := findRelease(artist.Name, album.Title)
+	if err != nil {
+		return nil, err
+	}
+	var m *MetadataMatch
+	if rel != nil {
+		m = proposeFromRelease(rel, artist, *album, tracks)
+	} else if m, err = proposeFromRecordings(artist, tracks); err != nil || m == nil {
+		return nil, err
+	}
+	sort.SliceStable(m.Changes, func(i, j int) bool {
+		return kindOrder[m.Changes[i].Kind] < kindOrder[m.Changes[j].Kind]
+	})
+	return m, nil
+}
+
+func proposeFromRelease(rel *mbRelease, artist Artist, album Album, tracks []Track) *MetadataMatch {
+	m := &MetadataMatch{
+		ReleaseID: rel.ID,
+		Release:   fmt.Sprintf("%s — %s (%s)", rel.artistName(), rel.Title, rel.Date),
+	}
+
+	// Составных артистов ("A feat. B") не переименовываем — это уже не правка написания,
+	// такая запись попадает в участники альбома
+	albumLabel := fmt.Sprintf("альбом «%s»", album.Title)
+	if len(rel.ArtistCredit) == 1 {
+		m.propose(KindArtist, artist.ID, "name", fmt.Sprintf("артист «%s»", artist.Name), artist.Name, rel.artistName())
+	} else if album.Credits == "" {
+		m.propose(KindAlbum, album.ID, "credits", albumLabel, album.Credits, rel.artistName())
+	}
+	m.propose(KindAlbum, album.ID, "title", albumLabel, album.Title, rel.Title)
+	m.propose(KindAlbum, album.ID, "year", albumLabel, optionalItoa(album.Year), optionalItoa(rel.year()))
+
+	// Номер трека на многодисковом релизе считаем сквозным
+	var remote []mbTrack
+	for _, medium := range rel.Media {
+		for _, t := range medium.Tracks {
+			t.Position = len(remote) + 1
+			remote = append(remote, t)
+		}
+	}
+	used := map[int]bool{}
+	for _, t := range tracks {
+		best, bestScore := -1, 0
+		for i, rt := range remote {
+			if used[i] {
+				continue
+			}
+			if s := similarity(normalizeName(t.Title), normalizeName(rt.Title)); s >= duplicateSimilarity && s > bestScore {
+				best, bestScore = i, s
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		used[best] = true
+		rt := remote[best]
+		label := fmt.Sprintf("трек «%s»", t.Title)
+		m.propose(KindTrack, t.ID, "title", label, t.Title, rt.Title)
+		m.propose(KindTrack, t.ID, "number", label, optionalItoa(t.Number), optionalItoa(rt.Position))
+		m.proposeDuration(t, label, rt.Length)
+	}
+	return m
+}
+
+// proposeFromRecordings — запасной путь, когда релиза в сервисе нет: написание имени артиста
+// и названия с длительностью каждого трека по отдельным записям. Год и номера так не узнать.
+// Возвращает nil, если не нашлось ни артиста, ни одной записи.
+func proposeFromRecordings(artist Artist, tracks []Track) (*MetadataMatch, error) {
+	m := &MetadataMatch{}
+	a, err := findArtist(artist.Name)
+	if err != nil {
+		return nil, err
+	}
+	if a != nil {
+		m.propose(KindArtist, artist.ID, "name", fmt.Sprintf("артист «%s»", artist.Name), artist.Name, a.Name)
+	}
+	for _, t := range tracks {
+		rec, err := findRecording(artist.Name, t.Title)
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			continue
+		}
+		m.Recordings++
+		label := fmt.Sprintf("трек «%s»", t.Title)
+		m.propose(KindTrack, t.ID, "title", label, t.Title, rec.Title)
+		m.proposeDuration(t, label, rec.Length)
+	}
+	if a == nil && m.Recordings == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+var kindOrder = map[string]int{KindArtist: 0, KindAlbum: 1, KindTrack: 2}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+var metadataFieldNames = map[string]string{
+	"name":     "имя",
+	"title":    "название",
+	"year":     "год",
+	"duration": "длительность",
+	"number":   "номер",
+	"credits":  "участники",
+}
+
+func metadataValue(field, v string) string {
+	if v == "" {
+		return "—"
+	}
+	if field == "duration" {
+		sec, _ := strconv.Atoi(v)
+		return formatDuration(sec)
+	}
+	return v
+}
+
+func describeMetadataChange(c MetadataChange) string {
+	return fmt.Sprintf("%s, %s: %s → %s", c.Label, metadataFieldNames[c.Field],
+		metadataValue(c.Field, c.Old), metadataValue(c.Field, c.New))
+}
+
+// applyMetadata записывает принятые правки одной командой, чтобы их можно было отменить
+func applyMetadata(changes []MetadataChange) error {
+	if len(changes) == 0 {
+		return fmt.Errorf("не выбрано ни одной правки")
+	}
+	return runCommand(&metadataCmd{Changes: changes})
+}
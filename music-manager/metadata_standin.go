@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// --- METADATA STAND-IN ---
+// Локальная подмена сервиса метаданных для работы без сети и для проверок:
+// отвечает на поиск и чтение релизов, поиск артистов и записей в формате MusicBrainz
+// по релизам из JSON-файла (массив релизов с "media", как в ответе /release/<id>?inc=recordings).
+// Артисты и записи берутся из тех же релизов.
+//
+//	music-app metadata-standin -addr 127.0.0.1:8089 -data releases.json
+//	METADATA_URL=http://127.0.0.1:8089/ws/2 music-app
+
+var luceneField = regexp.MustCompile(`(\w+):"((?:[^"\\]|\\.)*)"`)
+
+type metadataStandin struct {
+	releases []mbRelease
+}
+
+func loadMetadataStandin(path string) (*metadataStandin, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &metadataStandin{}
+	if err := json.Unmarshal(data, &s.releases); err != nil {
+		return nil, fmt.Errorf("файл релизов %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Путь может начинаться с любого префикса (например, /ws/2) — важно только то, что после него
+func (s *metadataStandin) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.NotFound(w, req)
+		return
+	}
+	resource, id := "", ""
+	for _, r := range []string{"/release", "/artist", "/recording"} {
+		if i := strings.Index(req.URL.Path, r); i >= 0 {
+			resource, id = r, strings.Trim(req.URL.Path[i+len(r):], "/")
+			break
+		}
+	}
+	query := parseLuceneQuery(req.URL.Query().Get("query"))
+
+	var out interface{}
+	switch {
+	case resource == "/release" && id == "":
+		out = s.search(query)
+	case resource == "/release":
+		for _, r := range s.releases {
+			if r.ID == id {
+				out = r
+			}
+		}
+	case resource == "/artist" && id == "":
+		out = s.searchArtists(query)
+	case resource == "/recording" && id == "":
+		out = s.searchRecordings(query)
+	}
+	if out == nil {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// parseLuceneQuery разбирает запросы вида release:"..." AND artist:"..." в нормализованные значения полей
+func parseLuceneQuery(query string) map[string]string {
+	fields := map[string]string{}
+	for _, m := range luceneField.FindAllStringSubmatch(query, -1) {
+		fields[m[1]] = normalizeName(strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(m[2]))
+	}
+	return fields
+}
+
+// matchScore — наименьшее сходство по заданным полям запроса; поля, которых нет в запросе, не учитываются
+func matchScore(fields map[string]string, values map[string]string) int {
+	score := 100
+	for field, v := range values {
+		if q, ok := fields[field]; ok {
+			score = min(score, similarity(q, normalizeName(v)))
+		}
+	}
+	return score
+}
+
+// search ищет релизы; оценка — сходство названий
+func (s *metadataStandin) search(fields map[string]string) mbReleaseSearch {
+	var found mbReleaseSearch
+	for _, r := range s.releases {
+		score := matchScore(fields, map[string]string{"release": r.Title, "artist": r.artistName()})
+		if score < 50 {
+			continue
+		}
+		r.Score = score
+		r.Media = nil
+		found.Releases = append(found.Releases, r)
+	}
+	sort.SliceStable(found.Releases, func(i, j int) bool { return found.Releases[i].Score > found.Releases[j].Score })
+	return found
+}
+
+// searchArtists ищет среди артистов релизов; ID артиста — его нормализованное имя
+func (s *metadataStandin) searchArtists(fields map[string]string) mbArtistSearch {
+	var found mbArtistSearch
+	seen := map[string]bool{}
+	for _, r := range s.releases {
+		for _, c := range r.ArtistCredit {
+			id := normalizeName(c.Name)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if score := matchScore(fields, map[string]string{"artist": c.Name}); score >= 50 {
+				found.Artists = append(found.Artists, mbArtist{ID: id, Score: score, Name: c.Name})
+			}
+		}
+	}
+	sort.SliceStable(found.Artists, func(i, j int) bool { return found.Artists[i].Score > found.Artists[j].Score })
+	return found
+}
+
+// searchRecordings ищет среди треков релизов; ID записи — ID релиза и номер трека
+func (s *metadataStandin) searchRecordings(fields map[string]string) mbRecordingSearch {
+	var found mbRecordingSearch
+	for _, r := range s.releases {
+		for _, m := range r.Media {
+			for _, t := range m.Tracks {
+				score := matchScore(fields, map[string]string{"recording": t.Title, "artist": r.artistName()})
+				if score < 50 {
+					continue
+				}
+				found.Recordings = append(found.Recordings, mbRecording{
+					ID: fmt.Sprintf("%s-%d-%d", r.ID, m.Position, t.Position), Score: score,
+					Title: t.Title, Length: t.Length, ArtistCredit: r.ArtistCredit,
+				})
+			}
+		}
+	}
+	sort.SliceStable(found.Recordings, func(i, j int) bool { return found.Recordings[i].Score > found.Recordings[j].Score })
+	return found
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// memoryCache — кэш ответов в памяти вместо таблицы metadata_cache
+type memoryCache map[string]string
+
+func (c memoryCache) CachedResponse(url string, maxAge time.Duration) (string, bool, error) {
+	body, ok := c[url]
+	return body, ok, nil
+}
+
+func (c memoryCache) SaveCachedResponse(url, body string) error {
+	c[url] = body
+	return nil
+}
+
+var standinReleases = []mbRelease{
+	{
+		ID: "r-ok", Title: "OK Computer", Date: "1997-05-21",
+		ArtistCredit: []mbArtistCredit{{Name: "Radiohead"}},
+		Media: []mbMedium{
+			{Position: 1, Tracks: []mbTrack{
+				{Position: 1, Title: "Airbag", Length: 284000},
+				{Position: 2, Title: "Paranoid Android", Length: 383400},
+			}},
+		},
+	},
+	{
+		ID: "r-duet", Title: "Duets", Date: "2001",
+		ArtistCredit: []mbArtistCredit{{Name: "Alpha", JoinPhrase: " & "}, {Name: "Beta"}},
+		Media: []mbMedium{
+			{Position: 1, Tracks: []mbTrack{{Position: 1, Title: "Together", Length: 200000}}},
+			{Position: 2, Tracks: []mbTrack{{Position: 1, Title: "Apart", Length: 180000}}},
+		},
+	},
+}
+
+// startMetadataStandin направляет клиент метаданных на подмену сервиса в httptest-сервере
+func startMetadataStandin(t *testing.T) (*httptest.Server, memoryCache) {
+	t.Helper()
+	srv := httptest.NewServer(&metadataStandin{releases: standinReleases})
+	cache := memoryCache{}
+	oldURL, oldInterval, oldCache := metadataURL, metadataInterval, metadataCache
+	metadataURL, metadataInterval = srv.URL+"/ws/2", 0
+	metadataCache = func() responseCache { return cache }
+	t.Cleanup(func() {
+		srv.Close()
+		metadataURL, metadataInterval, metadataCache = oldURL, oldInterval, oldCache
+	})
+	return srv, cache
+}
+
+func TestFindRelease(t *testing.T) {
+	startMetadataStandin(t)
+	tests := []struct {
+		artist, album string
+		want          string // ID релиза, "" — не найден
+		tracks        int
+	}{
+		{"Radiohead", "OK Computer", "r-ok", 2},
+		{"radiohead", "Ok Computer!", "r-ok", 2},
+		{"The Radiohead", "OK Computer", "r-ok", 2},
+		{"Alpha & Beta", "Duets", "r-duet", 2},
+		{"Radiohead", "Kid A", "", 0},
+		{"Nobody", "OK Computer", "", 0},
+	}
+	for _, tt := range tests {
+		rel, err := findRelease(tt.artist, tt.album)
+		if err != nil {
+			t.Fatalf("findRelease(%q, %q): %v", tt.artist, tt.album, err)
+		}
+		got, tracks := "", 0
+		if rel != nil {
+			got = rel.ID
+			for _, m := range rel.Media {
+				tracks += len(m.Tracks)
+			}
+		}
+		if got != tt.want || tracks != tt.tracks {
+			t.Errorf("findRelease(%q, %q) = %q с %d треками, want %q с %d", tt.artist, tt.album, got, tracks, tt.want, tt.tracks)
+		}
+	}
+}
+
+func TestFindArtist(t *testing.T) {
+	startMetadataStandin(t)
+	tests := []struct{ query, want string }{
+		{"Radiohead", "Radiohead"},
+		{"radiohed", "Radiohead"},
+		{"Beta", "Beta"},
+		{"Someone Else", ""},
+	}
+	for _, tt := range tests {
+		a, err := findArtist(tt.query)
+		if err != nil {
+			t.Fatalf("findArtist(%q): %v", tt.query, err)
+		}
+		got := ""
+		if a != nil {
+			got = a.Name
+		}
+		if got != tt.want {
+			t.Errorf("findArtist(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFindRecording(t *testing.T) {
+	startMetadataStandin(t)
+	tests := []struct {
+		artist, title string
+		want          string
+		length        int
+	}{
+		{"Radiohead", "Paranoid Android", "Paranoid Android", 383400},
+		{"Radiohead", "paranoid androd", "Paranoid Android", 383400},
+		{"Alpha & Beta", "Apart", "Apart", 180000},
+		{"Radiohead", "Apart", "", 0},
+	}
+	for _, tt := range tests {
+		rec, err := findRecording(tt.artist, tt.title)
+		if err != nil {
+			t.Fatalf("findRecording(%q, %q): %v", tt.artist, tt.title, err)
+		}
+		got, length := "", 0
+		if rec != nil {
+			got, length = rec.Title, rec.Length
+		}
+		if got != tt.want || length != tt.length {
+			t.Errorf("findRecording(%q, %q) = %q (%d мс), want %q (%d мс)", tt.artist, tt.title, got, length, tt.want, tt.length)
+		}
+	}
+}
+
+// Повторный запрос берётся из кэша и не требует сервиса
+func TestMetadataCache(t *testing.T) {
+	srv, cache := startMetadataStandin(t)
+	if _, err := findArtist("Radiohead"); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache) != 1 {
+		t.Fatalf("в кэше %d ответов, want 1", len(cache))
+	}
+	srv.Close()
+	a, err := findArtist("Radiohead")
+	if err != nil || a == nil || a.Name != "Radiohead" {
+		t.Fatalf("findArtist из кэша = %v, %v", a, err)
+	}
+}
+
+func TestProposeFromRelease(t *testing.T) {
+	startMetadataStandin(t)
+	rel, err := findRelease("Radiohead", "OK Computer")
+	if err != nil || rel == nil {
+		t.Fatalf("findRelease: %v, %v", rel, err)
+	}
+	artist := Artist{ID: 1, Name: "radiohead"}
+	album := Album{ID: 2, Title: "OK Computer", ArtistID: 1}
+	tracks := []Track{
+		{ID: 3, Title: "Airbag", Duration: 284, Number: 1},          // совпадает
+		{ID: 4, Title: "Paranoid Androd", Duration: 380, Number: 0}, // опечатка, нет номера, другая длительность
+		{ID: 5, Title: "Lucky", Duration: 259},                      // в релизе нет
+	}
+	m := proposeFromRelease(rel, artist, album, tracks)
+	want := map[string]string{
+		"artist/1/name":    "Radiohead",
+		"album/2/year":     "1997",
+		"track/4/title":    "Paranoid Android",
+		"track/4/number":   "2",
+		"track/4/duration": "383",
+	}
+	got := map[string]string{}
+	for _, c := range m.Changes {
+		got[fmt.Sprintf("%s/%d/%s", c.Kind, c.ID, c.Field)] = c.New
+	}
+	if len(got) != len(want) {
+		t.Errorf("правки %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestMetadataArg(t *testing.T) {
+	tests := []struct {
+		numeric bool
+		value   string
+		want    interface{}
+		wantErr bool
+	}{
+		{true, "", sql.NullInt64{}, false},
+		{true, "0", sql.NullInt64{}, false},
+		{true, "1997", sql.NullInt64{Int64: 1997, Valid: true}, false},
+		{true, "девяносто седьмой", nil, true},
+		{false, "", "", false},
+		{false, "OK Computer", "OK Computer", false},
+	}
+	for _, tt := range tests {
+		got, err := metadataArg(tt.numeric, "альбом", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("metadataArg(%v, %q): ошибка %v", tt.numeric, tt.value, err)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("metadataArg(%v, %q) = %#v, want %#v", tt.numeric, tt.value, got, tt.want)
+		}
+	}
+}
+
+// Пустые год и длительность: правка записывает число, отмена — NULL, который читатели
+// каталога возвращают как 0, и следующее предложение снова видит пустое поле
+func TestMetadataEmptyFieldRoundTrip(t *testing.T) {
+	startMetadataStandin(t)
+	rel, err := findRelease("Radiohead", "OK Computer")
+	if err != nil || rel == nil {
+		t.Fatalf("findRelease: %v, %v", rel, err)
+	}
+	album := Album{ID: 2, Title: "OK Computer", ArtistID: 1}
+	tracks := []Track{{ID: 3, Title: "Airbag", Number: 1}}
+	m := proposeFromRelease(rel, Artist{ID: 1, Name: "Radiohead"}, album, tracks)
+
+	// stored — что прочитает COALESCE(столбец, 0) после записи arg
+	stored := func(arg interface{}) int {
+		n := arg.(sql.NullInt64)
+		if !n.Valid {
+			return 0
+		}
+		return int(n.Int64)
+	}
+	checked := 0
+	for _, c := range m.Changes {
+		if c.Field != "year" && c.Field != "duration" {
+			continue
+		}
+		checked++
+		if c.Old != "" {
+			t.Errorf("%s: прежнее значение %q, want пустое", c.Field, c.Old)
+		}
+		apply, err := metadataArg(true, c.Label, c.New)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Field, err)
+		}
+		if got := optionalItoa(stored(apply)); got != c.New {
+			t.Errorf("%s после правки = %q, want %q", c.Field, got, c.New)
+		}
+		revert, err := metadataArg(true, c.Label, c.Old)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Field, err)
+		}
+		if revert != (sql.NullInt64{}) {
+			t.Errorf("%s: отмена пишет %#v, want NULL", c.Field, revert)
+		}
+		if got := optionalItoa(stored(revert)); got != c.Old {
+			t.Errorf("%s после отмены = %q, want %q", c.Field, got, c.Old)
+		}
+	}
+	if checked != 2 {
+		t.Errorf("проверено полей: %d, want год и длительность", checked)
+	}
+}
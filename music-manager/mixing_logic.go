@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// --- TEMPO AND KEY ---
+
+type TempoReport struct {
+	Tracks int
+	Failed []string // файлы, которые не удалось обработать, с причиной
+}
+
+// analyzeTempoJob определяет темп и тональность треков с привязанными файлами;
+// onlyMissing — только треки, для которых они ещё не определены
+func analyzeTempoJob(onlyMissing bool, progress func(done, total int)) (*TempoReport, error) {
+	if err := requireCatalogEditor(); err != nil {
+		return nil, err
+	}
+	files, err := repo.GetTrackFiles()
+	if err != nil {
+		return nil, err
+	}
+	var todo []TrackFile
+	for _, f := range files {
+		if !onlyMissing || !f.HasTempo {
+			todo = append(todo, f)
+		}
+	}
+	rep := &TempoReport{}
+	for i, f := range todo {
+		bpm, key, err := detectTempoAndKey(f.Path)
+		if err != nil {
+			rep.Failed = append(rep.Failed, fmt.Sprintf("%s: %v", f.Path, err))
+		} else if err := repo.SaveTempo(f.TrackID, bpm, key); err != nil {
+			return rep, err
+		} else {
+			rep.Tracks++
+		}
+		if progress != nil {
+			progress(i+1, len(todo))
+		}
+	}
+	return rep, nil
+}
+
+// detectTempoAndKey — темп обязателен, тональность может остаться неопределённой (ударные, речь)
+func detectTempoAndKey(path string) (float64, string, error) {
+	a, err := decodePCM(path, tempoMaxSeconds)
+	if err != nil {
+		return 0, "", err
+	}
+	samples := resample(a.mono(), a.Rate, tempoSampleRate)
+	bpm, err := detectTempo(samples)
+	if err != nil {
+		return 0, "", err
+	}
+	key, _ := detectKey(samples)
+	return bpm, key, nil
+}
+
+func describeTempoReport(rep *TempoReport) string {
+	s := fmt.Sprintf("Определены темп и тональность треков: %d.", rep.Tracks)
+	if len(rep.Failed) > 0 {
+		s += fmt.Sprintf("\nНе удалось обработать (%d):\n  %s", len(rep.Failed), strings.Join(rep.Failed, "\n  "))
+	}
+	return s
+}
+
+// --- TRACK PICKER ---
+
+// Сортировка треков в выборе для плейлиста
+const (
+	TrackSortTitle = "title"
+	TrackSortBPM   = "bpm"
+	TrackSortKey   = "key"
+)
+
+var trackSortOptions = []string{TrackSortTitle, TrackSortBPM, TrackSortKey}
+
+var trackSortLabels = map[string]string{
+	TrackSortTitle: "По названию",
+	TrackSortBPM:   "По темпу",
+	TrackSortKey:   "По тональности",
+}
+
+// TrackFilter — условия выбора треков: пустые поля не ограничивают
+type TrackFilter struct {
+	Text           string
+	MinBPM, MaxBPM float64
+	Key            string // оставить треки, гармонично совместимые с этой тональностью
+	Sort           string
+}
+
+// filterTracks отбирает и сортирует треки; при фильтре по темпу или тональности
+// треки без этих данных не попадают в выборку
+func filterTracks(tracks []Track, f TrackFilter) []Track {
+	var out []Track
+	for _, t := range tracks {
+		if f.Text != "" && !containsIgnoreCase(t.Title, f.Text) {
+			continue
+		}
+		if (f.MinBPM > 0 || f.MaxBPM > 0) && t.BPM == 0 {
+			continue
+		}
+		if (f.MinBPM > 0 && t.BPM < f.MinBPM) || (f.MaxBPM > 0 && t.BPM > f.MaxBPM) {
+			continue
+		}
+		if f.Key != "" && !keysCompatible(t.Key, f.Key) {
+			continue
+		}
+		out = append(out, t)
+	}
+	switch f.Sort {
+	case TrackSortBPM:
+		// Треки без темпа — в конце
+		sort.SliceStable(out, func(i, j int) bool {
+			a, b := out[i].BPM, out[j].BPM
+			return a != 0 && (b == 0 || a < b)
+		})
+	case TrackSortKey:
+		sort.SliceStable(out, func(i, j int) bool { return keyOrder(out[i].Key) < keyOrder(out[j].Key) })
+	}
+	return out
+}
+
+// keyOrder — положение тональности на круге Camelot; неизвестные — в конце
+func keyOrder(key string) int {
+	n, minor, ok := parseCamelot(key)
+	if !ok {
+		return math.MaxInt
+	}
+	if minor {
+		return 2 * n
+	}
+	return 2*n + 1
+}
+
+// trackPickerLabel — название с длительностью, темпом и тональностью, если они известны
+func trackPickerLabel(t Track) string {
+	s := t.Title + " (" + formatDuration(t.Duration)
+	if t.BPM > 0 {
+		s += fmt.Sprintf(", %g BPM", t.BPM)
+	}
+	if t.Key != "" {
+		s += ", " + t.Key
+	}
+	return s + ")"
+}
+
+// --- MIXING ORDER ---
+
+// mixCost — насколько тяжело свести трек a в трек b. Темп сравнивается с учётом
+// двойного и половинного (70 и 140 BPM сводятся), каждый процент разницы —
+// полшага; каждый шаг по кругу Camelot сверх совместимого — два шага.
+func mixCost(a, b Track) float64 {
+	cost := 0.0
+	if a.BPM > 0 && b.BPM > 0 {
+		ratio := b.BPM / a.BPM
+		for ratio > 1.5 {
+			ratio /= 2
+		}
+		for ratio < 0.75 {
+			ratio *= 2
+		}
+		cost += math.Abs(ratio-1) * 100 / 2
+	} else {
+		cost += 5
+	}
+	if d := keyDistance(a.Key, b.Key); d > 1 {
+		cost += float64(2 * (d - 1))
+	}
+	return cost
+}
+
+// mixOrder выстраивает треки для плавного сведения: от самого медленного жадно
+// к ближайшему по стоимости, затем 2-opt убирает пересечения. Треки без темпа — в конце.
+func mixOrder(tracks []Track) []Track {
+	var known, unknown []Track
+	for _, t := range tracks {
+		if t.BPM > 0 {
+			known = append(known, t)
+		} else {
+			unknown = append(unknown, t)
+		}
+	}
+	if len(known) < 3 {
+		sort.SliceStable(known, func(i, j int) bool { return known[i].BPM < known[j].BPM })
+		return append(known, unknown...)
+	}
+	start := 0
+	for i, t := range known {
+		if t.BPM < known[start].BPM {
+			start = i
+		}
+	}
+	order := []Track{known[start]}
+	used := map[int]bool{start: true}
+	for len(order) < len(known) {
+		last := order[len(order)-1]
+		next := -1
+		for i, t := range known {
+			if !used[i] && (next < 0 || mixCost(last, t) < mixCost(last, known[next])) {
+				next = i
+			}
+		}
+		used[next] = true
+		order = append(order, known[next])
+	}
+	// 2-opt: разворачиваем отрезок, если стыки на его концах становятся дешевле
+	for improved, pass := true, 0; improved && pass < 50; pass++ {
+		improved = false
+		for i := 0; i < len(order)-2; i++ {
+			for j := i + 2; j < len(order); j++ {
+				before := mixCost(order[i], order[i+1])
+				after := mixCost(order[i], order[j])
+				if j+1 < len(order) {
+					before += mixCost(order[j], order[j+1])
+					after += mixCost(order[i+1], order[j+1])
+				}
+				if after < before-1e-9 {
+					for l, r := i+1, j; l < r; l, r = l+1, r-1 {
+						order[l], order[r] = order[r], order[l]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+	return append(order, unknown...)
+}
+
+// mixedEntries переставляет вхождения плейлиста в порядке mixOrder. Позиции остаются тем же
+// набором, меняется только то, какому треку какая принадлежит.
+func mixedEntries(entries []EntryRow, byID map[int]Track) ([]EntryRow, error) {
+	var tracks []Track
+	withTempo := 0
+	for _, e := range entries {
+		t, ok := byID[e.TrackID]
+		if !ok {
+			t = Track{ID: e.TrackID} // удалённый из каталога трек уйдёт в конец
+		}
+		if t.BPM > 0 {
+			withTempo++
+		}
+		tracks = append(tracks, t)
+	}
+	if withTempo == 0 {
+		return nil, fmt.Errorf("у треков плейлиста не определён темп — сначала проанализируйте файлы")
+	}
+	entryOf := map[int]EntryRow{}
+	for _, e := range entries {
+		entryOf[e.TrackID] = e
+	}
+	var after []EntryRow
+	for i, t := range mixOrder(tracks) {
+		e := entryOf[t.ID]
+		e.Position = entries[i].Position
+		after = append(after, e)
+	}
+	return after, nil
+}
+
+// autoOrderForMixing переставляет треки плейлиста в порядке mixOrder одной отменяемой правкой
+func autoOrderForMixing(p *Playlist) (bool, error) {
+	if err := requirePlaylistPermission(p.ID, PermEdit); err != nil {
+		return false, err
+	}
+	entries, err := repo.SnapshotEntries(p.ID, nil)
+	if err != nil {
+		return false, err
+	}
+	if len(entries) < 2 {
+		return false, fmt.Errorf("в плейлисте меньше двух треков")
+	}
+	all, err := repo.GetTracks()
+	if err != nil {
+		return false, err
+	}
+	byID := map[int]Track{}
+	for _, t := range all {
+		byID[t.ID] = t
+	}
+	after, err := mixedEntries(entries, byID)
+	if err != nil {
+		return false, err
+	}
+	cmd := &reorderEntriesCmd{PlaylistID: p.ID, Before: entries, After: after}
+	if err := runCommand(cmd); err != nil {
+		return false, err
+	}
+	return applyPlaylistVersion(p, cmd.version), nil
+}
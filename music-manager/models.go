@@ -1,14 +1,106 @@
 package main
 
+import "time"
+
+// Роли пользователей
+const (
+	RoleAdmin    = "admin"    // управляет пользователями и каталогом
+	RoleEditor   = "editor"   // редактирует каталог
+	RoleListener = "listener" // ведёт только свои плейлисты
+)
+
 // DATA STRUCTURES
 type User struct {
 	ID       int
 	Username string
+	Role     string
 }
 
+// Права на плейлист
+const (
+	PermView  = "view"  // только просмотр
+	PermAdd   = "add"   // добавление треков
+	PermEdit  = "edit"  // добавление и удаление треков
+	PermOwner = "owner" // владелец: всё, включая удаление и приглашения
+)
+
+// Видимость плейлиста
+const (
+	VisibilityPrivate  = "private"  // владелец и участники
+	VisibilityUnlisted = "unlisted" // любой, кто знает код ссылки
+	VisibilityPublic   = "public"   // виден в обзоре
+)
+
 type Playlist struct {
-	ID    int
-	Title string
+	ID         int
+	Title      string
+	Version    int    // счётчик изменений состава, для обнаружения чужих правок
+	OwnerName  string // заполняется для чужих плейлистов
+	Permission string // права текущего пользователя
+	Visibility string
+	FolderID   int // 0 — в корне
+}
+
+// Сводка плейлиста для заголовка и командной строки
+type PlaylistSummary struct {
+	PlaylistID int       `json:"id"`
+	Title      string    `json:"title"`
+	Tracks     int       `json:"tracks"`
+	Duration   int       `json:"duration"` // секунды
+	Artists    int       `json:"artists"`  // разных артистов
+	FirstYear  int       `json:"first_year,omitempty"`
+	LastYear   int       `json:"last_year,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type PlaylistFolder struct {
+	ID       int
+	Name     string
+	ParentID int // 0 — в корне
+}
+
+// Плейлист в обзоре публичных плейлистов
+type PublicPlaylist struct {
+	Playlist
+	OwnerID        int
+	Followers      int
+	TrackCount     int
+	Following      bool // текущий пользователь подписан на плейлист
+	FollowingOwner bool // текущий пользователь подписан на владельца
+}
+
+// Трек вместе с альбомом и артистом — для экспорта и подробных списков
+type TrackInfo struct {
+	Track
+	AlbumTitle string
+	ArtistID   int
+	ArtistName string
+	Year       int
+	// Заполняются для экспорта (attachAudioInfo)
+	FilePath      string    // файл, с которого снят отпечаток; "" — не привязан
+	TrackLoudness *Loudness // nil — не измерена
+	AlbumLoudness *Loudness
+}
+
+// Loudness — громкость записи по EBU R128 и поправка ReplayGain
+type Loudness struct {
+	LUFS     float64 // интегральная громкость
+	TruePeak float64 // dBTP
+	Gain     float64 // дБ до -18 LUFS
+}
+
+// Трек в плейлисте с информацией о том, кто его добавил
+type PlaylistEntry struct {
+	Track
+	AddedBy string
+	AddedAt time.Time
+}
+
+type Collaborator struct {
+	UserID     int
+	Username   string
+	Permission string
 }
 
 type Artist struct {
@@ -21,6 +113,7 @@ type Album struct {
 	Title    string
 	ArtistID int // внешний ключ к таблице artists
 	Year     int
+	Credits  string // участники, свободным текстом
 }
 
 type Track struct {
@@ -28,4 +121,95 @@ type Track struct {
 	Title    string
 	AlbumID  int // Внешний ключ к таблице albums
 	Duration int
+	Genre    string
+	Number   int     // номер трека на альбоме, 0 — неизвестен
+	BPM      float64 // темп, 0 — не определён
+	Key      string  // тональность в нотации Camelot ("8A"), "" — не определена
+}
+
+// Строки таблиц каталога: запись и то, что показывается в колонках рядом с ней
+
+type ArtistRow struct {
+	Artist
+	Albums int
+	Tracks int
+}
+
+type AlbumRow struct {
+	Album
+	ArtistName string
+	Tracks     int
+	Duration   int // суммарная длительность треков, секунды
+}
+
+type TrackRow struct {
+	Track
+	ArtistName string
+	AlbumTitle string
+	Year       int
+	Rating     int // оценка текущего пользователя, 0 — нет
+}
+
+// TableQuery — поиск и сортировка таблицы каталога; Sort — ключ колонки
+type TableQuery struct {
+	Search string
+	Sort   string
+	Desc   bool
+}
+
+// Что затронет удаление записей каталога
+type DeleteImpact struct {
+	Albums            int
+	Tracks            int
+	PlaylistEntries   int
+	Playlists         int
+	Users             int // владельцы затронутых плейлистов
+	OtherUsersEntries int // записи в плейлистах других пользователей
+	AffectedPlaylists []AffectedPlaylist
+}
+
+type AffectedPlaylist struct {
+	Title   string
+	Owner   string
+	OwnerID int
+	Entries int
+}
+
+type LoginAttempt struct {
+	Username    string
+	Source      string
+	Success     bool
+	Reason      string
+	AttemptedAt time.Time
+}
+
+type Session struct {
+	ID         int
+	Device     string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// AuditEntry — запись журнала изменений; Before/After — строка в JSON до и после изменения
+type AuditEntry struct {
+	ID         int64
+	UserID     int // 0 — изменение без пользователя (например, регистрация)
+	Username   string
+	CreatedAt  time.Time
+	Operation  string
+	EntityType string
+	EntityID   int
+	Before     string
+	After      string
+}
+
+// AuditFilter — условия выборки журнала; пустые поля не ограничивают выборку
+type AuditFilter struct {
+	Username   string
+	Operation  string
+	EntityType string
+	EntityID   int
+	From, To   time.Time
+	Limit      int
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// multiSelection — множественный выбор строк списка по ID записей.
+// Клик выбирает одну строку, Ctrl+клик переключает строку, Shift+клик выделяет диапазон;
+// флажки в строках работают как Ctrl+клик.
+type multiSelection struct {
+	selected map[int]bool
+	anchor   int // индекс строки последнего клика — начало диапазона для Shift
+	onChange func()
+}
+
+func newMultiSelection(onChange func()) *multiSelection {
+	return &multiSelection{selected: map[int]bool{}, onChange: onChange}
+}
+
+func currentModifiers() fyne.KeyModifier {
+	if drv, ok := fyne.CurrentApp().Driver().(desktop.Driver); ok {
+		return drv.CurrentKeyModifiers()
+	}
+	return 0
+}
+
+// click обрабатывает клик по строке index; idAt возвращает ID записи в строке
+func (m *multiSelection) click(index int, idAt func(int) int) {
+	mods := currentModifiers()
+	switch {
+	case mods&fyne.KeyModifierShift != 0:
+		from, to := m.anchor, index
+		if from > to {
+			from, to = to, from
+		}
+		for i := from; i <= to; i++ {
+			m.selected[idAt(i)] = true
+		}
+	case mods&(fyne.KeyModifierControl|fyne.KeyModifierSuper) != 0:
+		m.toggle(idAt(index), !m.selected[idAt(index)])
+		m.anchor = index
+	default:
+		m.selected = map[int]bool{idAt(index): true}
+		m.anchor = index
+	}
+	m.changed()
+}
+
+func (m *multiSelection) toggle(id int, on bool) {
+	if on {
+		m.selected[id] = true
+	} else {
+		delete(m.selected, id)
+	}
+	m.changed()
+}
+
+func (m *multiSelection) isSelected(id int) bool { return m.selected[id] }
+func (m *multiSelection) count() int             { return len(m.selected) }
+
+func (m *multiSelection) ids() []int {
+	ids := make([]int, 0, len(m.selected))
+	for id := range m.selected {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (m *multiSelection) selectAll(ids []int) {
+	for _, id := range ids {
+		m.selected[id] = true
+	}
+	m.changed()
+}
+
+func (m *multiSelection) clear() {
+	m.selected = map[int]bool{}
+	m.changed()
+}
+
+// retain убирает из выбора записи, которых больше нет
+func (m *multiSelection) retain(ids []int) {
+	alive := map[int]bool{}
+	for _, id := range ids {
+		alive[id] = true
+	}
+	for id := range m.selected {
+		if !alive[id] {
+			delete(m.selected, id)
+		}
+	}
+}
+
+func (m *multiSelection) changed() {
+	if m.onChange != nil {
+		m.onChange()
+	}
+}
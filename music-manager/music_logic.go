@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // --- PLAYLISTS ---
@@ -22,7 +24,7 @@ func createPlaylist(title string) error {
 	if title == "" {
 		return fmt.Errorf("название плейлиста не может быть пустым")
 	}
-	return repo.CreatePlaylist(title, currentUser.ID)
+	return runCommand(&createPlaylistCmd{Title: title})
 }
 
 // Получение треков конкретного плейлиста
@@ -34,15 +36,41 @@ func getTracksFromPlaylist(playlistID int) ([]Track, []string) {
 
 	var names []string
 	for _, t := range items {
-		min := t.Duration / 60
-		sec := t.Duration % 60
-		names = append(names, fmt.Sprintf("%s (%d:%02d)", t.Title, min, sec))
+		names = append(names, fmt.Sprintf("%s (%s)", t.Title, formatDuration(t.Duration)))
 	}
 	return items, names
 }
 
+// getPlaylistSummary — сводка плейлиста, доступного текущему пользователю
+func getPlaylistSummary(pID int) (*PlaylistSummary, error) {
+	if err := requirePlaylistPermission(pID, PermView); err != nil {
+		return nil, err
+	}
+	return repo.GetPlaylistSummary(pID)
+}
+
+// describePlaylistSummary — "треков: 12 · 48:10 · артистов: 5 · 1994–2021 · создан … · изменён …"
+func describePlaylistSummary(s *PlaylistSummary) string {
+	parts := []string{
+		fmt.Sprintf("треков: %d", s.Tracks),
+		formatDuration(s.Duration),
+		fmt.Sprintf("артистов: %d", s.Artists),
+	}
+	switch {
+	case s.FirstYear == 0:
+	case s.FirstYear == s.LastYear:
+		parts = append(parts, fmt.Sprint(s.FirstYear))
+	default:
+		parts = append(parts, fmt.Sprintf("%d–%d", s.FirstYear, s.LastYear))
+	}
+	parts = append(parts,
+		"создан "+s.CreatedAt.Format("02.01.2006 15:04"),
+		"изменён "+s.UpdatedAt.Format("02.01.2006 15:04"))
+	return strings.Join(parts, " · ")
+}
+
 func deletePlaylist(id int) error {
-	return repo.DeletePlaylist(id)
+	return runCommand(&deletePlaylistCmd{ID: id})
 }
 
 // --- ARTISTS ---
@@ -60,14 +88,25 @@ func getArtists() ([]Artist, []string) {
 }
 
 func addArtist(name string) error {
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
 	if name == "" {
 		return fmt.Errorf("имя артиста пустое")
 	}
-	return repo.CreateArtist(name)
+	return runCommand(&createCatalogCmd{Kind: KindArtist, Title: name})
+}
+
+func editArtist(a Artist, name string) error {
+	if name == "" {
+		return fmt.Errorf("имя артиста пустое")
+	}
+	return runCommand(&editCatalogCmd{Kind: KindArtist, ID: a.ID,
+		Before: CatalogFields{Title: a.Name}, After: CatalogFields{Title: name}})
 }
 
 func deleteArtist(id int) error {
-	return repo.DeleteArtist(id)
+	return bulkDelete(KindArtist, []int{id})
 }
 
 // --- ALBUMS ---
@@ -84,12 +123,36 @@ func getAlbums() ([]Album, []string) {
 	return items, names
 }
 
+// parseYear разбирает год альбома; пустая строка — год не указан (0)
+func parseYear(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	year, err := strconv.Atoi(s)
+	if err != nil || year < 1000 || year > 9999 {
+		return 0, fmt.Errorf("неверный год %q", s)
+	}
+	return year, nil
+}
+
 func addAlbum(title string, artistID, year int) error {
-	return repo.CreateAlbum(title, artistID, year)
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	return runCommand(&createCatalogCmd{Kind: KindAlbum, Title: title, ParentID: artistID, Number: year})
+}
+
+func editAlbum(a Album, title string, year int) error {
+	if title == "" {
+		return fmt.Errorf("название альбома пустое")
+	}
+	return runCommand(&editCatalogCmd{Kind: KindAlbum, ID: a.ID,
+		Before: CatalogFields{Title: a.Title, Number: a.Year}, After: CatalogFields{Title: title, Number: year}})
 }
 
 func deleteAlbum(id int) error {
-	return repo.DeleteAlbum(id)
+	return bulkDelete(KindAlbum, []int{id})
 }
 
 // --- TRACKS ---
@@ -101,17 +164,26 @@ func getTracks() ([]Track, []string) {
 	}
 	var names []string
 	for _, t := range items {
-		min := t.Duration / 60
-		sec := t.Duration % 60
-		names = append(names, fmt.Sprintf("%s (%d:%02d)", t.Title, min, sec))
+		names = append(names, fmt.Sprintf("%s (%s)", t.Title, formatDuration(t.Duration)))
 	}
 	return items, names
 }
 
 func addTrack(title string, albumID, duration int) error {
-	return repo.CreateTrack(title, albumID, duration)
+	if err := requireCatalogEditor(); err != nil {
+		return err
+	}
+	return runCommand(&createCatalogCmd{Kind: KindTrack, Title: title, ParentID: albumID, Number: duration})
+}
+
+func editTrack(t Track, title string, duration int) error {
+	if title == "" {
+		return fmt.Errorf("название трека пустое")
+	}
+	return runCommand(&editCatalogCmd{Kind: KindTrack, ID: t.ID,
+		Before: CatalogFields{Title: t.Title, Number: t.Duration}, After: CatalogFields{Title: title, Number: duration}})
 }
 
 func deleteTrack(id int) error {
-	return repo.DeleteTrack(id)
+	return bulkDelete(KindTrack, []int{id})
 }
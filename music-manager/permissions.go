@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+)
+
+var errForbidden = fmt.Errorf("недостаточно прав для этого действия")
+
+func hasRole(roles ...string) bool {
+	if currentUser == nil {
+		return false
+	}
+	for _, r := range roles {
+		if currentUser.Role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// Каталог (артисты, альбомы, треки) редактируют администраторы и редакторы
+func canEditCatalog() bool {
+	return hasRole(RoleAdmin, RoleEditor)
+}
+
+func isAdmin() bool {
+	return hasRole(RoleAdmin)
+}
+
+func requireCatalogEditor() error {
+	if !canEditCatalog() {
+		return errForbidden
+	}
+	return nil
+}
+
+func requireAdmin() error {
+	if !isAdmin() {
+		return errForbidden
+	}
+	return nil
+}
+
+func validRole(role string) bool {
+	return role == RoleAdmin || role == RoleEditor || role == RoleListener
+}
+
+// --- USER MANAGEMENT ---
+
+func getUsers() ([]User, error) {
+	if err := requireAdmin(); err != nil {
+		return nil, err
+	}
+	return repo.GetUsers()
+}
+
+// ensureNotLastAdmin не даёт оставить систему без администратора
+func ensureNotLastAdmin(u User) error {
+	if u.Role != RoleAdmin {
+		return nil
+	}
+	n, err := repo.CountUsersWithRole(RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if n <= 1 {
+		return fmt.Errorf("нельзя лишить систему последнего администратора")
+	}
+	return nil
+}
+
+func setUserRole(u User, role string) error {
+	if err := requireAdmin(); err != nil {
+		return err
+	}
+	if !validRole(role) {
+		return fmt.Errorf("неизвестная роль %q", role)
+	}
+	if role != RoleAdmin {
+		if err := ensureNotLastAdmin(u); err != nil {
+			return err
+		}
+	}
+	if err := repo.SetUserRole(u.ID, role); err != nil {
+		return err
+	}
+	if u.ID == currentUser.ID {
+		currentUser.Role = role
+	}
+	return nil
+}
+
+func removeUser(u User) error {
+	if err := requireAdmin(); err != nil {
+		return err
+	}
+	if u.ID == currentUser.ID {
+		return fmt.Errorf("свой аккаунт удаляется на вкладке «Аккаунт»")
+	}
+	if err := ensureNotLastAdmin(u); err != nil {
+		return err
+	}
+	return repo.RemoveUser(u.ID)
+}
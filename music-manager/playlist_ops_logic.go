@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+)
+
+var setOperationLabels = map[string]string{
+	SetUnion:        "Объединение (A ∪ B)",
+	SetIntersection: "Пересечение (A ∩ B)",
+	SetDifference:   "Разность (A − B)",
+}
+
+var setOperationOptions = []string{SetUnion, SetIntersection, SetDifference}
+
+var splitModeLabels = map[string]string{
+	SplitByCount:    "По числу треков",
+	SplitByDuration: "По длительности (минуты)",
+}
+
+var splitModeOptions = []string{SplitByCount, SplitByDuration}
+
+func duplicatePlaylist(p Playlist) (string, error) {
+	if err := requirePlaylistPermission(p.ID, PermView); err != nil {
+		return "", err
+	}
+	title := uniquePlaylistTitle(p.Title + " (копия)")
+	_, err := repo.CopyPlaylist(p.ID, currentUser.ID, title)
+	return title, err
+}
+
+func mergePlaylists(sources []Playlist, title string, dedup bool) error {
+	if len(sources) < 2 {
+		return fmt.Errorf("выберите хотя бы два плейлиста")
+	}
+	if title == "" {
+		return fmt.Errorf("название плейлиста не может быть пустым")
+	}
+	var ids []int
+	for _, p := range sources {
+		if err := requirePlaylistPermission(p.ID, PermView); err != nil {
+			return err
+		}
+		ids = append(ids, p.ID)
+	}
+	_, err := repo.MergePlaylists(ids, currentUser.ID, title, dedup)
+	return err
+}
+
+// splitPlaylist делит плейлист на части; limit — треков или минут на часть в зависимости от mode
+func splitPlaylist(p Playlist, mode string, limit int) (int, error) {
+	if err := requirePlaylistPermission(p.ID, PermView); err != nil {
+		return 0, err
+	}
+	if mode == SplitByDuration {
+		limit *= 60
+	}
+	ids, err := repo.SplitPlaylist(p.ID, currentUser.ID, mode, limit, func(part int) string {
+		return uniquePlaylistTitle(fmt.Sprintf("%s — часть %d", p.Title, part))
+	})
+	return len(ids), err
+}
+
+func setOperationPlaylists(a, b Playlist, op, title string) error {
+	if a.ID == b.ID {
+		return fmt.Errorf("выберите два разных плейлиста")
+	}
+	if title == "" {
+		return fmt.Errorf("название плейлиста не может быть пустым")
+	}
+	for _, p := range []Playlist{a, b} {
+		if err := requirePlaylistPermission(p.ID, PermView); err != nil {
+			return err
+		}
+	}
+	_, err := repo.SetOperationPlaylists(a.ID, b.ID, op, currentUser.ID, title)
+	return err
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// --- RECOMMENDATIONS ---
+// Оценка трека складывается из того, как часто он стоит в плейлистах (своих и
+// публичных) рядом с треками-образцами, совпадения артиста и жанра с ними,
+// оценок и прослушиваний. Сама оценка считается по загруженным в память данным
+// (recommendData), без обращений к базе.
+
+// Веса составляющих оценки рекомендации
+const (
+	recWeightCooccurrence = 4.0
+	recWeightArtist       = 1.5
+	recWeightGenre        = 1.0
+	recWeightOwnRating    = 1.5
+	recWeightOtherRatings = 0.5
+	recWeightPlays        = 0.5
+)
+
+type Recommendation struct {
+	TrackID int
+	Score   float64
+	Reasons []string
+	Label   string
+	Track   TrackInfo // заполняется в labelRecommendations
+}
+
+type recommendData struct {
+	playlists  [][]int         // состав своих и публичных плейлистов
+	tracks     map[int]Track   // каталог
+	artists    map[int]int     // трек → артист
+	ratings    map[int]int     // оценки текущего пользователя
+	avgRatings map[int]float64 // средние оценки остальных
+	plays      map[int]int     // прослушивания текущего пользователя
+
+	counts map[int]int         // в скольких плейлистах встречается трек
+	pairs  map[int]map[int]int // в скольких плейлистах треки встречаются вместе
+}
+
+func loadRecommendData() (*recommendData, error) {
+	d := &recommendData{tracks: map[int]Track{}}
+	var err error
+	if d.playlists, err = repo.GetPlaylistTrackSets(currentUser.ID); err != nil {
+		return nil, err
+	}
+	all, err := repo.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range all {
+		d.tracks[t.ID] = t
+	}
+	if d.artists, err = repo.GetTrackArtists(); err != nil {
+		return nil, err
+	}
+	if d.ratings, err = repo.GetUserRatings(currentUser.ID); err != nil {
+		return nil, err
+	}
+	if d.avgRatings, err = repo.GetAverageRatings(currentUser.ID); err != nil {
+		return nil, err
+	}
+	if d.plays, err = repo.GetPlayCounts(currentUser.ID); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// index считает совместную встречаемость треков; вызывается лениво из recommend
+func (d *recommendData) index() {
+	if d.pairs != nil {
+		return
+	}
+	d.counts, d.pairs = map[int]int{}, map[int]map[int]int{}
+	for _, set := range d.playlists {
+		for i, a := range set {
+			d.counts[a]++
+			for _, b := range set[i+1:] {
+				if a == b {
+					continue
+				}
+				for _, p := range [][2]int{{a, b}, {b, a}} {
+					if d.pairs[p[0]] == nil {
+						d.pairs[p[0]] = map[int]int{}
+					}
+					d.pairs[p[0]][p[1]]++
+				}
+			}
+		}
+	}
+}
+
+// favoriteTracks — образцы для пустого плейлиста: треки с оценкой 4–5 и самые слушаемые
+func (d *recommendData) favoriteTracks(limit int) []int {
+	type fav struct {
+		id    int
+		score float64
+	}
+	var favs []fav
+	for id := range d.tracks {
+		s := float64(d.plays[id])
+		if d.ratings[id] >= 4 {
+			s += 100 * float64(d.ratings[id])
+		}
+		if s > 0 {
+			favs = append(favs, fav{id, s})
+		}
+	}
+	sort.Slice(favs, func(i, j int) bool {
+		return favs[i].score > favs[j].score || (favs[i].score == favs[j].score && favs[i].id < favs[j].id)
+	})
+	var ids []int
+	for i := 0; i < len(favs) && i < limit; i++ {
+		ids = append(ids, favs[i].id)
+	}
+	return ids
+}
+
+// recommend возвращает до limit треков, похожих на seeds, кроме exclude, по убыванию оценки.
+// Трек попадает в рекомендации, только если он связан с образцами (встречаемость, артист
+// или жанр); оценки и прослушивания лишь меняют порядок. Треки с оценкой 1 не предлагаются.
+func (d *recommendData) recommend(seeds []int, exclude map[int]bool, limit int) []Recommendation {
+	if len(seeds) == 0 {
+		return nil
+	}
+	d.index()
+	n := float64(len(seeds))
+	var recs []Recommendation
+	for id, t := range d.tracks {
+		if exclude[id] || d.ratings[id] == 1 {
+			continue
+		}
+		var co, sameArtist, sameGenre float64
+		for _, s := range seeds {
+			if s == id {
+				continue
+			}
+			if both := d.pairs[s][id]; both > 0 {
+				co += float64(both) / math.Sqrt(float64(d.counts[s]*d.counts[id]))
+			}
+			if a, ok := d.artists[s]; ok && a == d.artists[id] {
+				sameArtist++
+			}
+			if g := d.tracks[s].Genre; g != "" && strings.EqualFold(g, t.Genre) {
+				sameGenre++
+			}
+		}
+		if co == 0 && sameArtist == 0 && sameGenre == 0 {
+			continue
+		}
+		rec := Recommendation{TrackID: id}
+		rec.Score = recWeightCooccurrence*co/n + recWeightArtist*sameArtist/n + recWeightGenre*sameGenre/n
+		if co > 0 {
+			rec.Reasons = append(rec.Reasons, "часто рядом в плейлистах")
+		}
+		if sameArtist > 0 {
+			rec.Reasons = append(rec.Reasons, "тот же артист")
+		}
+		if sameGenre > 0 {
+			rec.Reasons = append(rec.Reasons, "жанр "+t.Genre)
+		}
+		if r, ok := d.ratings[id]; ok {
+			rec.Score += recWeightOwnRating * float64(r-3) / 2
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("ваша оценка %d", r))
+		} else if avg, ok := d.avgRatings[id]; ok {
+			rec.Score += recWeightOtherRatings * (avg - 3) / 2
+		}
+		if p := d.plays[id]; p > 0 {
+			rec.Score += recWeightPlays * math.Min(math.Log1p(float64(p))/math.Log(11), 1)
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("слушали %d раз", p))
+		}
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].Score > recs[j].Score || (recs[i].Score == recs[j].Score && recs[i].TrackID < recs[j].TrackID)
+	})
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs
+}
+
+// Сколько последних треков радио служат образцами для следующего и из скольких лучших он выбирается
+const (
+	radioContext = 3
+	radioChoices = 5
+)
+
+// radio строит цепочку треков: каждый следующий похож на исходные образцы и последние
+// выбранные треки, выбирается случайно среди лучших с весом по оценке, а один артист
+// не звучит дважды подряд, если есть из чего выбрать
+func (d *recommendData) radio(start int, seeds []int, size int, rng *rand.Rand) []int {
+	chosen := []int{start}
+	used := map[int]bool{start: true}
+	for len(chosen) < size {
+		context := append([]int{}, seeds...)
+		context = append(context, chosen[max(0, len(chosen)-radioContext):]...)
+		recs := d.recommend(context, used, 4*radioChoices)
+		last := d.artists[chosen[len(chosen)-1]]
+		var pool []Recommendation
+		for _, r := range recs {
+			if d.artists[r.TrackID] != last {
+				pool = append(pool, r)
+			}
+		}
+		if len(pool) == 0 {
+			pool = recs
+		}
+		if len(pool) == 0 {
+			break
+		}
+		pool = pool[:min(len(pool), radioChoices)]
+		next := pool[0].TrackID
+		var total float64
+		for _, r := range pool {
+			total += math.Max(r.Score, 0.01)
+		}
+		x := rng.Float64() * total
+		for _, r := range pool {
+			if x -= math.Max(r.Score, 0.01); x <= 0 {
+				next = r.TrackID
+				break
+			}
+		}
+		chosen = append(chosen, next)
+		used[next] = true
+	}
+	return chosen
+}
+
+// labelRecommendations подписывает рекомендации названием, артистом и причинами
+func labelRecommendations(recs []Recommendation) error {
+	ids := make([]int, len(recs))
+	for i, r := range recs {
+		ids[i] = r.TrackID
+	}
+	infos, err := repo.GetTrackInfos(ids)
+	if err != nil {
+		return err
+	}
+	byID := map[int]TrackInfo{}
+	for _, t := range infos {
+		byID[t.ID] = t
+	}
+	for i, r := range recs {
+		t := byID[r.TrackID]
+		recs[i].Track = t
+		recs[i].Label = fmt.Sprintf("%s — %s (%s)", t.ArtistName, t.Title, strings.Join(r.Reasons, ", "))
+	}
+	return nil
+}
+
+// suggestForPlaylist — треки, которые подойдут плейлисту; для пустого плейлиста
+// образцами служат любимые треки пользователя
+func suggestForPlaylist(pID, limit int) ([]Recommendation, error) {
+	if err := requirePlaylistPermission(pID, PermView); err != nil {
+		return nil, err
+	}
+	entries, err := repo.GetTracksFromPlaylist(pID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := loadRecommendData()
+	if err != nil {
+		return nil, err
+	}
+	seeds := trackIDs(entries)
+	exclude := map[int]bool{}
+	for _, id := range seeds {
+		exclude[id] = true
+	}
+	if len(seeds) == 0 {
+		seeds = d.favoriteTracks(10)
+	}
+	recs := d.recommend(seeds, exclude, limit)
+	return recs, labelRecommendations(recs)
+}
+
+// Образец для радио
+const (
+	RadioFromTrack  = "track"
+	RadioFromArtist = "artist"
+)
+
+// createRadioPlaylist создаёт плейлист-радио из size треков от трека или артиста seedID;
+// создание можно отменить, как и обычный плейлист
+func createRadioPlaylist(kind string, seedID, size int) (string, error) {
+	if size < 2 {
+		return "", fmt.Errorf("в радио должно быть хотя бы два трека")
+	}
+	d, err := loadRecommendData()
+	if err != nil {
+		return "", err
+	}
+	var start int
+	var seeds []int
+	var name string
+	switch kind {
+	case RadioFromTrack:
+		t, ok := d.tracks[seedID]
+		if !ok {
+			return "", fmt.Errorf("трек не найден")
+		}
+		start, seeds, name = seedID, []int{seedID}, t.Title
+	case RadioFromArtist:
+		// Начинаем с самого любимого трека артиста, образцы — все его треки
+		for id := range d.tracks {
+			if d.artists[id] == seedID {
+				seeds = append(seeds, id)
+			}
+		}
+		if len(seeds) == 0 {
+			return "", fmt.Errorf("у артиста нет треков")
+		}
+		sort.Ints(seeds)
+		start = seeds[0]
+		for _, id := range seeds {
+			if d.ratings[id]*1000+d.plays[id] > d.ratings[start]*1000+d.plays[start] {
+				start = id
+			}
+		}
+		artists, _ := repo.GetArtists()
+		for _, a := range artists {
+			if a.ID == seedID {
+				name = a.Name
+			}
+		}
+	default:
+		return "", fmt.Errorf("неизвестный образец радио %q", kind)
+	}
+	ids := d.radio(start, seeds, size, rand.New(rand.NewSource(nowFunc().UnixNano())))
+	if len(ids) < 2 {
+		return "", fmt.Errorf("не нашлось похожих треков — нужно больше плейлистов, оценок или жанров")
+	}
+	title := uniquePlaylistTitle("Радио: " + name)
+	return title, runCommand(&createPlaylistCmd{Title: title, TrackIDs: ids})
+}
+
+// --- RATINGS AND PLAYS ---
+
+// rateTrack ставит оценку от 1 до 5; 0 снимает оценку
+func rateTrack(trackID, rating int) error {
+	if rating < 0 || rating > 5 {
+		return fmt.Errorf("оценка должна быть от 1 до 5 (0 — снять оценку)")
+	}
+	return repo.SetRating(currentUser.ID, trackID, rating)
+}
+
+func getTrackRating(trackID int) int {
+	ratings, err := repo.GetUserRatings(currentUser.ID)
+	if err != nil {
+		return 0
+	}
+	return ratings[trackID]
+}
+
+func recordPlay(trackID int) error {
+	return repo.RecordPlay(currentUser.ID, trackID)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testRecommendData — небольшой каталог в памяти:
+// треки 1, 2 и 6 одного артиста, 1 и 3 — рок, 4 пользователь оценил на 1
+func testRecommendData() *recommendData {
+	return &recommendData{
+		playlists: [][]int{{1, 2, 3}, {1, 2}, {1, 4}, {5, 7}},
+		tracks: map[int]Track{
+			1: {ID: 1, Genre: "Rock"},
+			2: {ID: 2},
+			3: {ID: 3, Genre: "rock"},
+			4: {ID: 4},
+			5: {ID: 5, Genre: "Jazz"},
+			6: {ID: 6},
+			7: {ID: 7},
+		},
+		artists:    map[int]int{1: 10, 2: 10, 3: 20, 4: 30, 5: 40, 6: 10, 7: 50},
+		ratings:    map[int]int{4: 1, 6: 5},
+		avgRatings: map[int]float64{2: 5},
+		plays:      map[int]int{3: 10},
+	}
+}
+
+func TestRecommendIndex(t *testing.T) {
+	d := testRecommendData()
+	d.index()
+	wantCounts := map[int]int{1: 3, 2: 2, 3: 1, 4: 1, 5: 1, 7: 1}
+	if !reflect.DeepEqual(d.counts, wantCounts) {
+		t.Errorf("counts = %v, want %v", d.counts, wantCounts)
+	}
+	tests := []struct {
+		a, b, want int
+	}{
+		{1, 2, 2},
+		{2, 1, 2},
+		{1, 3, 1},
+		{2, 3, 1},
+		{1, 4, 1},
+		{5, 7, 1},
+		{1, 5, 0},
+		{3, 4, 0},
+	}
+	for _, tt := range tests {
+		if got := d.pairs[tt.a][tt.b]; got != tt.want {
+			t.Errorf("pairs[%d][%d] = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		name    string
+		seeds   []int
+		exclude []int
+		limit   int
+		want    []int
+	}{
+		// 2: встречаемость + артист + средняя оценка; 3: встречаемость + жанр + прослушивания;
+		// 6: артист + своя оценка 5; 4 с оценкой 1 и несвязанные 5, 7 не предлагаются
+		{"один образец", []int{1}, []int{1}, 10, []int{2, 3, 6}},
+		{"ограничение", []int{1}, []int{1}, 2, []int{2, 3}},
+		{"исключение", []int{1}, []int{1, 2}, 10, []int{3, 6}},
+		{"два образца", []int{1, 2}, []int{1, 2}, 10, []int{3, 6}},
+		{"только встречаемость", []int{5}, nil, 10, []int{7}},
+		{"без образцов", nil, nil, 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exclude := map[int]bool{}
+			for _, id := range tt.exclude {
+				exclude[id] = true
+			}
+			var got []int
+			for _, r := range testRecommendData().recommend(tt.seeds, exclude, tt.limit) {
+				got = append(got, r.TrackID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recommend(%v) = %v, want %v", tt.seeds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecommendReasons(t *testing.T) {
+	want := map[int][]string{
+		2: {"часто рядом в плейлистах", "тот же артист"},
+		3: {"часто рядом в плейлистах", "жанр rock", "слушали 10 раз"},
+		6: {"тот же артист", "ваша оценка 5"},
+	}
+	for _, r := range testRecommendData().recommend([]int{1}, map[int]bool{1: true}, 10) {
+		if !reflect.DeepEqual(r.Reasons, want[r.TrackID]) {
+			t.Errorf("причины для %d = %q, want %q", r.TrackID, r.Reasons, want[r.TrackID])
+		}
+	}
+}
+
+func TestFavoriteTracks(t *testing.T) {
+	// 6 оценён на 5, 3 слушали; оценка 1 у трека 4 в любимые не выводит
+	if got, want := testRecommendData().favoriteTracks(10), []int{6, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("favoriteTracks = %v, want %v", got, want)
+	}
+}
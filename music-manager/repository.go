@@ -3,37 +3,218 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Repository struct {
 	db *sql.DB
+
+	// Пользователь, от имени которого вносятся изменения, — для журнала аудита
+	actorID   int
+	actorName string
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// SetActor задаёт автора последующих изменений (0 — изменения без пользователя)
+func (r *Repository) SetActor(userID int, username string) {
+	r.actorID, r.actorName = userID, username
+}
+
+// begin открывает транзакцию и сообщает триггерам аудита, кто вносит изменения.
+// Все изменения данных идут через begin, inTx или exec.
+func (r *Repository) begin() (*sql.Tx, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("SELECT set_config('app.actor_id', $1, true), set_config('app.actor_name', $2, true)",
+		strconv.Itoa(r.actorID), r.actorName)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// inTx выполняет fn в транзакции и фиксирует её, если fn не вернула ошибку
+func (r *Repository) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// exec выполняет одиночное изменение в собственной транзакции
+func (r *Repository) exec(query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := r.inTx(func(tx *sql.Tx) error {
+		var err error
+		res, err = tx.Exec(query, args...)
+		return err
+	})
+	return res, err
+}
+
+// insertID выполняет INSERT ... RETURNING id в собственной транзакции
+func (r *Repository) insertID(query string, args ...interface{}) (int, error) {
+	var id int
+	err := r.inTx(func(tx *sql.Tx) error {
+		return tx.QueryRow(query, args...).Scan(&id)
+	})
+	return id, err
+}
+
 // AUTH & USERS
 
+// RegisterUser создаёт пользователя; самый первый зарегистрированный становится администратором
 func (r *Repository) RegisterUser(u, p string) error {
 	hash, err := bcrypt.GenerateFromPassword([]byte(p), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Exec("INSERT INTO users (username, password_hash) VALUES ($1, $2)", u, string(hash))
-	return err
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// Блокировка исключает двух "первых" пользователей при одновременной регистрации
+	if _, err := tx.Exec("LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO users (username, password_hash, role) VALUES ($1, $2,
+        CASE WHEN EXISTS (SELECT 1 FROM users) THEN $3 ELSE $4 END)`,
+		u, string(hash), RoleListener, RoleAdmin)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *Repository) LoginUser(u, p string) (*User, error) {
 	var id int
-	var hash string
-	err := r.db.QueryRow("SELECT id, password_hash FROM users WHERE username=$1", u).Scan(&id, &hash)
+	var hash, role string
+	err := r.db.QueryRow("SELECT id, password_hash, role FROM users WHERE username=$1", u).Scan(&id, &hash, &role)
 	if err != nil || bcrypt.CompareHashAndPassword([]byte(hash), []byte(p)) != nil {
 		return nil, fmt.Errorf("неверный логин или пароль")
 	}
-	return &User{ID: id, Username: u}, nil
+	return &User{ID: id, Username: u, Role: role}, nil
+}
+
+func (r *Repository) GetUsers() ([]User, error) {
+	rows, err := r.db.Query("SELECT id, username, role FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var u User
+		rows.Scan(&u.ID, &u.Username, &u.Role)
+		items = append(items, u)
+	}
+	return items, nil
+}
+
+func (r *Repository) SetUserRole(userID int, role string) error {
+	_, err := r.exec("UPDATE users SET role=$1 WHERE id=$2", role, userID)
+	return err
+}
+
+// SetUserRoleByName меняет роль по имени пользователя — для команды set-role
+func (r *Repository) SetUserRoleByName(username, role string) error {
+	res, err := r.exec("UPDATE users SET role=$1 WHERE username=$2", role, username)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("пользователь %q не найден", username)
+	}
+	return nil
+}
+
+func (r *Repository) CountUsersWithRole(role string) (int, error) {
+	var n int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE role=$1", role).Scan(&n)
+	return n, err
+}
+
+// CheckPassword сверяет пароль пользователя с сохранённым хешем
+func (r *Repository) CheckPassword(userID int, p string) error {
+	var hash string
+	err := r.db.QueryRow("SELECT password_hash FROM users WHERE id=$1", userID).Scan(&hash)
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(hash), []byte(p)) != nil {
+		return fmt.Errorf("неверный пароль")
+	}
+	return nil
+}
+
+func (r *Repository) ChangePassword(userID int, oldPass, newPass string) error {
+	if err := r.CheckPassword(userID, oldPass); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPass), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	_, err = r.exec("UPDATE users SET password_hash=$1 WHERE id=$2", string(hash), userID)
+	return err
+}
+
+func (r *Repository) ChangeUsername(userID int, username string) error {
+	_, err := r.exec("UPDATE users SET username=$1 WHERE id=$2", username, userID)
+	return err
+}
+
+// DeleteUser удаляет собственный аккаунт после проверки пароля
+func (r *Repository) DeleteUser(userID int, password string) error {
+	if err := r.CheckPassword(userID, password); err != nil {
+		return err
+	}
+	return r.RemoveUser(userID)
+}
+
+// RemoveUser удаляет аккаунт вместе со всеми его плейлистами
+func (r *Repository) RemoveUser(userID int) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM playlist_tracks WHERE playlist_id IN (
+        SELECT id FROM playlists WHERE user_id = $1
+    )`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM playlist_follows WHERE user_id = $1 OR playlist_id IN (
+        SELECT id FROM playlists WHERE user_id = $1
+    )`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM playlist_collaborators WHERE user_id = $1 OR playlist_id IN (
+        SELECT id FROM playlists WHERE user_id = $1
+    )`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM playlists WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM playlist_folders WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM users WHERE id = $1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // ARTISTS
@@ -53,30 +234,18 @@ func (r *Repository) GetArtists() ([]Artist, error) {
 	return items, nil
 }
 
-func (r *Repository) CreateArtist(name string) error {
-	_, err := r.db.Exec("INSERT INTO artists (name) VALUES ($1)", name)
-	return err
+func (r *Repository) CreateArtist(name string) (int, error) {
+	return r.insertID("INSERT INTO artists (name) VALUES ($1) RETURNING id", name)
 }
 
 func (r *Repository) DeleteArtist(id int) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	// Каскадное удаление
-	tx.Exec(`DELETE FROM playlist_tracks WHERE track_id IN (
-        SELECT t.id FROM tracks t JOIN albums a ON t.album_id = a.id WHERE a.artist_id = $1
-    )`, id)
-	tx.Exec("DELETE FROM tracks WHERE album_id IN (SELECT id FROM albums WHERE artist_id = $1)", id)
-	tx.Exec("DELETE FROM albums WHERE artist_id = $1", id)
-	tx.Exec("DELETE FROM artists WHERE id = $1", id)
-	return tx.Commit()
+	return r.DeleteArtists([]int{id})
 }
 
 // --- ALBUMS ---
 
 func (r *Repository) GetAlbums() ([]Album, error) {
-	rows, err := r.db.Query("SELECT id, title, year, artist_id FROM albums WHERE is_deleted=false ORDER BY title")
+	rows, err := r.db.Query("SELECT id, title, COALESCE(year, 0), artist_id, COALESCE(credits, '') FROM albums WHERE is_deleted=false ORDER BY title")
 	if err != nil {
 		return nil, err
 	}
@@ -84,32 +253,27 @@ func (r *Repository) GetAlbums() ([]Album, error) {
 	var items []Album
 	for rows.Next() {
 		var a Album
-		rows.Scan(&a.ID, &a.Title, &a.Year, &a.ArtistID)
+		if err := rows.Scan(&a.ID, &a.Title, &a.Year, &a.ArtistID, &a.Credits); err != nil {
+			return nil, err
+		}
 		items = append(items, a)
 	}
-	return items, nil
+	return items, rows.Err()
 }
 
-func (r *Repository) CreateAlbum(title string, artistID, year int) error {
-	_, err := r.db.Exec("INSERT INTO albums (title, artist_id, year) VALUES ($1, $2, $3)", title, artistID, year)
-	return err
+func (r *Repository) CreateAlbum(title string, artistID, year int) (int, error) {
+	return r.insertID("INSERT INTO albums (title, artist_id, year) VALUES ($1, $2, $3) RETURNING id", title, artistID, year)
 }
 
 func (r *Repository) DeleteAlbum(id int) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	tx.Exec("DELETE FROM playlist_tracks WHERE track_id IN (SELECT id FROM tracks WHERE album_id = $1)", id)
-	tx.Exec("DELETE FROM tracks WHERE album_id = $1", id)
-	tx.Exec("DELETE FROM albums WHERE id = $1", id)
-	return tx.Commit()
+	return r.DeleteAlbums([]int{id})
 }
 
 // --- TRACKS ---
 
 func (r *Repository) GetTracks() ([]Track, error) {
-	rows, err := r.db.Query("SELECT id, title, album_id, duration FROM tracks WHERE is_deleted=false ORDER BY title")
+	rows, err := r.db.Query(`SELECT id, title, album_id, COALESCE(duration, 0), COALESCE(genre, ''), COALESCE(track_number, 0), COALESCE(bpm, 0), COALESCE(musical_key, '')
+    FROM tracks WHERE is_deleted=false ORDER BY title`)
 	if err != nil {
 		return nil, err
 	}
@@ -117,73 +281,141 @@ func (r *Repository) GetTracks() ([]Track, error) {
 	var items []Track
 	for rows.Next() {
 		var t Track
-		rows.Scan(&t.ID, &t.Title, &t.AlbumID, &t.Duration)
+		if err := rows.Scan(&t.ID, &t.Title, &t.AlbumID, &t.Duration, &t.Genre, &t.Number, &t.BPM, &t.Key); err != nil {
+			return nil, err
+		}
 		items = append(items, t)
 	}
-	return items, nil
+	return items, rows.Err()
 }
 
-func (r *Repository) CreateTrack(title string, albumID, duration int) error {
-	_, err := r.db.Exec("INSERT INTO tracks (title, album_id, duration) VALUES ($1, $2, $3)", title, albumID, duration)
-	return err
+func (r *Repository) CreateTrack(title string, albumID, duration int) (int, error) {
+	return r.insertID("INSERT INTO tracks (title, album_id, duration) VALUES ($1, $2, $3) RETURNING id", title, albumID, duration)
 }
 
 func (r *Repository) DeleteTrack(id int) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	tx.Exec("DELETE FROM playlist_tracks WHERE track_id = $1", id)
-	tx.Exec("DELETE FROM tracks WHERE id = $1", id)
-	return tx.Commit()
+	return r.DeleteTracks([]int{id})
 }
 
 // --- PLAYLISTS ---
 func (r *Repository) GetPlaylists(userID int) ([]Playlist, error) {
-	rows, err := r.db.Query("SELECT id, title FROM playlists WHERE user_id=$1 AND is_deleted=false ORDER BY title", userID)
+	rows, err := r.db.Query("SELECT id, title, version, visibility, COALESCE(folder_id, 0) FROM playlists WHERE user_id=$1 AND is_deleted=false ORDER BY title", userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var items []Playlist
 	for rows.Next() {
-		var p Playlist
-		rows.Scan(&p.ID, &p.Title)
+		p := Playlist{Permission: PermOwner}
+		rows.Scan(&p.ID, &p.Title, &p.Version, &p.Visibility, &p.FolderID)
 		items = append(items, p)
 	}
 	return items, nil
 }
 
-func (r *Repository) CreatePlaylist(title string, userID int) error {
-	_, err := r.db.Exec("INSERT INTO playlists (title, user_id) VALUES ($1, $2)", title, userID)
-	return err
+func (r *Repository) CreatePlaylist(title string, userID int) (int, error) {
+	return r.insertID("INSERT INTO playlists (title, user_id) VALUES ($1, $2) RETURNING id", title, userID)
+}
+
+// DeletePlaylists удаляет плейлисты одной транзакцией: либо все, либо ни одного
+func (r *Repository) DeletePlaylists(ids []int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		for _, id := range ids {
+			if err := deletePlaylistTx(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *Repository) DeletePlaylist(id int) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
+	return r.inTx(func(tx *sql.Tx) error { return deletePlaylistTx(tx, id) })
+}
+
+func deletePlaylistTx(tx *sql.Tx, id int) error {
+	for _, q := range []string{
+		"UPDATE playlists SET folder_id=NULL WHERE id=$1",
+		"DELETE FROM playlist_tracks WHERE playlist_id=$1",
+		"DELETE FROM playlist_collaborators WHERE playlist_id=$1",
+		"DELETE FROM playlist_follows WHERE playlist_id=$1",
+		"DELETE FROM playlists WHERE id=$1",
+	} {
+		if _, err := tx.Exec(q, id); err != nil {
+			return err
+		}
 	}
-	tx.Exec("DELETE FROM playlist_tracks WHERE playlist_id=$1", id)
-	tx.Exec("DELETE FROM playlists WHERE id=$1", id)
-	return tx.Commit()
+	return nil
 }
 
-func (r *Repository) AddTrackToPlaylist(pID, tID int) error {
-	_, err := r.db.Exec("INSERT INTO playlist_tracks (playlist_id, track_id) VALUES ($1, $2)", pID, tID)
-	return err
+// lockPlaylist блокирует строку плейлиста до конца транзакции, чтобы правки участников шли по очереди
+func lockPlaylist(tx *sql.Tx, pID int) error {
+	var id int
+	return tx.QueryRow("SELECT id FROM playlists WHERE id=$1 FOR UPDATE", pID).Scan(&id)
 }
 
-func (r *Repository) RemoveTrackFromPlaylist(pID, tID int) error {
-	_, err := r.db.Exec("DELETE FROM playlist_tracks WHERE playlist_id=$1 AND track_id=$2", pID, tID)
-	return err
+// bumpPlaylistVersion отмечает изменение состава плейлиста и возвращает новую версию
+func bumpPlaylistVersion(tx *sql.Tx, pID int) (int, error) {
+	var version int
+	err := tx.QueryRow("UPDATE playlists SET version = version + 1, updated_at = now() WHERE id=$1 RETURNING version", pID).Scan(&version)
+	return version, err
+}
+
+// AddTrackToPlaylist добавляет трек от имени userID и возвращает новую версию плейлиста
+func (r *Repository) AddTrackToPlaylist(pID, tID, userID int) (int, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if err := lockPlaylist(tx, pID); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(`INSERT INTO playlist_tracks (playlist_id, track_id, added_by, position)
+        VALUES ($1, $2, $3, `+nextPositionSQL+` + 1)
+        ON CONFLICT (playlist_id, track_id) DO NOTHING`, pID, tID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, fmt.Errorf("трек уже есть в плейлисте")
+	}
+	version, err := bumpPlaylistVersion(tx, pID)
+	if err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+// RemoveTrackFromPlaylist удаляет трек и возвращает новую версию плейлиста
+func (r *Repository) RemoveTrackFromPlaylist(pID, tID int) (int, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if err := lockPlaylist(tx, pID); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM playlist_tracks WHERE playlist_id=$1 AND track_id=$2", pID, tID)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, fmt.Errorf("трек уже удалён из плейлиста")
+	}
+	version, err := bumpPlaylistVersion(tx, pID)
+	if err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
 }
 
 func (r *Repository) GetTracksFromPlaylist(pID int) ([]Track, error) {
 	rows, err := r.db.Query(`
-    SELECT t.id, t.title, t.duration 
-    FROM tracks t 
-    JOIN playlist_tracks pt ON pt.track_id = t.id 
+    SELECT t.id, t.title, COALESCE(t.duration, 0)
+    FROM tracks t
+    JOIN playlist_tracks pt ON pt.track_id = t.id
     WHERE pt.playlist_id = $1`, pID)
 	if err != nil {
 		return nil, err
@@ -192,8 +424,78 @@ func (r *Repository) GetTracksFromPlaylist(pID int) ([]Track, error) {
 	var items []Track
 	for rows.Next() {
 		var t Track
-		rows.Scan(&t.ID, &t.Title, &t.Duration)
+		if err := rows.Scan(&t.ID, &t.Title, &t.Duration); err != nil {
+			return nil, err
+		}
 		items = append(items, t)
 	}
-	return items, nil
+	return items, rows.Err()
+}
+
+func (r *Repository) GetPlaylistEntries(pID int) ([]PlaylistEntry, error) {
+	rows, err := r.db.Query(`
+    SELECT t.id, t.title, t.album_id, COALESCE(t.duration, 0), COALESCE(u.username, ''), pt.added_at
+    FROM tracks t
+    JOIN playlist_tracks pt ON pt.track_id = t.id
+    LEFT JOIN users u ON u.id = pt.added_by
+    WHERE pt.playlist_id = $1
+    ORDER BY pt.position`, pID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlaylistEntry
+	for rows.Next() {
+		var e PlaylistEntry
+		if err := rows.Scan(&e.ID, &e.Title, &e.AlbumID, &e.Duration, &e.AddedBy, &e.AddedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
+
+// GetPlaylistSummary считает сводку плейлиста одним запросом
+func (r *Repository) GetPlaylistSummary(pID int) (*PlaylistSummary, error) {
+	s := &PlaylistSummary{PlaylistID: pID}
+	err := r.db.QueryRow(`
+    SELECT p.title, p.created_at, p.updated_at,
+        COUNT(t.id), COALESCE(SUM(t.duration), 0), COUNT(DISTINCT al.artist_id),
+        COALESCE(MIN(NULLIF(al.year, 0)), 0), COALESCE(MAX(NULLIF(al.year, 0)), 0)
+    FROM playlists p
+    LEFT JOIN playlist_tracks pt ON pt.playlist_id = p.id
+    LEFT JOIN tracks t ON t.id = pt.track_id
+    LEFT JOIN albums al ON al.id = t.album_id
+    WHERE p.id = $1 AND p.is_deleted = false
+    GROUP BY p.id`, pID).Scan(&s.Title, &s.CreatedAt, &s.UpdatedAt,
+		&s.Tracks, &s.Duration, &s.Artists, &s.FirstYear, &s.LastYear)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("плейлист не найден")
+	}
+	return s, err
+}
+
+// GetPlaylistTrackInfos возвращает треки плейлиста по порядку вместе с альбомом и артистом
+func (r *Repository) GetPlaylistTrackInfos(pID int) ([]TrackInfo, error) {
+	rows, err := r.db.Query(`
+    SELECT t.id, t.title, t.album_id, COALESCE(t.duration, 0), COALESCE(t.track_number, 0), al.title, ar.id, ar.name, COALESCE(al.year, 0)
+    FROM playlist_tracks pt
+    JOIN tracks t ON t.id = pt.track_id
+    JOIN albums al ON al.id = t.album_id
+    JOIN artists ar ON ar.id = al.artist_id
+    WHERE pt.playlist_id = $1
+    ORDER BY pt.position`, pID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrackInfo
+	for rows.Next() {
+		var t TrackInfo
+		if err := rows.Scan(&t.ID, &t.Title, &t.AlbumID, &t.Duration, &t.Number, &t.AlbumTitle, &t.ArtistID, &t.ArtistName, &t.Year); err != nil {
+			return nil, err
+		}
+		items = append(items, t)
+	}
+	return items, rows.Err()
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AUDIT LOG
+// Записи добавляют триггеры базы (см. audit_row в shema.sql), здесь — только чтение и очистка.
+
+// AuditEntityTypes — таблицы, изменения которых пишутся в журнал
+var AuditEntityTypes = []string{
+	"users", "artists", "albums", "tracks", "track_tags",
+	"playlists", "playlist_tracks", "playlist_collaborators",
+	"playlist_follows", "user_follows", "playlist_folders",
+	"track_ratings", "track_plays", "track_fingerprints", "user_settings",
+}
+
+var AuditOperations = []string{"insert", "update", "delete"}
+
+func (r *Repository) GetAuditLog(f AuditFilter) ([]AuditEntry, error) {
+	if f.Limit <= 0 {
+		f.Limit = 500
+	}
+	var from, to interface{}
+	if !f.From.IsZero() {
+		from = f.From
+	}
+	if !f.To.IsZero() {
+		to = f.To
+	}
+	rows, err := r.db.Query(`
+    SELECT id, COALESCE(user_id, 0), COALESCE(username, ''), created_at, operation, entity_type,
+           COALESCE(entity_id, 0), COALESCE(before::text, ''), COALESCE(after::text, '')
+    FROM audit_log
+    WHERE ($1 = '' OR username ILIKE '%' || $1 || '%')
+      AND ($2 = '' OR operation = $2)
+      AND ($3 = '' OR entity_type = $3)
+      AND ($4 = 0 OR entity_id = $4)
+      AND ($5::timestamptz IS NULL OR created_at >= $5)
+      AND ($6::timestamptz IS NULL OR created_at < $6)
+    ORDER BY id DESC
+    LIMIT $7`, f.Username, f.Operation, f.EntityType, f.EntityID, from, to, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Username, &e.CreatedAt, &e.Operation, &e.EntityType,
+			&e.EntityID, &e.Before, &e.After); err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
+
+// PurgeAuditLog удаляет записи старше before и возвращает их число
+func (r *Repository) PurgeAuditLog(before time.Time) (int, error) {
+	var n int64
+	err := r.inTx(func(tx *sql.Tx) error {
+		res, err := tx.Exec("DELETE FROM audit_log WHERE created_at < $1", before)
+		if err != nil {
+			return err
+		}
+		n, _ = res.RowsAffected()
+		return nil
+	})
+	return int(n), err
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BACKUP & RESTORE
+// Архив хранит данные с исходными ID только как ссылки внутри архива:
+// при восстановлении записи получают новые ID, а ссылки пересчитываются.
+
+// Версии архива:
+//
+//	1 — каталог, пользователи, папки, плейлисты и подписки
+//	2 — громкость, темп и тональность треков, участники и громкость альбомов, оценки и прослушивания
+//
+// Архив старой версии восстанавливается как есть: чего в нём нет, остаётся пустым.
+const (
+	backupFormat  = "music-manager-backup"
+	backupVersion = 2
+)
+
+// Режимы восстановления
+const (
+	RestoreMerge   = "merge"   // добавить недостающее, совпадающие записи не трогать
+	RestoreReplace = "replace" // заменить каталог и плейлисты содержимым архива
+)
+
+type Backup struct {
+	Format          string                 `json:"format"`
+	Version         int                    `json:"version"`
+	CreatedAt       time.Time              `json:"created_at"`
+	Users           []BackupUser           `json:"users"`
+	Artists         []BackupArtist         `json:"artists"`
+	Albums          []BackupAlbum          `json:"albums"`
+	Tracks          []BackupTrack          `json:"tracks"`
+	Folders         []BackupFolder         `json:"folders"`
+	Playlists       []BackupPlaylist       `json:"playlists"`
+	Entries         []BackupEntry          `json:"entries"`
+	Collaborators   []BackupCollaborator   `json:"collaborators"`
+	PlaylistFollows []BackupPlaylistFollow `json:"playlist_follows"`
+	UserFollows     []BackupUserFollow     `json:"user_follows"`
+	Ratings         []BackupRating         `json:"ratings,omitempty"` // с версии 2
+	Plays           []BackupPlay           `json:"plays,omitempty"`   // с версии 2
+}
+
+type BackupUser struct {
+	ID           int    `json:"id"`
+	Username     string `json:"username"`
+	Role         string `json:"role"`
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+type BackupArtist struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type BackupAlbum struct {
+	ID       int             `json:"id"`
+	ArtistID int             `json:"artist_id"`
+	Title    string          `json:"title"`
+	Year     int             `json:"year,omitempty"`
+	Credits  string          `json:"credits,omitempty"`  // с версии 2
+	Loudness *BackupLoudness `json:"loudness,omitempty"` // с версии 2
+}
+
+type BackupTrack struct {
+	ID       int             `json:"id"`
+	AlbumID  int             `json:"album_id"`
+	Title    string          `json:"title"`
+	Duration int             `json:"duration,omitempty"`
+	Genre    string          `json:"genre,omitempty"`
+	Number   int             `json:"number,omitempty"`
+	Tags     []string        `json:"tags,omitempty"`
+	Loudness *BackupLoudness `json:"loudness,omitempty"` // с версии 2
+	BPM      float64         `json:"bpm,omitempty"`      // с версии 2
+	Key      string          `json:"key,omitempty"`      // с версии 2
+}
+
+type BackupLoudness struct {
+	LUFS     float64 `json:"lufs"`
+	TruePeak float64 `json:"true_peak"`
+	Gain     float64 `json:"replay_gain"`
+}
+
+type BackupFolder struct {
+	ID       int    `json:"id"`
+	UserID   int    `json:"user_id"`
+	ParentID int    `json:"parent_id,omitempty"`
+	Name     string `json:"name"`
+}
+
+type BackupPlaylist struct {
+	ID         int    `json:"id"`
+	UserID     int    `json:"user_id"`
+	FolderID   int    `json:"folder_id,omitempty"`
+	Title      string `json:"title"`
+	Visibility string `json:"visibility"`
+}
+
+type BackupEntry struct {
+	PlaylistID int       `json:"playlist_id"`
+	TrackID    int       `json:"track_id"`
+	AddedBy    int       `json:"added_by,omitempty"`
+	AddedAt    time.Time `json:"added_at"`
+}
+
+type BackupCollaborator struct {
+	PlaylistID int    `json:"playlist_id"`
+	UserID     int    `json:"user_id"`
+	Permission string `json:"permission"`
+}
+
+type BackupPlaylistFollow struct {
+	UserID     int       `json:"user_id"`
+	PlaylistID int       `json:"playlist_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type BackupUserFollow struct {
+	FollowerID int       `json:"follower_id"`
+	FolloweeID int       `json:"followee_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type BackupRating struct {
+	UserID  int       `json:"user_id"`
+	TrackID int       `json:"track_id"`
+	Rating  int       `json:"rating"`
+	RatedAt time.Time `json:"rated_at"`
+}
+
+type BackupPlay struct {
+	UserID   int       `json:"user_id"`
+	TrackID  int       `json:"track_id"`
+	PlayedAt time.Time `json:"played_at"`
+}
+
+// RestoreReport — что было создано при восстановлении
+type RestoreReport struct {
+	Users, Artists, Albums, Tracks, Folders, Playlists, Entries int
+	Ratings, Plays                                              int
+	ArchiveVersion                                              int
+	TempPasswords                                               map[string]string // логин -> выданный временный пароль
+}
+
+// scanLoudness собирает громкость из трёх столбцов; nil — не измерена
+func scanLoudness(lufs, peak, gain sql.NullFloat64) *BackupLoudness {
+	if !lufs.Valid {
+		return nil
+	}
+	return &BackupLoudness{LUFS: lufs.Float64, TruePeak: peak.Float64, Gain: gain.Float64}
+}
+
+// loudnessArgs — значения для столбцов громкости; nil в базе остаётся NULL
+func loudnessArgs(l *BackupLoudness) []interface{} {
+	if l == nil {
+		return []interface{}{nil, nil, nil}
+	}
+	return []interface{}{l.LUFS, l.TruePeak, l.Gain}
+}
+
+// ExportBackup читает все данные в одном снимке базы, чтобы архив был согласованным
+func (r *Repository) ExportBackup(withHashes bool) (*Backup, error) {
+	tx, err := r.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	b := &Backup{Format: backupFormat, Version: backupVersion, CreatedAt: nowFunc()}
+	each := func(query string, scan func(rows *sql.Rows) error) error {
+		rows, err := tx.Query(query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+
+	steps := []struct {
+		query string
+		scan  func(rows *sql.Rows) error
+	}{
+		{"SELECT id, username, role, password_hash FROM users ORDER BY id", func(rows *sql.Rows) error {
+			var u BackupUser
+			if err := rows.Scan(&u.ID, &u.Username, &u.Role, &u.PasswordHash); err != nil {
+				return err
+			}
+			if !withHashes {
+				u.PasswordHash = ""
+			}
+			b.Users = append(b.Users, u)
+			return nil
+		}},
+		{"SELECT id, name FROM artists ORDER BY id", func(rows *sql.Rows) error {
+			var a BackupArtist
+			err := rows.Scan(&a.ID, &a.Name)
+			b.Artists = append(b.Artists, a)
+			return err
+		}},
+		{`SELECT id, artist_id, title, COALESCE(year, 0), COALESCE(credits, ''), loudness_lufs, true_peak_db, replay_gain_db
+          FROM albums ORDER BY id`, func(rows *sql.Rows) error {
+			var a BackupAlbum
+			var lufs, peak, gain sql.NullFloat64
+			err := rows.Scan(&a.ID, &a.ArtistID, &a.Title, &a.Year, &a.Credits, &lufs, &peak, &gain)
+			a.Loudness = scanLoudness(lufs, peak, gain)
+			b.Albums = append(b.Albums, a)
+			return err
+		}},
+		{`SELECT t.id, t.album_id, t.title, COALESCE(t.duration, 0), COALESCE(t.genre, ''), COALESCE(t.track_number, 0),
+            COALESCE(array_agg(tt.tag ORDER BY tt.tag) FILTER (WHERE tt.tag IS NOT NULL), '{}'),
+            t.loudness_lufs, t.true_peak_db, t.replay_gain_db, COALESCE(t.bpm, 0), COALESCE(t.musical_key, '')
+          FROM tracks t LEFT JOIN track_tags tt ON tt.track_id = t.id
+          GROUP BY t.id ORDER BY t.id`, func(rows *sql.Rows) error {
+			var t BackupTrack
+			var lufs, peak, gain sql.NullFloat64
+			err := rows.Scan(&t.ID, &t.AlbumID, &t.Title, &t.Duration, &t.Genre, &t.Number, pq.Array(&t.Tags),
+				&lufs, &peak, &gain, &t.BPM, &t.Key)
+			t.Loudness = scanLoudness(lufs, peak, gain)
+			b.Tracks = append(b.Tracks, t)
+			return err
+		}},
+		// Родительские папки идут раньше вложенных
+		{`WITH RECURSIVE tree AS (
+            SELECT id, user_id, parent_id, name, 0 AS depth FROM playlist_folders WHERE parent_id IS NULL
+            UNION ALL
+            SELECT f.id, f.user_id, f.parent_id, f.name, tree.depth + 1
+            FROM playlist_folders f JOIN tree ON f.parent_id = tree.id
+          )
+          SELECT id, user_id, COALESCE(parent_id, 0), name FROM tree ORDER BY depth, id`, func(rows *sql.Rows) error {
+			var f BackupFolder
+			err := rows.Scan(&f.ID, &f.UserID, &f.ParentID, &f.Name)
+			b.Folders = append(b.Folders, f)
+			return err
+		}},
+		{"SELECT id, user_id, COALESCE(folder_id, 0), title, visibility FROM playlists ORDER BY id", func(rows *sql.Rows) error {
+			var p BackupPlaylist
+			err := rows.Scan(&p.ID, &p.UserID, &p.FolderID, &p.Title, &p.Visibility)
+			b.Playlists = append(b.Playlists, p)
+			return err
+		}},
+		{"SELECT playlist_id, track_id, COALESCE(added_by, 0), added_at FROM playlist_tracks ORDER BY playlist_id, position", func(rows *sql.Rows) error {
+			var e BackupEntry
+			err := rows.Scan(&e.PlaylistID, &e.TrackID, &e.AddedBy, &e.AddedAt)
+			b.Entries = append(b.Entries, e)
+			return err
+		}},
+		{"SELECT playlist_id, user_id, permission FROM playlist_collaborators ORDER BY playlist_id, user_id", func(rows *sql.Rows) error {
+			var c BackupCollaborator
+			err := rows.Scan(&c.PlaylistID, &c.UserID, &c.Permission)
+			b.Collaborators = append(b.Collaborators, c)
+			return err
+		}},
+		{"SELECT user_id, playlist_id, followed_at FROM playlist_follows ORDER BY playlist_id, user_id", func(rows *sql.Rows) error {
+			var f BackupPlaylistFollow
+			err := rows.Scan(&f.UserID, &f.PlaylistID, &f.FollowedAt)
+			b.PlaylistFollows = append(b.PlaylistFollows, f)
+			return err
+		}},
+		{"SELECT follower_id, followee_id, followed_at FROM user_follows ORDER BY follower_id, followee_id", func(rows *sql.Rows) error {
+			var f BackupUserFollow
+			err := rows.Scan(&f.FollowerID, &f.FolloweeID, &f.FollowedAt)
+			b.UserFollows = append(b.UserFollows, f)
+			return err
+		}},
+		{"SELECT user_id, track_id, rating, rated_at FROM track_ratings ORDER BY user_id, track_id", func(rows *sql.Rows) error {
+			var rt BackupRating
+			err := rows.Scan(&rt.UserID, &rt.TrackID, &rt.Rating, &rt.RatedAt)
+			b.Ratings = append(b.Ratings, rt)
+			return err
+		}},
+		{"SELECT user_id, track_id, played_at FROM track_plays ORDER BY id", func(rows *sql.Rows) error {
+			var p BackupPlay
+			err := rows.Scan(&p.UserID, &p.TrackID, &p.PlayedAt)
+			b.Plays = append(b.Plays, p)
+			return err
+		}},
+	}
+	for _, s := range steps {
+		if err := each(s.query, s.scan); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// ImportBackup восстанавливает архив одной транзакцией: при ошибке база не меняется.
+// Учётные записи не удаляются ни в каком режиме, чтобы восстановление не лишило входа
+// администратора: пользователи сопоставляются по логину, недостающие создаются.
+// Для созданных пользователей без хеша в архиве ставится пароль из tempPasswords.
+func (r *Repository) ImportBackup(b *Backup, mode string, tempPasswords map[string]string) (*RestoreReport, error) {
+	rep := &RestoreReport{ArchiveVersion: b.Version, TempPasswords: map[string]string{}}
+	err := r.inTx(func(tx *sql.Tx) error {
+		if mode == RestoreReplace {
+			for _, table := range []string{"playlist_follows", "playlist_collaborators", "playlist_tracks",
+				"playlists", "playlist_folders", "track_tags", "tracks", "albums", "artists"} {
+				if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.Exec("DELETE FROM user_follows"); err != nil {
+				return err
+			}
+		}
+
+		// findOrInsert возвращает ID существующей записи (find) или вставляет новую (insert)
+		findOrInsert := func(counter *int, find, insert string, findArgs, insertArgs []interface{}) (int, error) {
+			var id int
+			err := tx.QueryRow(find, findArgs...).Scan(&id)
+			if err == nil {
+				return id, nil
+			}
+			if err != sql.ErrNoRows {
+				return 0, err
+			}
+			if err := tx.QueryRow(insert, insertArgs...).Scan(&id); err != nil {
+				return 0, err
+			}
+			*counter++
+			return id, nil
+		}
+
+		users := map[int]int{}
+		for _, u := range b.Users {
+			var id int
+			err := tx.QueryRow("SELECT id FROM users WHERE username=$1", u.Username).Scan(&id)
+			if err == sql.ErrNoRows {
+				hash := u.PasswordHash
+				if hash == "" {
+					h, err := bcrypt.GenerateFromPassword([]byte(tempPasswords[u.Username]), bcrypt.DefaultCost)
+					if err != nil {
+						return err
+					}
+					hash = string(h)
+					rep.TempPasswords[u.Username] = tempPasswords[u.Username]
+				}
+				err = tx.QueryRow("INSERT INTO users (username, password_hash, role) VALUES ($1, $2, $3) RETURNING id",
+					u.Username, hash, u.Role).Scan(&id)
+				rep.Users++
+			}
+			if err != nil {
+				return fmt.Errorf("пользователь %q: %w", u.Username, err)
+			}
+			users[u.ID] = id
+		}
+
+		artists := map[int]int{}
+		for _, a := range b.Artists {
+			id, err := findOrInsert(&rep.Artists,
+				"SELECT id FROM artists WHERE name=$1 AND is_deleted = false LIMIT 1",
+				"INSERT INTO artists (name) VALUES ($1) RETURNING id",
+				[]interface{}{a.Name}, []interface{}{a.Name})
+			if err != nil {
+				return fmt.Errorf("артист %q: %w", a.Name, err)
+			}
+			artists[a.ID] = id
+		}
+
+		albums := map[int]int{}
+		for _, a := range b.Albums {
+			artistID, ok := artists[a.ArtistID]
+			if !ok {
+				return fmt.Errorf("альбом %q ссылается на неизвестного артиста %d", a.Title, a.ArtistID)
+			}
+			id, err := findOrInsert(&rep.Albums,
+				"SELECT id FROM albums WHERE artist_id=$1 AND title=$2 AND is_deleted = false LIMIT 1",
+				"INSERT INTO albums (artist_id, title, year) VALUES ($1, $2, NULLIF($3, 0)) RETURNING id",
+				[]interface{}{artistID, a.Title}, []interface{}{artistID, a.Title, a.Year})
+			if err != nil {
+				return fmt.Errorf("альбом %q: %w", a.Title, err)
+			}
+			albums[a.ID] = id
+			// Участники и громкость дополняют уже существующий альбом, но не перезаписывают его данные
+			if _, err := tx.Exec(`UPDATE albums SET credits = COALESCE(credits, NULLIF($2, '')),
+                loudness_lufs = COALESCE(loudness_lufs, $3), true_peak_db = COALESCE(true_peak_db, $4),
+                replay_gain_db = COALESCE(replay_gain_db, $5)
+            WHERE id = $1`, append([]interface{}{id, a.Credits}, loudnessArgs(a.Loudness)...)...); err != nil {
+				return fmt.Errorf("альбом %q: %w", a.Title, err)
+			}
+		}
+
+		tracks := map[int]int{}
+		for _, t := range b.Tracks {
+			albumID, ok := albums[t.AlbumID]
+			if !ok {
+				return fmt.Errorf("трек %q ссылается на неизвестный альбом %d", t.Title, t.AlbumID)
+			}
+			id, err := findOrInsert(&rep.Tracks,
+				"SELECT id FROM tracks WHERE album_id=$1 AND title=$2 AND is_deleted = false LIMIT 1",
+				`INSERT INTO tracks (album_id, title, duration, genre, track_number)
+                VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), NULLIF($5, 0)) RETURNING id`,
+				[]interface{}{albumID, t.Title}, []interface{}{albumID, t.Title, t.Duration, t.Genre, t.Number})
+			if err != nil {
+				return fmt.Errorf("трек %q: %w", t.Title, err)
+			}
+			tracks[t.ID] = id
+			if _, err := tx.Exec(`UPDATE tracks SET loudness_lufs = COALESCE(loudness_lufs, $2),
+                true_peak_db = COALESCE(true_peak_db, $3), replay_gain_db = COALESCE(replay_gain_db, $4),
+                bpm = COALESCE(bpm, NULLIF($5::real, 0)), musical_key = COALESCE(musical_key, NULLIF($6, ''))
+            WHERE id = $1`, append(append([]interface{}{id}, loudnessArgs(t.Loudness)...), t.BPM, t.Key)...); err != nil {
+				return fmt.Errorf("трек %q: %w", t.Title, err)
+			}
+			if len(t.Tags) > 0 {
+				if _, err := tx.Exec(`INSERT INTO track_tags (track_id, tag) SELECT $1, unnest($2::text[])
+                ON CONFLICT DO NOTHING`, id, pq.Array(t.Tags)); err != nil {
+					return err
+				}
+			}
+		}
+
+		folders := map[int]int{}
+		for _, f := range b.Folders {
+			userID, ok := users[f.UserID]
+			if !ok {
+				return fmt.Errorf("папка %q принадлежит неизвестному пользователю %d", f.Name, f.UserID)
+			}
+			parentID := 0
+			if f.ParentID != 0 {
+				if parentID, ok = folders[f.ParentID]; !ok {
+					return fmt.Errorf("папка %q вложена в неизвестную папку %d", f.Name, f.ParentID)
+				}
+			}
+			id, err := findOrInsert(&rep.Folders,
+				"SELECT id FROM playlist_folders WHERE user_id=$1 AND name=$2 AND parent_id IS NOT DISTINCT FROM $3 LIMIT 1",
+				"INSERT INTO playlist_folders (user_id, name, parent_id) VALUES ($1, $2, $3) RETURNING id",
+				[]interface{}{userID, f.Name, nullableID(parentID)}, []interface{}{userID, f.Name, nullableID(parentID)})
+			if err != nil {
+				return fmt.Errorf("папка %q: %w", f.Name, err)
+			}
+			folders[f.ID] = id
+		}
+
+		playlists := map[int]int{}
+		for _, p := range b.Playlists {
+			userID, ok := users[p.UserID]
+			if !ok {
+				return fmt.Errorf("плейлист %q принадлежит неизвестному пользователю %d", p.Title, p.UserID)
+			}
+			visibility := p.Visibility
+			if visibility == "" {
+				visibility = VisibilityPrivate
+			}
+			// В существующий плейлист с тем же названием треки будут добавлены
+			id, err := findOrInsert(&rep.Playlists,
+				"SELECT id FROM playlists WHERE user_id=$1 AND title=$2 AND is_deleted = false LIMIT 1",
+				"INSERT INTO playlists (user_id, title, visibility, folder_id) VALUES ($1, $2, $3, $4) RETURNING id",
+				[]interface{}{userID, p.Title}, []interface{}{userID, p.Title, visibility, nullableID(folders[p.FolderID])})
+			if err != nil {
+				return fmt.Errorf("плейлист %q: %w", p.Title, err)
+			}
+			playlists[p.ID] = id
+		}
+
+		for _, e := range b.Entries {
+			pID, ok1 := playlists[e.PlaylistID]
+			tID, ok2 := tracks[e.TrackID]
+			if !ok1 || !ok2 {
+				return fmt.Errorf("запись плейлиста %d ссылается на неизвестный трек или плейлист", e.PlaylistID)
+			}
+			// Записи в архиве идут в порядке плейлиста, поэтому каждая встаёт в конец
+			res, err := tx.Exec(`INSERT INTO playlist_tracks (playlist_id, track_id, added_by, added_at, position)
+            VALUES ($1, $2, $3, $4, `+nextPositionSQL+` + 1) ON CONFLICT (playlist_id, track_id) DO NOTHING`,
+				pID, tID, nullableID(users[e.AddedBy]), e.AddedAt)
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			rep.Entries += int(n)
+		}
+
+		for _, c := range b.Collaborators {
+			pID, uID := playlists[c.PlaylistID], users[c.UserID]
+			if pID == 0 || uID == 0 {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT INTO playlist_collaborators (playlist_id, user_id, permission)
+            VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, pID, uID, c.Permission); err != nil {
+				return err
+			}
+		}
+		for _, f := range b.PlaylistFollows {
+			uID, pID := users[f.UserID], playlists[f.PlaylistID]
+			if pID == 0 || uID == 0 {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT INTO playlist_follows (user_id, playlist_id, followed_at)
+            VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, uID, pID, f.FollowedAt); err != nil {
+				return err
+			}
+		}
+		for _, f := range b.UserFollows {
+			a, c := users[f.FollowerID], users[f.FolloweeID]
+			if a == 0 || c == 0 || a == c {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT INTO user_follows (follower_id, followee_id, followed_at)
+            VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, a, c, f.FollowedAt); err != nil {
+				return err
+			}
+		}
+
+		// Оценки и прослушивания есть только в архивах версии 2 и новее
+		for _, rt := range b.Ratings {
+			uID, tID := users[rt.UserID], tracks[rt.TrackID]
+			if uID == 0 || tID == 0 {
+				continue
+			}
+			res, err := tx.Exec(`INSERT INTO track_ratings (user_id, track_id, rating, rated_at)
+            VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`, uID, tID, rt.Rating, rt.RatedAt)
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			rep.Ratings += int(n)
+		}
+		// У прослушиваний нет естественного ключа: повтором считается то же время у того же трека
+		for _, p := range b.Plays {
+			uID, tID := users[p.UserID], tracks[p.TrackID]
+			if uID == 0 || tID == 0 {
+				continue
+			}
+			res, err := tx.Exec(`INSERT INTO track_plays (user_id, track_id, played_at)
+            SELECT $1, $2, $3 WHERE NOT EXISTS (
+                SELECT 1 FROM track_plays WHERE user_id = $1 AND track_id = $2 AND played_at = $3)`, uID, tID, p.PlayedAt)
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			rep.Plays += int(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// BULK OPERATIONS
+// Массовые операции выполняются одной транзакцией: при ошибке не меняется ничего.
+
+// Виды записей каталога
+const (
+	KindArtist = "artist"
+	KindAlbum  = "album"
+	KindTrack  = "track"
+)
+
+// affectedTracksSQL — подзапрос ID треков, которые исчезнут при удалении записей вида kind из $1
+var affectedTracksSQL = map[string]string{
+	KindArtist: "SELECT t.id FROM tracks t JOIN albums a ON t.album_id = a.id WHERE a.artist_id = ANY($1)",
+	KindAlbum:  "SELECT id FROM tracks WHERE album_id = ANY($1)",
+	KindTrack:  "SELECT id FROM tracks WHERE id = ANY($1)",
+}
+
+// CatalogDeleteImpact — пробный прогон удаления: ничего не меняет и считает,
+// сколько зависимых записей и чьих плейлистов затронет удаление
+func (r *Repository) CatalogDeleteImpact(kind string, ids []int, userID int) (DeleteImpact, error) {
+	var impact DeleteImpact
+	tracksSQL, ok := affectedTracksSQL[kind]
+	if !ok {
+		return impact, fmt.Errorf("неизвестный вид записей %q", kind)
+	}
+	albumsSQL := "SELECT 0"
+	if kind == KindArtist {
+		albumsSQL = "SELECT COUNT(*) FROM albums WHERE artist_id = ANY($1)"
+	}
+	err := r.db.QueryRow(`
+    SELECT (`+albumsSQL+`), (SELECT COUNT(*) FROM (`+tracksSQL+`) t)`,
+		pq.Array(ids)).Scan(&impact.Albums, &impact.Tracks)
+	if err != nil {
+		return impact, err
+	}
+
+	rows, err := r.db.Query(`
+    SELECT p.title, u.username, u.id, COUNT(*)
+    FROM playlist_tracks pt
+    JOIN playlists p ON p.id = pt.playlist_id
+    JOIN users u ON u.id = p.user_id
+    WHERE pt.track_id IN (`+tracksSQL+`)
+    GROUP BY p.id, p.title, u.username, u.id
+    ORDER BY u.username, p.title`, pq.Array(ids))
+	if err != nil {
+		return impact, err
+	}
+	defer rows.Close()
+	owners := map[int]bool{}
+	for rows.Next() {
+		var a AffectedPlaylist
+		rows.Scan(&a.Title, &a.Owner, &a.OwnerID, &a.Entries)
+		impact.AffectedPlaylists = append(impact.AffectedPlaylists, a)
+		impact.PlaylistEntries += a.Entries
+		impact.Playlists++
+		owners[a.OwnerID] = true
+		if a.OwnerID != userID {
+			impact.OtherUsersEntries += a.Entries
+		}
+	}
+	impact.Users = len(owners)
+	return impact, nil
+}
+
+// deleteTracksTx удаляет треки вместе с их вхождениями в плейлисты и тегами
+func deleteTracksTx(tx *sql.Tx, kind string, ids []int) error {
+	tracksSQL := affectedTracksSQL[kind]
+	if _, err := tx.Exec("DELETE FROM playlist_tracks WHERE track_id IN ("+tracksSQL+")", pq.Array(ids)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM track_tags WHERE track_id IN ("+tracksSQL+")", pq.Array(ids)); err != nil {
+		return err
+	}
+	_, err := tx.Exec("DELETE FROM tracks WHERE id IN ("+tracksSQL+")", pq.Array(ids))
+	return err
+}
+
+func (r *Repository) DeleteArtists(ids []int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		// Каскадное удаление
+		if err := deleteTracksTx(tx, KindArtist, ids); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM albums WHERE artist_id = ANY($1)", pq.Array(ids)); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM artists WHERE id = ANY($1)", pq.Array(ids))
+		return err
+	})
+}
+
+func (r *Repository) DeleteAlbums(ids []int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		if err := deleteTracksTx(tx, KindAlbum, ids); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM albums WHERE id = ANY($1)", pq.Array(ids))
+		return err
+	})
+}
+
+func (r *Repository) DeleteTracks(ids []int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		return deleteTracksTx(tx, KindTrack, ids)
+	})
+}
+
+// AddTracksToPlaylist добавляет треки, которых ещё нет в плейлисте; возвращает ID добавленных и новую версию
+func (r *Repository) AddTracksToPlaylist(pID int, trackIDs []int, userID int) ([]int, int, error) {
+	var added []int
+	var version int
+	err := r.inTx(func(tx *sql.Tx) error {
+		if err := lockPlaylist(tx, pID); err != nil {
+			return err
+		}
+		rows, err := tx.Query(`
+        INSERT INTO playlist_tracks (playlist_id, track_id, added_by, position)
+        SELECT $1, track_id, $3, `+nextPositionSQL+` + ord
+        FROM unnest($2::int[]) WITH ORDINALITY AS u(track_id, ord)
+        ON CONFLICT (playlist_id, track_id) DO NOTHING
+        RETURNING track_id`, pID, pq.Array(trackIDs), userID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id int
+			rows.Scan(&id)
+			added = append(added, id)
+		}
+		rows.Close()
+		version, err = bumpPlaylistVersion(tx, pID)
+		return err
+	})
+	return added, version, err
+}
+
+// RemoveTracksFromPlaylist удаляет треки из плейлиста и возвращает новую версию
+func (r *Repository) RemoveTracksFromPlaylist(pID int, trackIDs []int) (int, error) {
+	var version int
+	err := r.inTx(func(tx *sql.Tx) error {
+		if err := lockPlaylist(tx, pID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM playlist_tracks WHERE playlist_id=$1 AND track_id = ANY($2)", pID, pq.Array(trackIDs)); err != nil {
+			return err
+		}
+		var err error
+		version, err = bumpPlaylistVersion(tx, pID)
+		return err
+	})
+	return version, err
+}
+
+// SetTracksGenreAndTags задаёт жанр (nil — не менять) и добавляет теги выбранным трекам
+func (r *Repository) SetTracksGenreAndTags(trackIDs []int, genre *string, tags []string) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		if genre != nil {
+			if _, err := tx.Exec("UPDATE tracks SET genre = NULLIF($1, '') WHERE id = ANY($2)", *genre, pq.Array(trackIDs)); err != nil {
+				return err
+			}
+		}
+		if len(tags) > 0 {
+			_, err := tx.Exec(`INSERT INTO track_tags (track_id, tag)
+            SELECT t, g FROM unnest($1::int[]) t CROSS JOIN unnest($2::text[]) g
+            ON CONFLICT DO NOTHING`, pq.Array(trackIDs), pq.Array(tags))
+			return err
+		}
+		return nil
+	})
+}
+
+// MoveTracksToAlbum переносит треки в другой альбом
+func (r *Repository) MoveTracksToAlbum(trackIDs []int, albumID int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE tracks SET album_id=$1 WHERE id = ANY($2)", albumID, pq.Array(trackIDs))
+		return err
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// CSV IMPORT & EXPORT
+
+// CatalogRow — строка импорта: артист обязателен, альбом и трек — по наличию
+type CatalogRow struct {
+	Line     int // номер строки в файле, для отчёта об ошибках
+	Artist   string
+	Album    string
+	Year     int
+	Track    string
+	Duration int
+	Genre    string
+}
+
+type ImportRowError struct {
+	Line    int
+	Message string
+}
+
+type ImportResult struct {
+	Artists, Albums, Tracks int // создано
+	Skipped                 int // строки, все записи которых уже были в каталоге
+	Errors                  []ImportRowError
+}
+
+// ImportCatalogRows создаёт недостающих артистов, альбомы и треки.
+// atomic — всё или ничего: первая же ошибка откатывает импорт целиком.
+// Иначе каждая строка выполняется в своей точке сохранения, и ошибочные пропускаются.
+func (r *Repository) ImportCatalogRows(rows []CatalogRow, atomic bool) (*ImportResult, error) {
+	res := &ImportResult{}
+	err := r.inTx(func(tx *sql.Tx) error {
+		for _, row := range rows {
+			if !atomic {
+				if _, err := tx.Exec("SAVEPOINT import_row"); err != nil {
+					return err
+				}
+			}
+			created, err := importCatalogRow(tx, row)
+			if err != nil {
+				if atomic {
+					return fmt.Errorf("строка %d: %w", row.Line, err)
+				}
+				if _, err := tx.Exec("ROLLBACK TO SAVEPOINT import_row"); err != nil {
+					return err
+				}
+				res.Errors = append(res.Errors, ImportRowError{Line: row.Line, Message: err.Error()})
+				continue
+			}
+			if !atomic {
+				if _, err := tx.Exec("RELEASE SAVEPOINT import_row"); err != nil {
+					return err
+				}
+			}
+			res.Artists += created[0]
+			res.Albums += created[1]
+			res.Tracks += created[2]
+			if created == [3]int{} {
+				res.Skipped++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// importCatalogRow возвращает, сколько создано артистов, альбомов и треков (0 или 1 каждого)
+func importCatalogRow(tx *sql.Tx, row CatalogRow) ([3]int, error) {
+	var created [3]int
+	findOrCreate := func(n int, find, insert string, findArgs, insertArgs []interface{}) (int, error) {
+		var id int
+		err := tx.QueryRow(find, findArgs...).Scan(&id)
+		if err == sql.ErrNoRows {
+			err = tx.QueryRow(insert, insertArgs...).Scan(&id)
+			created[n] = 1
+		}
+		return id, err
+	}
+
+	artistID, err := findOrCreate(0,
+		"SELECT id FROM artists WHERE lower(name) = lower($1) AND is_deleted = false LIMIT 1",
+		"INSERT INTO artists (name) VALUES ($1) RETURNING id",
+		[]interface{}{row.Artist}, []interface{}{row.Artist})
+	if err != nil || row.Album == "" {
+		return created, err
+	}
+	albumID, err := findOrCreate(1,
+		"SELECT id FROM albums WHERE artist_id = $1 AND lower(title) = lower($2) AND is_deleted = false LIMIT 1",
+		"INSERT INTO albums (title, artist_id, year) VALUES ($1, $2, NULLIF($3, 0)) RETURNING id",
+		[]interface{}{artistID, row.Album}, []interface{}{row.Album, artistID, row.Year})
+	if err != nil || row.Track == "" {
+		return created, err
+	}
+	_, err = findOrCreate(2,
+		"SELECT id FROM tracks WHERE album_id = $1 AND lower(title) = lower($2) AND is_deleted = false LIMIT 1",
+		"INSERT INTO tracks (title, album_id, duration, genre) VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, '')) RETURNING id",
+		[]interface{}{albumID, row.Track}, []interface{}{row.Track, albumID, row.Duration, row.Genre})
+	return created, err
+}
+
+// GetTrackInfos возвращает треки с альбомом и артистом в порядке ids
+func (r *Repository) GetTrackInfos(ids []int) ([]TrackInfo, error) {
+	rows, err := r.db.Query(`
+    SELECT t.id, t.title, t.album_id, COALESCE(t.duration, 0), COALESCE(t.genre, ''), COALESCE(t.track_number, 0), COALESCE(t.bpm, 0), COALESCE(t.musical_key, ''), al.title, ar.id, ar.name, COALESCE(al.year, 0)
+    FROM unnest($1::int[]) WITH ORDINALITY AS u(id, ord)
+    JOIN tracks t ON t.id = u.id
+    JOIN albums al ON al.id = t.album_id
+    JOIN artists ar ON ar.id = al.artist_id
+    ORDER BY u.ord`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrackInfo
+	for rows.Next() {
+		var t TrackInfo
+		if err := rows.Scan(&t.ID, &t.Title, &t.AlbumID, &t.Duration, &t.Genre, &t.Number, &t.BPM, &t.Key, &t.AlbumTitle, &t.ArtistID, &t.ArtistName, &t.Year); err != nil {
+			return nil, err
+		}
+		items = append(items, t)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// DUPLICATES
+
+// catalogUsageSQL — сколько зависимых записей у каждой записи вида kind из $1:
+// альбомов у артиста, треков у альбома, вхождений в плейлисты у трека
+var catalogUsageSQL = map[string]string{
+	KindArtist: "SELECT artist_id, COUNT(*) FROM albums WHERE artist_id = ANY($1) GROUP BY artist_id",
+	KindAlbum:  "SELECT album_id, COUNT(*) FROM tracks WHERE album_id = ANY($1) GROUP BY album_id",
+	KindTrack:  "SELECT track_id, COUNT(*) FROM playlist_tracks WHERE track_id = ANY($1) GROUP BY track_id",
+}
+
+func (r *Repository) CatalogUsage(kind string, ids []int) (map[int]int, error) {
+	q, ok := catalogUsageSQL[kind]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный вид записей %q", kind)
+	}
+	rows, err := r.db.Query(q, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	usage := map[int]int{}
+	for rows.Next() {
+		var id, n int
+		rows.Scan(&id, &n)
+		usage[id] = n
+	}
+	return usage, nil
+}
+
+// MergeCatalog сливает записи ids в keepID одной транзакцией. Зависимые записи
+// переносятся на оставшуюся; совпадающие по названию альбомы и треки сливаются
+// рекурсивно, вхождения в плейлисты не дублируются. Возвращает число затронутых плейлистов.
+func (r *Repository) MergeCatalog(kind string, keepID int, ids []int) (int, error) {
+	m := &merger{playlists: map[int]bool{}}
+	err := r.inTx(func(tx *sql.Tx) error {
+		m.tx = tx
+		var err error
+		switch kind {
+		case KindArtist:
+			err = m.artists(keepID, ids)
+		case KindAlbum:
+			err = m.albums(keepID, ids)
+		case KindTrack:
+			err = m.tracks(keepID, ids)
+		default:
+			err = fmt.Errorf("неизвестный вид записей %q", kind)
+		}
+		if err != nil {
+			return err
+		}
+		return m.bumpPlaylists()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(m.playlists), nil
+}
+
+type merger struct {
+	tx        *sql.Tx
+	playlists map[int]bool // плейлисты, состав которых изменился
+}
+
+// children возвращает пары (id, название) дочерних записей родителей из ids
+func (m *merger) children(q string, ids []int) ([]int, []string, error) {
+	rows, err := m.tx.Query(q, pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var childIDs []int
+	var titles []string
+	for rows.Next() {
+		var id int
+		var title string
+		rows.Scan(&id, &title)
+		childIDs = append(childIDs, id)
+		titles = append(titles, title)
+	}
+	return childIDs, titles, rows.Err()
+}
+
+// findSibling ищет у родителя запись с тем же названием без учёта регистра
+func (m *merger) findSibling(q string, parentID int, title string) (int, error) {
+	var id int
+	err := m.tx.QueryRow(q, parentID, title).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+func (m *merger) artists(keepID int, ids []int) error {
+	albums, titles, err := m.children("SELECT id, title FROM albums WHERE artist_id = ANY($1) ORDER BY id", ids)
+	if err != nil {
+		return err
+	}
+	for i, albumID := range albums {
+		same, err := m.findSibling(
+			"SELECT id FROM albums WHERE artist_id = $1 AND lower(title) = lower($2) AND is_deleted = false LIMIT 1",
+			keepID, titles[i])
+		if err != nil {
+			return err
+		}
+		if same != 0 {
+			err = m.albums(same, []int{albumID})
+		} else {
+			_, err = m.tx.Exec("UPDATE albums SET artist_id = $1 WHERE id = $2", keepID, albumID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = m.tx.Exec("DELETE FROM artists WHERE id = ANY($1)", pq.Array(ids))
+	return err
+}
+
+func (m *merger) albums(keepID int, ids []int) error {
+	tracks, titles, err := m.children("SELECT id, title FROM tracks WHERE album_id = ANY($1) ORDER BY id", ids)
+	if err != nil {
+		return err
+	}
+	for i, trackID := range tracks {
+		same, err := m.findSibling(
+			"SELECT id FROM tracks WHERE album_id = $1 AND lower(title) = lower($2) AND is_deleted = false LIMIT 1",
+			keepID, titles[i])
+		if err != nil {
+			return err
+		}
+		if same != 0 {
+			err = m.tracks(same, []int{trackID})
+		} else {
+			_, err = m.tx.Exec("UPDATE tracks SET album_id = $1 WHERE id = $2", keepID, trackID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Год берём у дубликата, если у оставшегося альбома он не указан
+	if _, err := m.tx.Exec(`
+    UPDATE albums SET year = (SELECT year FROM albums WHERE id = ANY($2) AND COALESCE(year, 0) <> 0 LIMIT 1)
+    WHERE id = $1 AND COALESCE(year, 0) = 0`, keepID, pq.Array(ids)); err != nil {
+		return err
+	}
+	_, err = m.tx.Exec("DELETE FROM albums WHERE id = ANY($1)", pq.Array(ids))
+	return err
+}
+
+func (m *merger) tracks(keepID int, ids []int) error {
+	rows, err := m.tx.Query("SELECT DISTINCT playlist_id FROM playlist_tracks WHERE track_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	var touched []int
+	for rows.Next() {
+		var pID int
+		rows.Scan(&pID)
+		touched = append(touched, pID)
+	}
+	rows.Close()
+	// Блокируем плейлисты в одном порядке, чтобы не встретиться в deadlock с правками участников
+	sort.Ints(touched)
+	for _, pID := range touched {
+		if err := lockPlaylist(m.tx, pID); err != nil {
+			return err
+		}
+		m.playlists[pID] = true
+	}
+
+	// В каждом плейлисте остаётся одно вхождение: оставшегося трека, а если его там не было —
+	// самое раннее из вхождений дубликатов, которое переводится на оставшийся трек
+	all := append([]int{keepID}, ids...)
+	if _, err := m.tx.Exec(`
+    DELETE FROM playlist_tracks pt
+    WHERE pt.track_id = ANY($2) AND EXISTS (
+        SELECT 1 FROM playlist_tracks o
+        WHERE o.playlist_id = pt.playlist_id AND o.track_id = ANY($3)
+          AND (o.track_id = $1 OR (o.added_at, o.track_id) < (pt.added_at, pt.track_id)))`,
+		keepID, pq.Array(ids), pq.Array(all)); err != nil {
+		return err
+	}
+	if _, err := m.tx.Exec("UPDATE playlist_tracks SET track_id = $1 WHERE track_id = ANY($2)", keepID, pq.Array(ids)); err != nil {
+		return err
+	}
+	if _, err := m.tx.Exec(`
+    INSERT INTO track_tags (track_id, tag)
+    SELECT $1, tag FROM track_tags WHERE track_id = ANY($2)
+    ON CONFLICT DO NOTHING`, keepID, pq.Array(ids)); err != nil {
+		return err
+	}
+	// Отпечаток звука переносим, если у оставшегося трека его ещё нет
+	if _, err := m.tx.Exec(`
+    INSERT INTO track_fingerprints (track_id, fingerprint, file_path, computed_at)
+    SELECT $1, fingerprint, file_path, computed_at FROM track_fingerprints WHERE track_id = ANY($2)
+    ORDER BY computed_at DESC LIMIT 1
+    ON CONFLICT DO NOTHING`, keepID, pq.Array(ids)); err != nil {
+		return err
+	}
+	// Оценка пользователя у оставшегося трека сохраняется; если её нет — берём самую свежую из оценок дубликатов
+	if _, err := m.tx.Exec(`
+    INSERT INTO track_ratings (user_id, track_id, rating, rated_at)
+    SELECT DISTINCT ON (user_id) user_id, $1, rating, rated_at FROM track_ratings WHERE track_id = ANY($2)
+    ORDER BY user_id, rated_at DESC
+    ON CONFLICT (user_id, track_id) DO NOTHING`, keepID, pq.Array(ids)); err != nil {
+		return err
+	}
+	// Прослушивания дубликатов засчитываются оставшемуся треку
+	if _, err := m.tx.Exec("UPDATE track_plays SET track_id = $1 WHERE track_id = ANY($2)", keepID, pq.Array(ids)); err != nil {
+		return err
+	}
+	// Пустые длительность и жанр дополняем данными дубликатов
+	if _, err := m.tx.Exec(`
+    UPDATE tracks SET
+        duration = COALESCE(NULLIF(duration, 0), (SELECT MAX(duration) FROM tracks WHERE id = ANY($2))),
+        genre = COALESCE(NULLIF(genre, ''), (SELECT genre FROM tracks WHERE id = ANY($2) AND COALESCE(genre, '') <> '' LIMIT 1))
+    WHERE id = $1`, keepID, pq.Array(ids)); err != nil {
+		return err
+	}
+	return deleteTracksTx(m.tx, KindTrack, ids)
+}
+
+func (m *merger) bumpPlaylists() error {
+	for pID := range m.playlists {
+		if _, err := bumpPlaylistVersion(m.tx, pID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
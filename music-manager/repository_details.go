@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// CATALOG PAGES
+
+// TrackPlays — трек и сколько раз его слушали все пользователи
+type TrackPlays struct {
+	Track
+	AlbumTitle string
+	Plays      int
+}
+
+// ArtistPlaylist — плейлист, в котором есть треки артиста
+type ArtistPlaylist struct {
+	ID        int
+	Title     string
+	OwnerName string
+	Tracks    int // треков артиста в плейлисте
+}
+
+func (r *Repository) GetArtist(id int) (*Artist, error) {
+	var a Artist
+	err := r.db.QueryRow("SELECT id, name FROM artists WHERE id = $1 AND is_deleted = false", id).Scan(&a.ID, &a.Name)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("артист не найден")
+	}
+	return &a, err
+}
+
+// GetAlbum — альбом с артистом, числом треков и общей длительностью
+func (r *Repository) GetAlbum(id int) (*AlbumRow, error) {
+	a, err := scanAlbumRow(r.db.QueryRow(albumRowSelect+`WHERE al.id = $1 AND al.is_deleted = false
+    GROUP BY al.id, ar.name`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("альбом не найден")
+	}
+	return &a, err
+}
+
+// GetArtistAlbums — дискография артиста по годам; альбомы без года — в конце
+func (r *Repository) GetArtistAlbums(artistID int) ([]AlbumRow, error) {
+	rows, err := r.db.Query(albumRowSelect+`WHERE al.artist_id = $1 AND al.is_deleted = false
+    GROUP BY al.id, ar.name
+    ORDER BY al.year NULLS LAST, lower(al.title)`, artistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AlbumRow
+	for rows.Next() {
+		a, err := scanAlbumRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+// GetArtistTopTracks — самые прослушиваемые треки артиста (по всем пользователям)
+func (r *Repository) GetArtistTopTracks(artistID, limit int) ([]TrackPlays, error) {
+	rows, err := r.db.Query(`SELECT t.id, t.title, t.album_id, COALESCE(t.duration, 0), al.title, COUNT(p.id)
+    FROM tracks t
+    JOIN albums al ON al.id = t.album_id
+    JOIN track_plays p ON p.track_id = t.id
+    WHERE al.artist_id = $1 AND t.is_deleted = false AND al.is_deleted = false
+    GROUP BY t.id, al.title
+    ORDER BY COUNT(p.id) DESC, lower(t.title)
+    LIMIT $2`, artistID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrackPlays
+	for rows.Next() {
+		var t TrackPlays
+		rows.Scan(&t.ID, &t.Title, &t.AlbumID, &t.Duration, &t.AlbumTitle, &t.Plays)
+		items = append(items, t)
+	}
+	return items, rows.Err()
+}
+
+// GetArtistPlaylists — плейлисты с треками артиста, которые видит пользователь userID:
+// свои, открытые ему участниками и публичные. Ссылочные не показываются — их открывают только по коду.
+func (r *Repository) GetArtistPlaylists(artistID, userID int) ([]ArtistPlaylist, error) {
+	rows, err := r.db.Query(`SELECT p.id, p.title, u.username, COUNT(*)
+    FROM playlists p
+    JOIN users u ON u.id = p.user_id
+    JOIN playlist_tracks pt ON pt.playlist_id = p.id
+    JOIN tracks t ON t.id = pt.track_id AND t.is_deleted = false
+    JOIN albums al ON al.id = t.album_id
+    LEFT JOIN playlist_collaborators c ON c.playlist_id = p.id AND c.user_id = $2
+    WHERE al.artist_id = $1 AND p.is_deleted = false
+      AND (p.user_id = $2 OR c.user_id IS NOT NULL OR p.visibility = 'public')
+    GROUP BY p.id, u.username
+    ORDER BY COUNT(*) DESC, lower(p.title)`, artistID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ArtistPlaylist
+	for rows.Next() {
+		var p ArtistPlaylist
+		rows.Scan(&p.ID, &p.Title, &p.OwnerName, &p.Tracks)
+		items = append(items, p)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/lib/pq"
+)
+
+// FINGERPRINTS
+// Отпечатки — производные данные: их можно снять заново, поэтому они не попадают
+// в резервные копии, а в журнал изменений пишется только факт снятия, без самого отпечатка.
+
+func (r *Repository) SaveFingerprint(trackID int, filePath string, fp []uint32) error {
+	_, err := r.exec(`INSERT INTO track_fingerprints (track_id, fingerprint, file_path, computed_at)
+    VALUES ($1, $2, $3, $4)
+    ON CONFLICT (track_id) DO UPDATE SET fingerprint = EXCLUDED.fingerprint,
+        file_path = EXCLUDED.file_path, computed_at = EXCLUDED.computed_at`,
+		trackID, encodeFingerprint(fp), filePath, nowFunc())
+	return err
+}
+
+// GetFingerprints возвращает отпечатки треков из ids; nil — всех треков
+func (r *Repository) GetFingerprints(ids []int) (map[int][]uint32, error) {
+	rows, err := r.db.Query(`SELECT f.track_id, f.fingerprint FROM track_fingerprints f
+    JOIN tracks t ON t.id = f.track_id
+    WHERE t.is_deleted = false AND ($1::int[] IS NULL OR f.track_id = ANY($1))`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	fps := map[int][]uint32{}
+	for rows.Next() {
+		var id int
+		var b []byte
+		rows.Scan(&id, &b)
+		fps[id] = decodeFingerprint(b)
+	}
+	return fps, rows.Err()
+}
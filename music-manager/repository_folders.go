@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// PLAYLIST FOLDERS
+
+func (r *Repository) GetFolders(userID int) ([]PlaylistFolder, error) {
+	rows, err := r.db.Query("SELECT id, name, COALESCE(parent_id, 0) FROM playlist_folders WHERE user_id=$1 ORDER BY name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlaylistFolder
+	for rows.Next() {
+		var f PlaylistFolder
+		rows.Scan(&f.ID, &f.Name, &f.ParentID)
+		items = append(items, f)
+	}
+	return items, nil
+}
+
+// nullableID превращает 0 ("корень") в NULL для внешних ключей
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// checkOwnFolder проверяет, что папка folderID принадлежит пользователю; 0 — корень, он есть у всех
+func checkOwnFolder(tx *sql.Tx, userID, folderID int) error {
+	if folderID == 0 {
+		return nil
+	}
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM playlist_folders WHERE id=$1 AND user_id=$2)", folderID, userID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("папка не найдена")
+	}
+	return nil
+}
+
+func (r *Repository) CreateFolder(userID int, name string, parentID int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		if err := checkOwnFolder(tx, userID, parentID); err != nil {
+			return err
+		}
+		_, err := tx.Exec("INSERT INTO playlist_folders (user_id, name, parent_id) VALUES ($1, $2, $3)", userID, name, nullableID(parentID))
+		return err
+	})
+}
+
+func (r *Repository) RenameFolder(userID, folderID int, name string) error {
+	_, err := r.exec("UPDATE playlist_folders SET name=$1 WHERE id=$2 AND user_id=$3", name, folderID, userID)
+	return err
+}
+
+// MoveFolder переносит папку в parentID. Папки пользователя блокируются на время проверки,
+// чтобы два встречных переноса не замкнули дерево в цикл.
+func (r *Repository) MoveFolder(userID, folderID, parentID int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("SELECT 1 FROM playlist_folders WHERE user_id=$1 FOR UPDATE", userID); err != nil {
+			return err
+		}
+		if err := checkOwnFolder(tx, userID, parentID); err != nil {
+			return err
+		}
+		// поднимаемся от нового родителя к корню: перенос в себя или в потомка замкнёт цикл
+		var cycle bool
+		err := tx.QueryRow(`WITH RECURSIVE up(id, parent_id) AS (
+            SELECT id, parent_id FROM playlist_folders WHERE id = $1
+            UNION
+            SELECT f.id, f.parent_id FROM playlist_folders f JOIN up ON f.id = up.parent_id
+        )
+        SELECT EXISTS (SELECT 1 FROM up WHERE id = $2)`, parentID, folderID).Scan(&cycle)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return fmt.Errorf("нельзя переместить папку внутрь самой себя")
+		}
+		_, err = tx.Exec("UPDATE playlist_folders SET parent_id=$1 WHERE id=$2 AND user_id=$3", nullableID(parentID), folderID, userID)
+		return err
+	})
+}
+
+// DeleteFolder удаляет папку; её плейлисты и вложенные папки поднимаются на уровень выше
+func (r *Repository) DeleteFolder(userID, folderID int) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var parentID interface{}
+	if err := tx.QueryRow("SELECT parent_id FROM playlist_folders WHERE id=$1 AND user_id=$2", folderID, userID).Scan(&parentID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE playlists SET folder_id=$1 WHERE folder_id=$2", parentID, folderID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE playlist_folders SET parent_id=$1 WHERE parent_id=$2", parentID, folderID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM playlist_folders WHERE id=$1", folderID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) MovePlaylistToFolder(userID, pID, folderID int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		if err := checkOwnFolder(tx, userID, folderID); err != nil {
+			return err
+		}
+		_, err := tx.Exec("UPDATE playlists SET folder_id=$1 WHERE id=$2 AND user_id=$3", nullableID(folderID), pID, userID)
+		return err
+	})
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UNDO HISTORY
+// Снимок хранит строки, которые удаляет операция, со всеми зависимыми записями,
+// чтобы отмена вернула их с прежними ID, порядком в плейлистах и авторством.
+
+type TagRow struct {
+	TrackID int
+	Tag     string
+}
+
+type EntryRow struct {
+	PlaylistID int
+	TrackID    int
+	AddedBy    int // 0 — автор неизвестен
+	AddedAt    time.Time
+	Position   int // 0 — в истории до появления позиций: трек встаёт в конец
+}
+
+type PlaylistRow struct {
+	ID         int
+	Title      string
+	UserID     int
+	Visibility string
+	FolderID   int
+}
+
+type CollaboratorRow struct {
+	PlaylistID int
+	UserID     int
+	Permission string
+}
+
+type FollowRow struct {
+	UserID     int
+	PlaylistID int
+	FollowedAt time.Time
+}
+
+// FingerprintRow — акустический отпечаток трека
+type FingerprintRow struct {
+	TrackID     int
+	Fingerprint []byte
+	FilePath    string
+	ComputedAt  time.Time
+}
+
+// LoudnessRow — измеренная громкость альбома или трека (Kind — KindAlbum или KindTrack)
+type LoudnessRow struct {
+	Kind string
+	ID   int
+	Loudness
+}
+
+type RatingRow struct {
+	UserID  int
+	TrackID int
+	Rating  int
+	RatedAt time.Time
+}
+
+type PlayRow struct {
+	ID       int
+	UserID   int
+	TrackID  int
+	PlayedAt time.Time
+}
+
+type Snapshot struct {
+	Artists       []Artist          `json:",omitempty"`
+	Albums        []Album           `json:",omitempty"`
+	Tracks        []Track           `json:",omitempty"`
+	Loudness      []LoudnessRow     `json:",omitempty"`
+	Tags          []TagRow          `json:",omitempty"`
+	Fingerprints  []FingerprintRow  `json:",omitempty"`
+	Ratings       []RatingRow       `json:",omitempty"`
+	Plays         []PlayRow         `json:",omitempty"`
+	Playlists     []PlaylistRow     `json:",omitempty"`
+	Entries       []EntryRow        `json:",omitempty"`
+	Collaborators []CollaboratorRow `json:",omitempty"`
+	Follows       []FollowRow       `json:",omitempty"`
+}
+
+// HistoryRecord — сохранённая команда стека отмены или повтора
+type HistoryRecord struct {
+	Op      string
+	Payload []byte
+}
+
+func scanEntries(rows *sql.Rows) ([]EntryRow, error) {
+	defer rows.Close()
+	var items []EntryRow
+	for rows.Next() {
+		var e EntryRow
+		var by sql.NullInt64
+		if err := rows.Scan(&e.PlaylistID, &e.TrackID, &by, &e.AddedAt, &e.Position); err != nil {
+			return nil, err
+		}
+		e.AddedBy = int(by.Int64)
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
+
+// eachRow выполняет запрос и передаёт scan каждую строку; ошибка чтения строки прерывает снимок,
+// чтобы отмена не восстановила запись наполовину
+func (r *Repository) eachRow(query string, scan func(rows *sql.Rows) error, args ...any) error {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// snapshotLoudness добавляет в снимок измеренную громкость записей kind из выборки idsSQL
+func (r *Repository) snapshotLoudness(s *Snapshot, kind, idsSQL string, args ...any) error {
+	return r.eachRow(`SELECT id, loudness_lufs, COALESCE(true_peak_db, 0), COALESCE(replay_gain_db, 0)
+    FROM `+loudnessTables[kind]+` WHERE loudness_lufs IS NOT NULL AND id IN (`+idsSQL+`)`, func(rows *sql.Rows) error {
+		l := LoudnessRow{Kind: kind}
+		err := rows.Scan(&l.ID, &l.LUFS, &l.TruePeak, &l.Gain)
+		s.Loudness = append(s.Loudness, l)
+		return err
+	}, args...)
+}
+
+// SnapshotCatalog сохраняет записи вида kind со всем, что удалится вместе с ними каскадом:
+// альбомами с участниками, треками, их громкостью, темпом и тональностью, тегами, отпечатками,
+// оценками, прослушиваниями и вхождениями в плейлисты. Таблица, которая ссылается на каталог
+// с ON DELETE CASCADE, должна попадать и сюда, и в RestoreSnapshot.
+func (r *Repository) SnapshotCatalog(kind string, ids []int) (*Snapshot, error) {
+	tracksSQL, ok := affectedTracksSQL[kind]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный вид записей %q", kind)
+	}
+	s := &Snapshot{}
+	arr := pq.Array(ids)
+
+	if kind == KindArtist {
+		if err := r.eachRow("SELECT id, name FROM artists WHERE id = ANY($1) ORDER BY id", func(rows *sql.Rows) error {
+			var a Artist
+			err := rows.Scan(&a.ID, &a.Name)
+			s.Artists = append(s.Artists, a)
+			return err
+		}, arr); err != nil {
+			return nil, err
+		}
+	}
+	if kind != KindTrack {
+		albumsWhere := "id = ANY($1)"
+		if kind == KindArtist {
+			albumsWhere = "artist_id = ANY($1)"
+		}
+		if err := r.eachRow("SELECT id, title, artist_id, COALESCE(year, 0), COALESCE(credits, '') FROM albums WHERE "+albumsWhere+" ORDER BY id", func(rows *sql.Rows) error {
+			var a Album
+			err := rows.Scan(&a.ID, &a.Title, &a.ArtistID, &a.Year, &a.Credits)
+			s.Albums = append(s.Albums, a)
+			return err
+		}, arr); err != nil {
+			return nil, err
+		}
+		if err := r.snapshotLoudness(s, KindAlbum, "SELECT id FROM albums WHERE "+albumsWhere, arr); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.eachRow(`SELECT id, title, album_id, COALESCE(duration, 0), COALESCE(genre, ''), COALESCE(track_number, 0),
+        COALESCE(bpm, 0), COALESCE(musical_key, '')
+    FROM tracks WHERE id IN (`+tracksSQL+`) ORDER BY id`, func(rows *sql.Rows) error {
+		var t Track
+		err := rows.Scan(&t.ID, &t.Title, &t.AlbumID, &t.Duration, &t.Genre, &t.Number, &t.BPM, &t.Key)
+		s.Tracks = append(s.Tracks, t)
+		return err
+	}, arr); err != nil {
+		return nil, err
+	}
+	if err := r.snapshotLoudness(s, KindTrack, tracksSQL, arr); err != nil {
+		return nil, err
+	}
+
+	if err := r.eachRow("SELECT track_id, tag FROM track_tags WHERE track_id IN ("+tracksSQL+")", func(rows *sql.Rows) error {
+		var t TagRow
+		err := rows.Scan(&t.TrackID, &t.Tag)
+		s.Tags = append(s.Tags, t)
+		return err
+	}, arr); err != nil {
+		return nil, err
+	}
+
+	if err := r.eachRow(`SELECT track_id, fingerprint, file_path, computed_at
+    FROM track_fingerprints WHERE track_id IN (`+tracksSQL+`)`, func(rows *sql.Rows) error {
+		var f FingerprintRow
+		err := rows.Scan(&f.TrackID, &f.Fingerprint, &f.FilePath, &f.ComputedAt)
+		s.Fingerprints = append(s.Fingerprints, f)
+		return err
+	}, arr); err != nil {
+		return nil, err
+	}
+
+	if err := r.eachRow("SELECT user_id, track_id, rating, rated_at FROM track_ratings WHERE track_id IN ("+tracksSQL+")", func(rows *sql.Rows) error {
+		var rt RatingRow
+		err := rows.Scan(&rt.UserID, &rt.TrackID, &rt.Rating, &rt.RatedAt)
+		s.Ratings = append(s.Ratings, rt)
+		return err
+	}, arr); err != nil {
+		return nil, err
+	}
+
+	if err := r.eachRow("SELECT id, user_id, track_id, played_at FROM track_plays WHERE track_id IN ("+tracksSQL+") ORDER BY id", func(rows *sql.Rows) error {
+		var p PlayRow
+		err := rows.Scan(&p.ID, &p.UserID, &p.TrackID, &p.PlayedAt)
+		s.Plays = append(s.Plays, p)
+		return err
+	}, arr); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT playlist_id, track_id, added_by, added_at, position
+    FROM playlist_tracks WHERE track_id IN (`+tracksSQL+`)`, arr)
+	if err != nil {
+		return nil, err
+	}
+	if s.Entries, err = scanEntries(rows); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SnapshotPlaylists сохраняет плейлисты вместе с треками, участниками и подписками
+func (r *Repository) SnapshotPlaylists(ids []int) (*Snapshot, error) {
+	s := &Snapshot{}
+	arr := pq.Array(ids)
+
+	if err := r.eachRow(`SELECT id, title, user_id, visibility, COALESCE(folder_id, 0)
+    FROM playlists WHERE id = ANY($1) ORDER BY id`, func(rows *sql.Rows) error {
+		var p PlaylistRow
+		err := rows.Scan(&p.ID, &p.Title, &p.UserID, &p.Visibility, &p.FolderID)
+		s.Playlists = append(s.Playlists, p)
+		return err
+	}, arr); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query("SELECT playlist_id, track_id, added_by, added_at, position FROM playlist_tracks WHERE playlist_id = ANY($1)", arr)
+	if err != nil {
+		return nil, err
+	}
+	if s.Entries, err = scanEntries(rows); err != nil {
+		return nil, err
+	}
+
+	if err := r.eachRow("SELECT playlist_id, user_id, permission FROM playlist_collaborators WHERE playlist_id = ANY($1)", func(rows *sql.Rows) error {
+		var c CollaboratorRow
+		err := rows.Scan(&c.PlaylistID, &c.UserID, &c.Permission)
+		s.Collaborators = append(s.Collaborators, c)
+		return err
+	}, arr); err != nil {
+		return nil, err
+	}
+
+	if err := r.eachRow("SELECT user_id, playlist_id, followed_at FROM playlist_follows WHERE playlist_id = ANY($1)", func(rows *sql.Rows) error {
+		var f FollowRow
+		err := rows.Scan(&f.UserID, &f.PlaylistID, &f.FollowedAt)
+		s.Follows = append(s.Follows, f)
+		return err
+	}, arr); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SnapshotEntries сохраняет вхождения треков в плейлист (nil — все треки плейлиста)
+func (r *Repository) SnapshotEntries(pID int, trackIDs []int) ([]EntryRow, error) {
+	rows, err := r.db.Query(`SELECT playlist_id, track_id, added_by, added_at, position FROM playlist_tracks
+    WHERE playlist_id=$1 AND ($2::int[] IS NULL OR track_id = ANY($2)) ORDER BY position`, pID, pq.Array(trackIDs))
+	if err != nil {
+		return nil, err
+	}
+	return scanEntries(rows)
+}
+
+// RestoreSnapshot возвращает удалённые строки одной транзакцией.
+// Вхождения в плейлисты, которых уже нет, и пользователей, удалённых с тех пор, пропускаются.
+func (r *Repository) RestoreSnapshot(s *Snapshot) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		for _, a := range s.Artists {
+			if _, err := tx.Exec("INSERT INTO artists (id, name) VALUES ($1, $2)", a.ID, a.Name); err != nil {
+				return fmt.Errorf("не удалось восстановить артиста %q: %w", a.Name, err)
+			}
+		}
+		for _, a := range s.Albums {
+			if _, err := tx.Exec("INSERT INTO albums (id, title, artist_id, year, credits) VALUES ($1, $2, $3, $4, NULLIF($5, ''))",
+				a.ID, a.Title, a.ArtistID, a.Year, a.Credits); err != nil {
+				return fmt.Errorf("не удалось восстановить альбом %q: %w", a.Title, err)
+			}
+		}
+		for _, t := range s.Tracks {
+			if _, err := tx.Exec(`INSERT INTO tracks (id, title, album_id, duration, genre, track_number, bpm, musical_key)
+            VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, 0), NULLIF($7::real, 0), NULLIF($8, ''))`,
+				t.ID, t.Title, t.AlbumID, t.Duration, t.Genre, t.Number, t.BPM, t.Key); err != nil {
+				return fmt.Errorf("не удалось восстановить трек %q: %w", t.Title, err)
+			}
+		}
+		for _, l := range s.Loudness {
+			if _, err := tx.Exec("UPDATE "+loudnessTables[l.Kind]+" SET loudness_lufs = $2, true_peak_db = $3, replay_gain_db = $4 WHERE id = $1",
+				l.ID, l.LUFS, l.TruePeak, l.Gain); err != nil {
+				return err
+			}
+		}
+		for _, t := range s.Tags {
+			if _, err := tx.Exec("INSERT INTO track_tags (track_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING", t.TrackID, t.Tag); err != nil {
+				return err
+			}
+		}
+		for _, f := range s.Fingerprints {
+			if _, err := tx.Exec(`INSERT INTO track_fingerprints (track_id, fingerprint, file_path, computed_at)
+            VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`, f.TrackID, f.Fingerprint, f.FilePath, f.ComputedAt); err != nil {
+				return err
+			}
+		}
+		for _, rt := range s.Ratings {
+			if _, err := tx.Exec(`INSERT INTO track_ratings (user_id, track_id, rating, rated_at)
+            SELECT $1, $2, $3, $4 WHERE EXISTS (SELECT 1 FROM users WHERE id = $1)
+            ON CONFLICT DO NOTHING`, rt.UserID, rt.TrackID, rt.Rating, rt.RatedAt); err != nil {
+				return err
+			}
+		}
+		for _, p := range s.Plays {
+			if _, err := tx.Exec(`INSERT INTO track_plays (id, user_id, track_id, played_at)
+            SELECT $1, $2, $3, $4 WHERE EXISTS (SELECT 1 FROM users WHERE id = $2)
+            ON CONFLICT DO NOTHING`, p.ID, p.UserID, p.TrackID, p.PlayedAt); err != nil {
+				return err
+			}
+		}
+		for _, p := range s.Playlists {
+			if _, err := tx.Exec(`INSERT INTO playlists (id, title, user_id, visibility, folder_id)
+            VALUES ($1, $2, $3, $4, (SELECT id FROM playlist_folders WHERE id = $5))`,
+				p.ID, p.Title, p.UserID, p.Visibility, p.FolderID); err != nil {
+				return fmt.Errorf("не удалось восстановить плейлист %q: %w", p.Title, err)
+			}
+		}
+
+		touched := map[int]bool{}
+		for _, e := range s.Entries {
+			res, err := tx.Exec(`INSERT INTO playlist_tracks (playlist_id, track_id, added_by, added_at, position)
+            SELECT $1, $2, (SELECT id FROM users WHERE id = $3), $4, CASE WHEN $5 > 0 THEN $5 ELSE `+nextPositionSQL+` + 1 END
+            WHERE EXISTS (SELECT 1 FROM playlists WHERE id = $1) AND EXISTS (SELECT 1 FROM tracks WHERE id = $2)
+            ON CONFLICT (playlist_id, track_id) DO NOTHING`, e.PlaylistID, e.TrackID, e.AddedBy, e.AddedAt, e.Position)
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				touched[e.PlaylistID] = true
+			}
+		}
+		for _, c := range s.Collaborators {
+			if _, err := tx.Exec(`INSERT INTO playlist_collaborators (playlist_id, user_id, permission)
+            SELECT $1, $2, $3 WHERE EXISTS (SELECT 1 FROM users WHERE id = $2)
+            ON CONFLICT DO NOTHING`, c.PlaylistID, c.UserID, c.Permission); err != nil {
+				return err
+			}
+		}
+		for _, f := range s.Follows {
+			if _, err := tx.Exec(`INSERT INTO playlist_follows (user_id, playlist_id, followed_at)
+            SELECT $1, $2, $3 WHERE EXISTS (SELECT 1 FROM users WHERE id = $1)
+            ON CONFLICT DO NOTHING`, f.UserID, f.PlaylistID, f.FollowedAt); err != nil {
+				return err
+			}
+		}
+		// Участники совместных плейлистов должны увидеть, что состав изменился
+		for pID := range touched {
+			if _, err := bumpPlaylistVersion(tx, pID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetEntryOrder переставляет треки плейлиста на позиции из entries; время добавления не меняется.
+// Возвращает новую версию плейлиста.
+func (r *Repository) SetEntryOrder(pID int, entries []EntryRow) (int, error) {
+	var version int
+	err := r.inTx(func(tx *sql.Tx) error {
+		if err := lockPlaylist(tx, pID); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if _, err := tx.Exec("UPDATE playlist_tracks SET position=$3 WHERE playlist_id=$1 AND track_id=$2",
+				pID, e.TrackID, e.Position); err != nil {
+				return err
+			}
+		}
+		var err error
+		version, err = bumpPlaylistVersion(tx, pID)
+		return err
+	})
+	return version, err
+}
+
+// --- EDITS ---
+
+func (r *Repository) UpdateArtist(id int, name string) error {
+	_, err := r.exec("UPDATE artists SET name=$2 WHERE id=$1", id, name)
+	return err
+}
+
+func (r *Repository) UpdateAlbum(id int, title string, year int) error {
+	_, err := r.exec("UPDATE albums SET title=$2, year=$3 WHERE id=$1", id, title, year)
+	return err
+}
+
+func (r *Repository) SetAlbumCredits(id int, credits string) error {
+	_, err := r.exec("UPDATE albums SET credits=NULLIF($2, '') WHERE id=$1", id, credits)
+	return err
+}
+
+func (r *Repository) UpdateTrack(id int, title string, duration int) error {
+	_, err := r.exec("UPDATE tracks SET title=$2, duration=$3 WHERE id=$1", id, title, duration)
+	return err
+}
+
+// --- PERSISTENCE ---
+
+// SaveHistory заменяет сохранённые стеки сеанса; записи идут от старых к новым
+func (r *Repository) SaveHistory(userID int, sessionKey string, undo, redo []HistoryRecord) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM command_history WHERE user_id=$1 AND session_key=$2", userID, sessionKey); err != nil {
+			return err
+		}
+		for stack, records := range map[string][]HistoryRecord{"undo": undo, "redo": redo} {
+			for i, rec := range records {
+				if _, err := tx.Exec(`INSERT INTO command_history (user_id, session_key, stack, seq, op, payload)
+                VALUES ($1, $2, $3, $4, $5, $6)`, userID, sessionKey, stack, i, rec.Op, string(rec.Payload)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repository) LoadHistory(userID int, sessionKey string) (undo, redo []HistoryRecord, err error) {
+	rows, err := r.db.Query(`SELECT stack, op, payload FROM command_history
+    WHERE user_id=$1 AND session_key=$2 ORDER BY stack, seq`, userID, sessionKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var stack, payload string
+		var rec HistoryRecord
+		if err := rows.Scan(&stack, &rec.Op, &payload); err != nil {
+			return nil, nil, err
+		}
+		rec.Payload = []byte(payload)
+		if stack == "undo" {
+			undo = append(undo, rec)
+		} else {
+			redo = append(redo, rec)
+		}
+	}
+	return undo, redo, rows.Err()
+}
+
+// PurgeHistory удаляет историю сеансов старше before
+func (r *Repository) PurgeHistory(before time.Time) error {
+	_, err := r.db.Exec("DELETE FROM command_history WHERE created_at < $1", before)
+	return err
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// LOUDNESS
+
+// TrackFile — трек с привязанным файлом; файл привязывается при снятии отпечатка
+type TrackFile struct {
+	TrackID     int
+	AlbumID     int
+	Path        string
+	HasLoudness bool // громкость уже измерена
+	HasTempo    bool // темп и тональность уже определены
+}
+
+// GetTrackFiles возвращает треки с файлами, сгруппированные по альбомам
+func (r *Repository) GetTrackFiles() ([]TrackFile, error) {
+	rows, err := r.db.Query(`SELECT t.id, t.album_id, f.file_path, t.loudness_lufs IS NOT NULL, t.bpm IS NOT NULL
+    FROM track_fingerprints f JOIN tracks t ON t.id = f.track_id
+    WHERE t.is_deleted = false
+    ORDER BY t.album_id, t.track_number NULLS LAST, t.title`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrackFile
+	for rows.Next() {
+		var f TrackFile
+		rows.Scan(&f.TrackID, &f.AlbumID, &f.Path, &f.HasLoudness, &f.HasTempo)
+		items = append(items, f)
+	}
+	return items, rows.Err()
+}
+
+var loudnessTables = map[string]string{KindTrack: "tracks", KindAlbum: "albums"}
+
+func (r *Repository) SaveLoudness(kind string, id int, l Loudness) error {
+	_, err := r.exec("UPDATE "+loudnessTables[kind]+" SET loudness_lufs = $2, true_peak_db = $3, replay_gain_db = $4 WHERE id = $1",
+		id, l.LUFS, l.TruePeak, l.Gain)
+	return err
+}
+
+// AudioInfo — файл и громкость трека и его альбома
+type AudioInfo struct {
+	FilePath      string
+	TrackLoudness *Loudness
+	AlbumLoudness *Loudness
+}
+
+func (r *Repository) GetAudioInfo(ids []int) (map[int]AudioInfo, error) {
+	rows, err := r.db.Query(`SELECT t.id, COALESCE(f.file_path, ''),
+        t.loudness_lufs, t.true_peak_db, t.replay_gain_db,
+        al.loudness_lufs, al.true_peak_db, al.replay_gain_db
+    FROM tracks t
+    JOIN albums al ON al.id = t.album_id
+    LEFT JOIN track_fingerprints f ON f.track_id = t.id
+    WHERE t.id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	infos := map[int]AudioInfo{}
+	for rows.Next() {
+		var id int
+		var info AudioInfo
+		var tl, tp, tg, al, ap, ag sql.NullFloat64
+		rows.Scan(&id, &info.FilePath, &tl, &tp, &tg, &al, &ap, &ag)
+		if tl.Valid {
+			info.TrackLoudness = &Loudness{LUFS: tl.Float64, TruePeak: tp.Float64, Gain: tg.Float64}
+		}
+		if al.Valid {
+			info.AlbumLoudness = &Loudness{LUFS: al.Float64, TruePeak: ap.Float64, Gain: ag.Float64}
+		}
+		infos[id] = info
+	}
+	return infos, rows.Err()
+}
+
+// SaveTempo сохраняет темп и тональность; пустая тональность — не определена
+func (r *Repository) SaveTempo(id int, bpm float64, key string) error {
+	_, err := r.exec("UPDATE tracks SET bpm = $2, musical_key = NULLIF($3, '') WHERE id = $1", id, bpm, key)
+	return err
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// METADATA ENRICHMENT
+
+// MetadataChange — правка одного поля записи каталога, предложенная сервисом метаданных.
+// Значения хранятся строками: числовые поля (год, длительность, номер) — десятичной записью.
+type MetadataChange struct {
+	Kind  string
+	ID    int
+	Field string
+	Label string // что правится, для показа: «трек "Yesterday"»
+	Old   string
+	New   string
+}
+
+// Поля, которые можно уточнить, и их столбцы
+var metadataColumns = map[string]struct {
+	table, column string
+	numeric       bool
+}{
+	KindArtist + ".name":    {"artists", "name", false},
+	KindAlbum + ".title":    {"albums", "title", false},
+	KindAlbum + ".year":     {"albums", "year", true},
+	KindAlbum + ".credits":  {"albums", "credits", false},
+	KindTrack + ".title":    {"tracks", "title", false},
+	KindTrack + ".duration": {"tracks", "duration", true},
+	KindTrack + ".number":   {"tracks", "track_number", true},
+}
+
+// metadataArg — значение поля для записи в столбец. Пустое или нулевое числовое поле
+// хранится как NULL, как и при импорте; читатели каталога возвращают его как 0.
+func metadataArg(numeric bool, label, value string) (interface{}, error) {
+	if !numeric {
+		return value, nil
+	}
+	if value == "" {
+		return sql.NullInt64{}, nil // поле было пустым
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: неверное число %q", label, value)
+	}
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}, nil
+}
+
+// ApplyMetadata записывает новые значения полей одной транзакцией; revert — вернуть прежние
+func (r *Repository) ApplyMetadata(changes []MetadataChange, revert bool) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		for _, c := range changes {
+			col, ok := metadataColumns[c.Kind+"."+c.Field]
+			if !ok {
+				return fmt.Errorf("поле %q нельзя изменить", c.Kind+"."+c.Field)
+			}
+			value := c.New
+			if revert {
+				value = c.Old
+			}
+			arg, err := metadataArg(col.numeric, c.Label, value)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec("UPDATE "+col.table+" SET "+col.column+" = $2 WHERE id = $1", c.ID, arg); err != nil {
+				return fmt.Errorf("%s: %w", c.Label, err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetAlbumTracks возвращает треки альбома по номерам, без номера — в конце
+func (r *Repository) GetAlbumTracks(albumID int) ([]Track, error) {
+	rows, err := r.db.Query(`SELECT id, title, album_id, COALESCE(duration, 0), COALESCE(genre, ''), COALESCE(track_number, 0)
+    FROM tracks WHERE album_id = $1 AND is_deleted = false
+    ORDER BY track_number NULLS LAST, title`, albumID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Track
+	for rows.Next() {
+		var t Track
+		rows.Scan(&t.ID, &t.Title, &t.AlbumID, &t.Duration, &t.Genre, &t.Number)
+		items = append(items, t)
+	}
+	return items, nil
+}
+
+// --- RESPONSE CACHE ---
+
+// CachedResponse возвращает сохранённый ответ не старше maxAge
+func (r *Repository) CachedResponse(url string, maxAge time.Duration) (string, bool, error) {
+	var body string
+	err := r.db.QueryRow("SELECT body FROM metadata_cache WHERE url = $1 AND fetched_at > $2",
+		url, nowFunc().Add(-maxAge)).Scan(&body)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	return body, err == nil, err
+}
+
+func (r *Repository) SaveCachedResponse(url, body string) error {
+	_, err := r.exec(`INSERT INTO metadata_cache (url, body, fetched_at) VALUES ($1, $2, $3)
+    ON CONFLICT (url) DO UPDATE SET body = EXCLUDED.body, fetched_at = EXCLUDED.fetched_at`, url, body, nowFunc())
+	return err
+}
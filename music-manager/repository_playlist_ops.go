@@ -0,0 +1,197 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// PLAYLIST OPERATIONS
+// Каждая операция создаёт новые плейлисты в одной транзакции: либо всё, либо ничего.
+
+// Операции над множествами треков двух плейлистов
+const (
+	SetUnion        = "union"
+	SetIntersection = "intersection"
+	SetDifference   = "difference"
+)
+
+var setOperationSQL = map[string]string{
+	SetUnion:        "UNION",
+	SetIntersection: "INTERSECT",
+	SetDifference:   "EXCEPT",
+}
+
+func createPlaylistTx(tx *sql.Tx, title string, userID int) (int, error) {
+	var id int
+	err := tx.QueryRow("INSERT INTO playlists (title, user_id) VALUES ($1, $2) RETURNING id", title, userID).Scan(&id)
+	return id, err
+}
+
+// MergePlaylists объединяет плейлисты в новый в порядке их перечисления.
+// Точные повторы отбрасываются всегда; при dedup ещё и треки с тем же названием
+// у того же артиста (например, один трек из альбома и из сборника)
+func (r *Repository) MergePlaylists(srcIDs []int, userID int, title string, dedup bool) (int, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := createPlaylistTx(tx, title, userID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(`
+    WITH src AS (
+        SELECT pt.track_id, pt.position, array_position($2::int[], pt.playlist_id) AS src_pos,
+            lower(trim(t.title)) AS norm_title, al.artist_id
+        FROM playlist_tracks pt
+        JOIN tracks t ON t.id = pt.track_id
+        JOIN albums al ON al.id = t.album_id
+        WHERE pt.playlist_id = ANY($2)
+    ), ranked AS (
+        SELECT track_id, src_pos, position,
+            ROW_NUMBER() OVER (PARTITION BY CASE WHEN $4 THEN norm_title || '|' || artist_id ELSE track_id::text END
+                ORDER BY src_pos, position) AS dup_rank
+        FROM src
+    )
+    INSERT INTO playlist_tracks (playlist_id, track_id, added_by, position)
+    SELECT $1, track_id, $3, ROW_NUMBER() OVER (ORDER BY src_pos, position)
+    FROM ranked WHERE dup_rank = 1`, id, pq.Array(srcIDs), userID, dedup)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// SetOperationPlaylists сохраняет в новый плейлист объединение, пересечение или разность треков A и B;
+// порядок — как в A, затем треки B
+func (r *Repository) SetOperationPlaylists(aID, bID int, op string, userID int, title string) (int, error) {
+	sqlOp, ok := setOperationSQL[op]
+	if !ok {
+		return 0, fmt.Errorf("неизвестная операция %q", op)
+	}
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := createPlaylistTx(tx, title, userID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(`
+    WITH result AS (
+        SELECT track_id FROM playlist_tracks WHERE playlist_id = $2
+        `+sqlOp+`
+        SELECT track_id FROM playlist_tracks WHERE playlist_id = $3
+    ), ordered AS (
+        SELECT DISTINCT ON (r.track_id) r.track_id,
+            CASE WHEN pt.playlist_id = $2 THEN 0 ELSE 1 END AS src_pos, pt.position
+        FROM result r JOIN playlist_tracks pt ON pt.track_id = r.track_id AND pt.playlist_id IN ($2, $3)
+        ORDER BY r.track_id, src_pos
+    )
+    INSERT INTO playlist_tracks (playlist_id, track_id, added_by, position)
+    SELECT $1, track_id, $4, ROW_NUMBER() OVER (ORDER BY src_pos, position)
+    FROM ordered`, id, aID, bID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// Способ разбиения плейлиста
+const (
+	SplitByCount    = "count"    // не больше limit треков в части
+	SplitByDuration = "duration" // не больше limit секунд в части
+)
+
+// SplitPlaylist разбивает плейлист на части подряд идущих треков;
+// название части с номером n (с единицы) даёт titles(n)
+func (r *Repository) SplitPlaylist(srcID, userID int, mode string, limit int, titles func(part int) string) ([]int, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("размер части должен быть больше нуля")
+	}
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем источник, чтобы разбить согласованный снимок
+	if err := lockPlaylist(tx, srcID); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`
+    SELECT pt.track_id, COALESCE(t.duration, 0)
+    FROM playlist_tracks pt JOIN tracks t ON t.id = pt.track_id
+    WHERE pt.playlist_id = $1 ORDER BY pt.position`, srcID)
+	if err != nil {
+		return nil, err
+	}
+	var parts [][]int
+	var used int
+	for rows.Next() {
+		var trackID, duration int
+		rows.Scan(&trackID, &duration)
+		size := 1
+		if mode == SplitByDuration {
+			size = duration
+		}
+		// новая часть начинается, когда трек не помещается в текущую
+		if len(parts) == 0 || (used > 0 && used+size > limit) {
+			parts = append(parts, nil)
+			used = 0
+		}
+		parts[len(parts)-1] = append(parts[len(parts)-1], trackID)
+		used += size
+	}
+	rows.Close()
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("плейлист и так укладывается в одну часть")
+	}
+
+	var ids []int
+	for i, trackIDs := range parts {
+		id, err := createPlaylistTx(tx, titles(i+1), userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := insertTracksTx(tx, id, userID, trackIDs); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit()
+}
+
+// nextPositionSQL — последняя позиция в плейлисте $1; новые треки встают после неё.
+// Порядок плейлиста задаёт position, а added_at остаётся временем добавления.
+const nextPositionSQL = "(SELECT COALESCE(MAX(position), 0) FROM playlist_tracks WHERE playlist_id = $1)"
+
+// insertTracksTx добавляет треки в конец плейлиста в порядке перечисления
+func insertTracksTx(tx *sql.Tx, playlistID, userID int, trackIDs []int) error {
+	_, err := tx.Exec(`
+    INSERT INTO playlist_tracks (playlist_id, track_id, added_by, position)
+    SELECT $1, track_id, $3, `+nextPositionSQL+` + ord
+    FROM unnest($2::int[]) WITH ORDINALITY AS u(track_id, ord)`, playlistID, pq.Array(trackIDs), userID)
+	return err
+}
+
+// CreatePlaylistWithTracks создаёт плейлист сразу с треками
+func (r *Repository) CreatePlaylistWithTracks(userID int, title string, trackIDs []int) (int, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := createPlaylistTx(tx, title, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertTracksTx(tx, id, userID, trackIDs); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
@@ -0,0 +1,109 @@
+package main
+
+// RECOMMENDATIONS
+
+// GetPlaylistTrackSets возвращает состав публичных плейлистов и своих плейлистов userID — только
+// ID треков, для подсчёта того, какие треки встречаются вместе. Чужие закрытые и ссылочные
+// плейлисты не учитываются, чтобы рекомендации не выдавали их содержимое.
+func (r *Repository) GetPlaylistTrackSets(userID int) ([][]int, error) {
+	rows, err := r.db.Query(`SELECT pt.playlist_id, pt.track_id
+    FROM playlist_tracks pt
+    JOIN playlists p ON p.id = pt.playlist_id
+    JOIN tracks t ON t.id = pt.track_id
+    WHERE p.is_deleted = false AND t.is_deleted = false
+      AND (p.user_id = $1 OR p.visibility = 'public')
+    ORDER BY pt.playlist_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sets [][]int
+	last := -1
+	for rows.Next() {
+		var pID, trackID int
+		rows.Scan(&pID, &trackID)
+		if pID != last {
+			sets = append(sets, nil)
+			last = pID
+		}
+		sets[len(sets)-1] = append(sets[len(sets)-1], trackID)
+	}
+	return sets, rows.Err()
+}
+
+// GetTrackArtists возвращает артиста каждого трека
+func (r *Repository) GetTrackArtists() (map[int]int, error) {
+	rows, err := r.db.Query(`SELECT t.id, al.artist_id FROM tracks t JOIN albums al ON al.id = t.album_id
+    WHERE t.is_deleted = false`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	artists := map[int]int{}
+	for rows.Next() {
+		var trackID, artistID int
+		rows.Scan(&trackID, &artistID)
+		artists[trackID] = artistID
+	}
+	return artists, rows.Err()
+}
+
+// --- RATINGS ---
+
+// SetRating ставит оценку; 0 — снять оценку
+func (r *Repository) SetRating(userID, trackID, rating int) error {
+	if rating == 0 {
+		_, err := r.exec("DELETE FROM track_ratings WHERE user_id = $1 AND track_id = $2", userID, trackID)
+		return err
+	}
+	_, err := r.exec(`INSERT INTO track_ratings (user_id, track_id, rating) VALUES ($1, $2, $3)
+    ON CONFLICT (user_id, track_id) DO UPDATE SET rating = EXCLUDED.rating, rated_at = now()`, userID, trackID, rating)
+	return err
+}
+
+func (r *Repository) GetUserRatings(userID int) (map[int]int, error) {
+	return r.intsByTrack("SELECT track_id, rating FROM track_ratings WHERE user_id = $1", userID)
+}
+
+// GetAverageRatings — средняя оценка трека остальными пользователями
+func (r *Repository) GetAverageRatings(exceptUserID int) (map[int]float64, error) {
+	rows, err := r.db.Query("SELECT track_id, AVG(rating) FROM track_ratings WHERE user_id <> $1 GROUP BY track_id", exceptUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	avg := map[int]float64{}
+	for rows.Next() {
+		var trackID int
+		var v float64
+		rows.Scan(&trackID, &v)
+		avg[trackID] = v
+	}
+	return avg, rows.Err()
+}
+
+// --- PLAYS ---
+
+func (r *Repository) RecordPlay(userID, trackID int) error {
+	_, err := r.exec("INSERT INTO track_plays (user_id, track_id) VALUES ($1, $2)", userID, trackID)
+	return err
+}
+
+func (r *Repository) GetPlayCounts(userID int) (map[int]int, error) {
+	return r.intsByTrack("SELECT track_id, COUNT(*) FROM track_plays WHERE user_id = $1 GROUP BY track_id", userID)
+}
+
+func (r *Repository) intsByTrack(query string, args ...any) (map[int]int, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	m := map[int]int{}
+	for rows.Next() {
+		var trackID, v int
+		rows.Scan(&trackID, &v)
+		m[trackID] = v
+	}
+	return m, rows.Err()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LOGIN THROTTLING
+
+// GetLockedUntil возвращает самый поздний срок блокировки среди ключей (нулевое время, если блокировки нет)
+func (r *Repository) GetLockedUntil(keys ...string) (time.Time, error) {
+	var until sql.NullTime
+	err := r.db.QueryRow("SELECT MAX(locked_until) FROM login_failures WHERE key = ANY($1)", pq.Array(keys)).Scan(&until)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until.Time, nil
+}
+
+// IncrementLoginFailures увеличивает счётчик неудач; счётчик начинается заново,
+// если предыдущая неудача была раньше windowStart
+func (r *Repository) IncrementLoginFailures(key string, now, windowStart time.Time) (int, error) {
+	var failures int
+	err := r.db.QueryRow(`
+    INSERT INTO login_failures (key, failures, last_failure) VALUES ($1, 1, $2)
+    ON CONFLICT (key) DO UPDATE SET
+        failures = CASE WHEN login_failures.last_failure < $3 THEN 1 ELSE login_failures.failures + 1 END,
+        last_failure = $2
+    RETURNING failures`, key, now, windowStart).Scan(&failures)
+	return failures, err
+}
+
+func (r *Repository) SetLockedUntil(key string, until time.Time) error {
+	_, err := r.exec("UPDATE login_failures SET locked_until=$1 WHERE key=$2", until, key)
+	return err
+}
+
+func (r *Repository) ResetLoginFailures(key string) error {
+	_, err := r.exec("DELETE FROM login_failures WHERE key=$1", key)
+	return err
+}
+
+// LOGIN AUDIT
+
+func (r *Repository) RecordLoginAttempt(a LoginAttempt) error {
+	_, err := r.exec(
+		"INSERT INTO login_attempts (username, source, success, reason, attempted_at) VALUES ($1, $2, $3, $4, $5)",
+		a.Username, a.Source, a.Success, a.Reason, a.AttemptedAt,
+	)
+	return err
+}
+
+func (r *Repository) GetLoginAttempts(username string, limit int) ([]LoginAttempt, error) {
+	rows, err := r.db.Query(`
+    SELECT username, source, success, COALESCE(reason, ''), attempted_at
+    FROM login_attempts WHERE username=$1
+    ORDER BY attempted_at DESC LIMIT $2`, username, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoginAttempt
+	for rows.Next() {
+		var a LoginAttempt
+		rows.Scan(&a.Username, &a.Source, &a.Success, &a.Reason, &a.AttemptedAt)
+		items = append(items, a)
+	}
+	return items, nil
+}
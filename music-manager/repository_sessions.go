@@ -0,0 +1,61 @@
+package main
+
+import (
+	"time"
+)
+
+// SESSIONS
+
+func (r *Repository) CreateSession(userID int, tokenHash, device string, now, expires time.Time) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+    INSERT INTO sessions (user_id, token_hash, device, created_at, last_used_at, expires_at)
+    VALUES ($1, $2, $3, $4, $4, $5) RETURNING id`,
+		userID, tokenHash, device, now, expires).Scan(&id)
+	return id, err
+}
+
+// GetSessionUser находит пользователя по действующему (не отозванному и не истёкшему) токену
+// и отмечает время последнего использования сеанса
+func (r *Repository) GetSessionUser(tokenHash string, now time.Time) (*User, int, error) {
+	var u User
+	var sessionID int
+	err := r.db.QueryRow(`
+    UPDATE sessions s SET last_used_at = $2
+    FROM users u
+    WHERE u.id = s.user_id AND s.token_hash = $1 AND NOT s.revoked AND s.expires_at > $2
+    RETURNING u.id, u.username, u.role, s.id`, tokenHash, now).Scan(&u.ID, &u.Username, &u.Role, &sessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &u, sessionID, nil
+}
+
+func (r *Repository) GetSessions(userID int, now time.Time) ([]Session, error) {
+	rows, err := r.db.Query(`
+    SELECT id, device, created_at, last_used_at, expires_at FROM sessions
+    WHERE user_id=$1 AND NOT revoked AND expires_at > $2
+    ORDER BY last_used_at DESC`, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var s Session
+		rows.Scan(&s.ID, &s.Device, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt)
+		items = append(items, s)
+	}
+	return items, nil
+}
+
+func (r *Repository) RevokeSession(userID, sessionID int) error {
+	_, err := r.exec("UPDATE sessions SET revoked=true WHERE id=$1 AND user_id=$2", sessionID, userID)
+	return err
+}
+
+// RevokeOtherSessions отзывает все сеансы пользователя, кроме exceptID
+func (r *Repository) RevokeOtherSessions(userID, exceptID int) error {
+	_, err := r.exec("UPDATE sessions SET revoked=true WHERE user_id=$1 AND id<>$2", userID, exceptID)
+	return err
+}
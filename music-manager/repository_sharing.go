@@ -0,0 +1,102 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// PLAYLIST SHARING
+
+// GetSharedPlaylists возвращает чужие плейлисты, к которым у пользователя есть доступ
+func (r *Repository) GetSharedPlaylists(userID int) ([]Playlist, error) {
+	rows, err := r.db.Query(`
+    SELECT p.id, p.title, p.version, u.username, c.permission
+    FROM playlist_collaborators c
+    JOIN playlists p ON p.id = c.playlist_id
+    JOIN users u ON u.id = p.user_id
+    WHERE c.user_id = $1 AND p.is_deleted = false
+    ORDER BY p.title`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Playlist
+	for rows.Next() {
+		var p Playlist
+		rows.Scan(&p.ID, &p.Title, &p.Version, &p.OwnerName, &p.Permission)
+		items = append(items, p)
+	}
+	return items, nil
+}
+
+// GetPlaylistPermission возвращает права пользователя на плейлист ("" — нет доступа).
+// token — код ссылки, по которому пользователь открыл плейлист; без него плейлист "по ссылке"
+// доступен только подписчикам — номер плейлиста сам по себе доступа не даёт.
+func (r *Repository) GetPlaylistPermission(pID, userID int, token string) (string, error) {
+	var perm string
+	err := r.db.QueryRow(`
+    SELECT CASE
+        WHEN p.user_id = $2 THEN 'owner'
+        WHEN c.permission IS NOT NULL THEN c.permission
+        WHEN p.visibility = 'public' THEN 'view'
+        WHEN p.visibility = 'unlisted' AND (p.share_token = $3
+            OR EXISTS (SELECT 1 FROM playlist_follows f WHERE f.playlist_id = p.id AND f.user_id = $2)) THEN 'view'
+        ELSE '' END
+    FROM playlists p
+    LEFT JOIN playlist_collaborators c ON c.playlist_id = p.id AND c.user_id = $2
+    WHERE p.id = $1`, pID, userID, token).Scan(&perm)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return perm, err
+}
+
+func (r *Repository) GetPlaylistVersion(pID int) (int, error) {
+	var version int
+	err := r.db.QueryRow("SELECT version FROM playlists WHERE id=$1", pID).Scan(&version)
+	return version, err
+}
+
+func (r *Repository) GetCollaborators(pID int) ([]Collaborator, error) {
+	rows, err := r.db.Query(`
+    SELECT u.id, u.username, c.permission
+    FROM playlist_collaborators c JOIN users u ON u.id = c.user_id
+    WHERE c.playlist_id = $1 ORDER BY u.username`, pID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Collaborator
+	for rows.Next() {
+		var c Collaborator
+		rows.Scan(&c.UserID, &c.Username, &c.Permission)
+		items = append(items, c)
+	}
+	return items, nil
+}
+
+// SetCollaborator приглашает пользователя по логину или меняет его права
+func (r *Repository) SetCollaborator(pID int, username, permission string) error {
+	var userID, ownerID int
+	err := r.db.QueryRow("SELECT id FROM users WHERE username=$1", username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("пользователь %q не найден", username)
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.db.QueryRow("SELECT user_id FROM playlists WHERE id=$1", pID).Scan(&ownerID); err != nil {
+		return err
+	}
+	if ownerID == userID {
+		return fmt.Errorf("владелец уже имеет полный доступ")
+	}
+	_, err = r.exec(`INSERT INTO playlist_collaborators (playlist_id, user_id, permission) VALUES ($1, $2, $3)
+        ON CONFLICT (playlist_id, user_id) DO UPDATE SET permission = EXCLUDED.permission`, pID, userID, permission)
+	return err
+}
+
+func (r *Repository) RemoveCollaborator(pID, userID int) error {
+	_, err := r.exec("DELETE FROM playlist_collaborators WHERE playlist_id=$1 AND user_id=$2", pID, userID)
+	return err
+}
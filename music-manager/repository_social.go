@@ -0,0 +1,169 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// PUBLIC PLAYLISTS
+
+// SetPlaylistVisibility меняет видимость; при переходе в режим "по ссылке" выдаётся новый код,
+// так что ссылки, разданные раньше, перестают работать
+func (r *Repository) SetPlaylistVisibility(pID int, visibility string) error {
+	_, err := r.exec(`UPDATE playlists SET visibility=$1, updated_at=now(),
+        share_token = CASE WHEN $1 = 'unlisted' THEN replace(gen_random_uuid()::text, '-', '') ELSE share_token END
+    WHERE id=$2`, visibility, pID)
+	return err
+}
+
+func (r *Repository) GetPlaylistShareToken(pID int) (string, error) {
+	var token string
+	err := r.db.QueryRow("SELECT share_token FROM playlists WHERE id=$1", pID).Scan(&token)
+	return token, err
+}
+
+// GetPlaylistIDByShareToken находит плейлист "по ссылке" по коду
+func (r *Repository) GetPlaylistIDByShareToken(token string) (int, error) {
+	var id int
+	err := r.db.QueryRow(`SELECT id FROM playlists
+    WHERE share_token = $1 AND visibility = 'unlisted' AND is_deleted = false`, token).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("плейлист не найден или закрыт владельцем")
+	}
+	return id, err
+}
+
+// Порядок сортировки обзора
+const (
+	SortByFollowers = "followers"
+	SortByTitle     = "title"
+	SortByTracks    = "tracks"
+)
+
+var publicPlaylistOrder = map[string]string{
+	SortByFollowers: "followers DESC, p.title",
+	SortByTitle:     "p.title",
+	SortByTracks:    "track_count DESC, p.title",
+}
+
+// publicPlaylistSelect — общая часть запросов обзора; $1 — текущий пользователь
+const publicPlaylistSelect = `
+    SELECT p.id, p.title, p.visibility, u.id, u.username,
+        (SELECT COUNT(*) FROM playlist_follows f WHERE f.playlist_id = p.id) AS followers,
+        (SELECT COUNT(*) FROM playlist_tracks pt WHERE pt.playlist_id = p.id) AS track_count,
+        EXISTS (SELECT 1 FROM playlist_follows f WHERE f.playlist_id = p.id AND f.user_id = $1),
+        EXISTS (SELECT 1 FROM user_follows uf WHERE uf.followee_id = u.id AND uf.follower_id = $1)
+    FROM playlists p JOIN users u ON u.id = p.user_id`
+
+func scanPublicPlaylists(rows *sql.Rows) []PublicPlaylist {
+	var items []PublicPlaylist
+	for rows.Next() {
+		p := PublicPlaylist{Playlist: Playlist{Permission: PermView}}
+		rows.Scan(&p.ID, &p.Title, &p.Visibility, &p.OwnerID, &p.OwnerName,
+			&p.Followers, &p.TrackCount, &p.Following, &p.FollowingOwner)
+		items = append(items, p)
+	}
+	return items
+}
+
+// GetPublicPlaylists ищет публичные плейлисты других пользователей по названию или владельцу
+func (r *Repository) GetPublicPlaylists(userID int, search, sortBy string, onlyFollowedUsers bool) ([]PublicPlaylist, error) {
+	order, ok := publicPlaylistOrder[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("неизвестная сортировка %q", sortBy)
+	}
+	rows, err := r.db.Query(publicPlaylistSelect+`
+    WHERE p.visibility = 'public' AND p.is_deleted = false AND p.user_id <> $1
+      AND (strpos(lower(p.title), lower($2)) > 0 OR strpos(lower(u.username), lower($2)) > 0)
+      AND (NOT $3 OR EXISTS (SELECT 1 FROM user_follows uf WHERE uf.followee_id = u.id AND uf.follower_id = $1))
+    ORDER BY `+order, userID, search, onlyFollowedUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanPublicPlaylists(rows), nil
+}
+
+// GetVisiblePlaylist открывает чужой публичный плейлист по номеру. Плейлист "по ссылке" открывается,
+// только если token совпадает с его кодом или пользователь уже подписан на него.
+func (r *Repository) GetVisiblePlaylist(userID, pID int, token string) (*PublicPlaylist, error) {
+	rows, err := r.db.Query(publicPlaylistSelect+`
+    WHERE p.id = $2 AND p.is_deleted = false
+      AND (p.visibility = 'public' OR p.visibility = 'unlisted' AND (p.share_token = $3
+          OR EXISTS (SELECT 1 FROM playlist_follows f WHERE f.playlist_id = p.id AND f.user_id = $1)))`, userID, pID, token)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := scanPublicPlaylists(rows)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("плейлист не найден или закрыт владельцем")
+	}
+	return &items[0], nil
+}
+
+// GetFollowedPlaylists — плейлисты, на которые подписан пользователь (пока они не стали приватными)
+func (r *Repository) GetFollowedPlaylists(userID int) ([]Playlist, error) {
+	rows, err := r.db.Query(`
+    SELECT p.id, p.title, p.version, p.visibility, u.username
+    FROM playlist_follows f
+    JOIN playlists p ON p.id = f.playlist_id
+    JOIN users u ON u.id = p.user_id
+    WHERE f.user_id = $1 AND p.is_deleted = false AND p.visibility IN ('public', 'unlisted')
+    ORDER BY p.title`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Playlist
+	for rows.Next() {
+		p := Playlist{Permission: PermView}
+		rows.Scan(&p.ID, &p.Title, &p.Version, &p.Visibility, &p.OwnerName)
+		items = append(items, p)
+	}
+	return items, nil
+}
+
+// FOLLOWING
+
+func (r *Repository) FollowPlaylist(userID, pID int) error {
+	_, err := r.exec("INSERT INTO playlist_follows (user_id, playlist_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", userID, pID)
+	return err
+}
+
+func (r *Repository) UnfollowPlaylist(userID, pID int) error {
+	_, err := r.exec("DELETE FROM playlist_follows WHERE user_id=$1 AND playlist_id=$2", userID, pID)
+	return err
+}
+
+func (r *Repository) FollowUser(followerID, followeeID int) error {
+	_, err := r.exec("INSERT INTO user_follows (follower_id, followee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", followerID, followeeID)
+	return err
+}
+
+func (r *Repository) UnfollowUser(followerID, followeeID int) error {
+	_, err := r.exec("DELETE FROM user_follows WHERE follower_id=$1 AND followee_id=$2", followerID, followeeID)
+	return err
+}
+
+// COPY
+
+// CopyPlaylist создаёт у пользователя новый плейлист с теми же треками; возвращает его ID
+func (r *Repository) CopyPlaylist(srcID, userID int, title string) (int, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var id int
+	if err := tx.QueryRow("INSERT INTO playlists (title, user_id) VALUES ($1, $2) RETURNING id", title, userID).Scan(&id); err != nil {
+		return 0, err
+	}
+	// Копия сохраняет порядок треков источника
+	if _, err := tx.Exec(`INSERT INTO playlist_tracks (playlist_id, track_id, added_by, position)
+        SELECT $1, track_id, $2, position
+        FROM playlist_tracks WHERE playlist_id = $3`, id, userID, srcID); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
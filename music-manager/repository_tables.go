@@ -0,0 +1,156 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// CATALOG TABLES
+// Сортировка и поиск выполняются в базе: ключ колонки переводится в выражение
+// ORDER BY только через эти таблицы, поэтому пользовательский ввод в SQL не попадает.
+
+var artistSortColumns = map[string]string{
+	"name":   "lower(ar.name)",
+	"albums": "COUNT(DISTINCT al.id)",
+	"tracks": "COUNT(t.id)",
+}
+
+var albumSortColumns = map[string]string{
+	"title":    "lower(al.title)",
+	"artist":   "lower(ar.name)",
+	"year":     "al.year",
+	"tracks":   "COUNT(t.id)",
+	"duration": "SUM(t.duration)",
+}
+
+var trackSortColumns = map[string]string{
+	"title":    "lower(t.title)",
+	"artist":   "lower(ar.name)",
+	"album":    "lower(al.title)",
+	"year":     "al.year",
+	"number":   "t.track_number",
+	"duration": "t.duration",
+	"genre":    "lower(t.genre)",
+	"rating":   "r.rating",
+	"bpm":      "t.bpm",
+	"key":      "t.musical_key",
+}
+
+// orderBy собирает ORDER BY; неизвестный ключ — сортировка по fallback.
+// Пустые значения всегда в конце, при равенстве порядок задаёт id.
+func orderBy(columns map[string]string, q TableQuery, fallback, id string) string {
+	expr, ok := columns[q.Sort]
+	if !ok {
+		expr = columns[fallback]
+	}
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("ORDER BY %s %s NULLS LAST, %s", expr, dir, id)
+}
+
+// searchCondition — подстрока без учёта регистра хотя бы в одном из полей; параметр поиска — $1
+func searchCondition(fields ...string) string {
+	var parts []string
+	for _, f := range fields {
+		parts = append(parts, fmt.Sprintf("strpos(lower(%s), lower($1::text)) > 0", f))
+	}
+	return "($1::text = '' OR " + strings.Join(parts, " OR ") + ")"
+}
+
+func (r *Repository) GetArtistRows(q TableQuery) ([]ArtistRow, error) {
+	rows, err := r.db.Query(`SELECT ar.id, ar.name, COUNT(DISTINCT al.id), COUNT(t.id)
+    FROM artists ar
+    LEFT JOIN albums al ON al.artist_id = ar.id AND al.is_deleted = false
+    LEFT JOIN tracks t ON t.album_id = al.id AND t.is_deleted = false
+    WHERE ar.is_deleted = false AND `+searchCondition("ar.name")+`
+    GROUP BY ar.id `+orderBy(artistSortColumns, q, "name", "ar.id"), q.Search)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ArtistRow
+	for rows.Next() {
+		var a ArtistRow
+		rows.Scan(&a.ID, &a.Name, &a.Albums, &a.Tracks)
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+const albumRowSelect = `SELECT al.id, al.title, al.artist_id, COALESCE(al.year, 0), COALESCE(al.credits, ''), ar.name,
+        COUNT(t.id), COALESCE(SUM(t.duration), 0)
+    FROM albums al
+    JOIN artists ar ON ar.id = al.artist_id
+    LEFT JOIN tracks t ON t.album_id = al.id AND t.is_deleted = false
+    `
+
+func scanAlbumRow(s interface{ Scan(...interface{}) error }) (AlbumRow, error) {
+	var a AlbumRow
+	err := s.Scan(&a.ID, &a.Title, &a.ArtistID, &a.Year, &a.Credits, &a.ArtistName, &a.Tracks, &a.Duration)
+	return a, err
+}
+
+func (r *Repository) GetAlbumRows(q TableQuery) ([]AlbumRow, error) {
+	rows, err := r.db.Query(albumRowSelect+`WHERE al.is_deleted = false AND `+searchCondition("al.title", "ar.name")+`
+    GROUP BY al.id, ar.name `+orderBy(albumSortColumns, q, "title", "al.id"), q.Search)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AlbumRow
+	for rows.Next() {
+		a, err := scanAlbumRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+// GetTrackRows — треки с альбомом, артистом и оценкой пользователя userID;
+// поиск идёт по названию трека, альбома и имени артиста
+func (r *Repository) GetTrackRows(userID int, q TableQuery) ([]TrackRow, error) {
+	rows, err := r.db.Query(`SELECT t.id, t.title, t.album_id, COALESCE(t.duration, 0), COALESCE(t.genre, ''),
+        COALESCE(t.track_number, 0), COALESCE(t.bpm, 0), COALESCE(t.musical_key, ''),
+        ar.name, al.title, COALESCE(al.year, 0), COALESCE(r.rating, 0)
+    FROM tracks t
+    JOIN albums al ON al.id = t.album_id
+    JOIN artists ar ON ar.id = al.artist_id
+    LEFT JOIN track_ratings r ON r.track_id = t.id AND r.user_id = $2
+    WHERE t.is_deleted = false AND `+searchCondition("t.title", "al.title", "ar.name")+`
+    `+orderBy(trackSortColumns, q, "title", "t.id"), q.Search, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrackRow
+	for rows.Next() {
+		var t TrackRow
+		rows.Scan(&t.ID, &t.Title, &t.AlbumID, &t.Duration, &t.Genre, &t.Number, &t.BPM, &t.Key,
+			&t.ArtistName, &t.AlbumTitle, &t.Year, &t.Rating)
+		items = append(items, t)
+	}
+	return items, rows.Err()
+}
+
+// --- USER SETTINGS ---
+
+// GetUserSetting возвращает значение настройки; "" — не задана
+func (r *Repository) GetUserSetting(userID int, key string) (string, error) {
+	var value string
+	err := r.db.QueryRow("SELECT value FROM user_settings WHERE user_id = $1 AND key = $2", userID, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+func (r *Repository) SaveUserSetting(userID int, key, value string) error {
+	_, err := r.exec(`INSERT INTO user_settings (user_id, key, value) VALUES ($1, $2, $3)
+    ON CONFLICT (user_id, key) DO UPDATE SET value = EXCLUDED.value`, userID, key, value)
+	return err
+}
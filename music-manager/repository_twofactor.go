@@ -0,0 +1,105 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TWO-FACTOR AUTH
+
+// GetTOTP возвращает секрет и признак включённой 2FA
+func (r *Repository) GetTOTP(userID int) (string, bool, error) {
+	var secret sql.NullString
+	var enabled bool
+	err := r.db.QueryRow("SELECT totp_secret, totp_enabled FROM users WHERE id=$1", userID).Scan(&secret, &enabled)
+	return secret.String, enabled, err
+}
+
+// EnableTOTP включает 2FA и заменяет резервные коды новыми; step — шаг кода подтверждения,
+// чтобы им же нельзя было войти
+func (r *Repository) EnableTOTP(userID int, secret string, step int64, recoveryCodes []string) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE users SET totp_secret=$1, totp_enabled=true, totp_last_step=$2 WHERE id=$3", secret, step, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	for _, code := range recoveryCodes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, string(hash)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DisableTOTP выключает 2FA и удаляет резервные коды (в том числе при сбросе администратором)
+func (r *Repository) DisableTOTP(userID int) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE users SET totp_secret=NULL, totp_enabled=false, totp_last_step=NULL WHERE id=$1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep запоминает шаг принятого кода; возвращает false, если этот или более поздний шаг уже был принят
+func (r *Repository) UseTOTPStep(userID int, step int64) (bool, error) {
+	res, err := r.exec(`UPDATE users SET totp_last_step=$1
+    WHERE id=$2 AND (totp_last_step IS NULL OR totp_last_step < $1)`, step, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// UseRecoveryCode погашает резервный код; возвращает false, если подходящего неиспользованного кода нет
+func (r *Repository) UseRecoveryCode(userID int, code string, now time.Time) (bool, error) {
+	rows, err := r.db.Query("SELECT id, code_hash FROM recovery_codes WHERE user_id=$1 AND used_at IS NULL", userID)
+	if err != nil {
+		return false, err
+	}
+	matched := 0
+	for rows.Next() {
+		var id int
+		var hash string
+		rows.Scan(&id, &hash)
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)) == nil {
+			matched = id
+			break
+		}
+	}
+	rows.Close()
+	if matched == 0 {
+		return false, nil
+	}
+	res, err := r.exec("UPDATE recovery_codes SET used_at=$1 WHERE id=$2 AND used_at IS NULL", now, matched)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *Repository) CountRecoveryCodes(userID int) (int, error) {
+	var n int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM recovery_codes WHERE user_id=$1 AND used_at IS NULL", userID).Scan(&n)
+	return n, err
+}
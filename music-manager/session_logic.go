@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// Ключ в настройках приложения, под которым хранится токен "запомнить меня"
+const sessionTokenPref = "session_token"
+
+var sessionTTL = time.Duration(envInt("SESSION_TTL_DAYS", 30)) * 24 * time.Hour
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// rememberSession создаёт сеанс пользователя userID, сохраняет токен в настройках и возвращает ID сеанса
+func rememberSession(userID int) (int, error) {
+	token, err := newToken()
+	if err != nil {
+		return 0, err
+	}
+	now := nowFunc()
+	id, err := repo.CreateSession(userID, hashToken(token), loginSource(), now, now.Add(sessionTTL))
+	if err != nil {
+		return 0, err
+	}
+	fyne.CurrentApp().Preferences().SetString(sessionTokenPref, token)
+	return id, nil
+}
+
+// resumeSession входит по сохранённому токену; возвращает false, если токена нет или он недействителен
+func resumeSession() bool {
+	prefs := fyne.CurrentApp().Preferences()
+	token := prefs.String(sessionTokenPref)
+	if token == "" {
+		return false
+	}
+	user, sessionID, err := repo.GetSessionUser(hashToken(token), nowFunc())
+	if err != nil {
+		prefs.RemoveValue(sessionTokenPref)
+		return false
+	}
+	currentUser = user
+	currentSessionID = sessionID
+	repo.SetActor(user.ID, user.Username)
+	return true
+}
+
+// forgetSession отзывает текущий сеанс и удаляет токен с этого устройства
+func forgetSession() {
+	if currentSessionID != 0 && currentUser != nil {
+		repo.RevokeSession(currentUser.ID, currentSessionID)
+	}
+	currentSessionID = 0
+	fyne.CurrentApp().Preferences().RemoveValue(sessionTokenPref)
+}
+
+func getSessions() ([]Session, error) {
+	return repo.GetSessions(currentUser.ID, nowFunc())
+}
+
+func revokeSession(id int) error {
+	if id == currentSessionID {
+		forgetSession()
+		return nil
+	}
+	return repo.RevokeSession(currentUser.ID, id)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Уровни доступа к плейлисту по возрастанию
+var permRank = map[string]int{PermView: 1, PermAdd: 2, PermEdit: 3, PermOwner: 4}
+
+var permissionOptions = []string{PermView, PermAdd, PermEdit}
+
+var permissionLabels = map[string]string{
+	PermView:  "просмотр",
+	PermAdd:   "добавление треков",
+	PermEdit:  "полное редактирование",
+	PermOwner: "владелец",
+}
+
+// canPlaylist — хватает ли прав perm для действия, требующего need
+func canPlaylist(perm, need string) bool {
+	return permRank[perm] >= permRank[need]
+}
+
+// Коды ссылок, по которым пользователь открыл плейлисты "по ссылке" в этом сеансе
+var playlistLinks = map[int]string{}
+
+// requirePlaylistPermission проверяет права по базе, а не по закэшированному в интерфейсе плейлисту
+func requirePlaylistPermission(pID int, need string) error {
+	perm, err := repo.GetPlaylistPermission(pID, currentUser.ID, playlistLinks[pID])
+	if err != nil {
+		return err
+	}
+	if !canPlaylist(perm, need) {
+		return errForbidden
+	}
+	return nil
+}
+
+func getSharedPlaylists() []Playlist {
+	items, err := repo.GetSharedPlaylists(currentUser.ID)
+	if err != nil {
+		return nil
+	}
+	return items
+}
+
+// Название плейлиста в списке: чужие помечаются владельцем
+func playlistLabel(p Playlist) string {
+	if p.Permission == PermOwner {
+		return p.Title
+	}
+	return fmt.Sprintf("%s (от %s, %s)", p.Title, p.OwnerName, permissionLabels[p.Permission])
+}
+
+// Треки плейлиста с указанием, кто их добавил
+func getPlaylistEntries(playlistID int) ([]PlaylistEntry, []string) {
+	items, err := repo.GetPlaylistEntries(playlistID)
+	if err != nil {
+		return nil, nil
+	}
+	var names []string
+	for _, e := range items {
+		name := fmt.Sprintf("%s (%s)", e.Title, formatDuration(e.Duration))
+		if e.AddedBy != "" {
+			name += " — добавил " + e.AddedBy
+		}
+		names = append(names, name)
+	}
+	return items, names
+}
+
+// applyPlaylistVersion запоминает новую версию плейлиста и сообщает,
+// вносил ли кто-то ещё изменения с момента последней загрузки
+func applyPlaylistVersion(p *Playlist, version int) bool {
+	changedByOthers := version != p.Version+1
+	p.Version = version
+	return changedByOthers
+}
+
+// addTrackToPlaylist возвращает true, если плейлист успели изменить другие участники
+func addTrackToPlaylist(p *Playlist, trackID int) (bool, error) {
+	if err := requirePlaylistPermission(p.ID, PermAdd); err != nil {
+		return false, err
+	}
+	same, err := acousticDuplicateInPlaylist(p.ID, trackID)
+	if err != nil {
+		return false, err
+	}
+	if same != nil {
+		return false, fmt.Errorf("в плейлисте уже есть «%s» — это та же запись по звучанию", same.Title)
+	}
+	cmd := &addEntriesCmd{PlaylistID: p.ID, TrackIDs: []int{trackID}}
+	if err := runCommand(cmd); err != nil {
+		return false, err
+	}
+	return applyPlaylistVersion(p, cmd.version), nil
+}
+
+func removeTrackFromPlaylist(p *Playlist, trackID int) (bool, error) {
+	if err := requirePlaylistPermission(p.ID, PermEdit); err != nil {
+		return false, err
+	}
+	cmd := &removeEntriesCmd{PlaylistID: p.ID, TrackIDs: []int{trackID}}
+	if err := runCommand(cmd); err != nil {
+		return false, err
+	}
+	return applyPlaylistVersion(p, cmd.version), nil
+}
+
+// moveEntry сдвигает трек на delta позиций (-1 — выше, 1 — ниже), меняясь местами с соседом
+func moveEntry(p *Playlist, trackID, delta int) (bool, error) {
+	if err := requirePlaylistPermission(p.ID, PermEdit); err != nil {
+		return false, err
+	}
+	entries, err := repo.SnapshotEntries(p.ID, nil)
+	if err != nil {
+		return false, err
+	}
+	i := -1
+	for k, e := range entries {
+		if e.TrackID == trackID {
+			i = k
+		}
+	}
+	j := i + delta
+	if i < 0 || j < 0 || j >= len(entries) {
+		return false, fmt.Errorf("трек уже на краю плейлиста")
+	}
+	before := []EntryRow{entries[i], entries[j]}
+	after := []EntryRow{entries[i], entries[j]}
+	after[0].Position, after[1].Position = entries[j].Position, entries[i].Position
+	cmd := &reorderEntriesCmd{PlaylistID: p.ID, Before: before, After: after}
+	if err := runCommand(cmd); err != nil {
+		return false, err
+	}
+	return applyPlaylistVersion(p, cmd.version), nil
+}
+
+// playlistChangedByOthers сверяет загруженную версию с базой
+func playlistChangedByOthers(p *Playlist) bool {
+	version, err := repo.GetPlaylistVersion(p.ID)
+	if err != nil || version == p.Version {
+		return false
+	}
+	p.Version = version
+	return true
+}
+
+// --- COLLABORATORS ---
+
+func getCollaborators(pID int) ([]Collaborator, error) {
+	if err := requirePlaylistPermission(pID, PermOwner); err != nil {
+		return nil, err
+	}
+	return repo.GetCollaborators(pID)
+}
+
+func inviteCollaborator(pID int, username, permission string) error {
+	if err := requirePlaylistPermission(pID, PermOwner); err != nil {
+		return err
+	}
+	if username == "" {
+		return fmt.Errorf("укажите логин пользователя")
+	}
+	if permRank[permission] == 0 || permission == PermOwner {
+		return fmt.Errorf("неизвестный уровень доступа %q", permission)
+	}
+	return repo.SetCollaborator(pID, username, permission)
+}
+
+func removeCollaborator(pID, userID int) error {
+	if err := requirePlaylistPermission(pID, PermOwner); err != nil {
+		return err
+	}
+	return repo.RemoveCollaborator(pID, userID)
+}
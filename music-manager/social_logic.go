@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+var visibilityOptions = []string{VisibilityPrivate, VisibilityUnlisted, VisibilityPublic}
+
+var visibilityLabels = map[string]string{
+	VisibilityPrivate:  "приватный",
+	VisibilityUnlisted: "по ссылке",
+	VisibilityPublic:   "публичный",
+}
+
+func setPlaylistVisibility(pID int, visibility string) error {
+	if err := requirePlaylistPermission(pID, PermOwner); err != nil {
+		return err
+	}
+	if _, ok := visibilityLabels[visibility]; !ok {
+		return fmt.Errorf("неизвестная видимость %q", visibility)
+	}
+	return repo.SetPlaylistVisibility(pID, visibility)
+}
+
+func getPublicPlaylists(search, sortBy string, onlyFollowedUsers bool) ([]PublicPlaylist, error) {
+	return repo.GetPublicPlaylists(currentUser.ID, search, sortBy, onlyFollowedUsers)
+}
+
+func openVisiblePlaylist(pID int) (*PublicPlaylist, error) {
+	return repo.GetVisiblePlaylist(currentUser.ID, pID, playlistLinks[pID])
+}
+
+// openPlaylistLink находит плейлист по коду ссылки и запоминает код до конца сеанса
+func openPlaylistLink(token string) (int, error) {
+	token = strings.ToLower(strings.TrimSpace(token))
+	if token == "" {
+		return 0, fmt.Errorf("введите код ссылки")
+	}
+	id, err := repo.GetPlaylistIDByShareToken(token)
+	if err != nil {
+		return 0, err
+	}
+	playlistLinks[id] = token
+	return id, nil
+}
+
+// getPlaylistLink — код ссылки для владельца плейлиста
+func getPlaylistLink(pID int) (string, error) {
+	if err := requirePlaylistPermission(pID, PermOwner); err != nil {
+		return "", err
+	}
+	return repo.GetPlaylistShareToken(pID)
+}
+
+func getFollowedPlaylists() []Playlist {
+	items, err := repo.GetFollowedPlaylists(currentUser.ID)
+	if err != nil {
+		return nil
+	}
+	return items
+}
+
+func setFollowPlaylist(pID int, follow bool) error {
+	if !follow {
+		return repo.UnfollowPlaylist(currentUser.ID, pID)
+	}
+	if err := requirePlaylistPermission(pID, PermView); err != nil {
+		return err
+	}
+	return repo.FollowPlaylist(currentUser.ID, pID)
+}
+
+func setFollowUser(userID int, follow bool) error {
+	if userID == currentUser.ID {
+		return fmt.Errorf("нельзя подписаться на самого себя")
+	}
+	if !follow {
+		return repo.UnfollowUser(currentUser.ID, userID)
+	}
+	return repo.FollowUser(currentUser.ID, userID)
+}
+
+// uniquePlaylistTitle подбирает название, которого ещё нет среди плейлистов пользователя
+func uniquePlaylistTitle(title string) string {
+	owned, _ := getPlaylists()
+	taken := map[string]bool{}
+	for _, p := range owned {
+		taken[p.Title] = true
+	}
+	if !taken[title] {
+		return title
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", title, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// copyPlaylistToMine копирует чужой плейлист в плейлисты текущего пользователя
+func copyPlaylistToMine(p Playlist) (string, error) {
+	if err := requirePlaylistPermission(p.ID, PermView); err != nil {
+		return "", err
+	}
+	title := uniquePlaylistTitle(p.Title)
+	_, err := repo.CopyPlaylist(p.ID, currentUser.ID, title)
+	return title, err
+}
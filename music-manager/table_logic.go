@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// --- CATALOG TABLES ---
+
+func getArtistRows(q TableQuery) ([]ArtistRow, error) {
+	return repo.GetArtistRows(q)
+}
+
+func getAlbumRows(q TableQuery) ([]AlbumRow, error) {
+	return repo.GetAlbumRows(q)
+}
+
+func getTrackRows(q TableQuery) ([]TrackRow, error) {
+	return repo.GetTrackRows(currentUser.ID, q)
+}
+
+// TableLayout — раскладка таблицы, которую пользователь настроил под себя:
+// скрытые колонки, ширины и сортировка. Хранится в user_settings как JSON.
+type TableLayout struct {
+	Hidden []string           `json:"hidden,omitempty"`
+	Widths map[string]float32 `json:"widths,omitempty"`
+	Sort   string             `json:"sort,omitempty"`
+	Desc   bool               `json:"desc,omitempty"`
+}
+
+func tableLayoutKey(table string) string { return "table." + table }
+
+// loadTableLayout возвращает сохранённую раскладку; nil — пользователь её не менял
+func loadTableLayout(table string) (*TableLayout, error) {
+	value, err := repo.GetUserSetting(currentUser.ID, tableLayoutKey(table))
+	if err != nil || value == "" {
+		return nil, err
+	}
+	var l TableLayout
+	if err := json.Unmarshal([]byte(value), &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func saveTableLayout(table string, l *TableLayout) error {
+	value, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return repo.SaveUserSetting(currentUser.ID, tableLayoutKey(table), string(value))
+}
+
+// ratingStars — оценка звёздами, как в карточке трека; 0 — пусто
+func ratingStars(rating int) string {
+	return strings.Repeat("★", max(rating, 0))
+}
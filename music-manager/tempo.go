@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// Темп и тональность для сведения. Темп: огибающая атак (spectral flux) и её
+// автокорреляция, оценённая на половине, одном, двух и четырёх периодах — так побеждает
+// период доли, а не её дробление. Тональность: средняя хрома трека сравнивается
+// с профилями Крумхансла для 24 тональностей. Тональность хранится в нотации
+// Camelot (8A — ля минор, 8B — до мажор): совместимы соседние номера одной буквы
+// и одинаковый номер с другой буквой.
+
+const (
+	tempoSampleRate = 11025
+	tempoFrameSize  = 1024
+	tempoHop        = 128
+	tempoMaxSeconds = 240
+	tempoMinBPM     = 60.0
+	tempoMaxBPM     = 200.0
+	tempoPreferred  = 120.0 // темпы около него вероятнее, см. tempoPrior
+)
+
+// onsetEnvelope — положительный прирост логарифмического спектра между кадрами
+func onsetEnvelope(samples []float64) []float64 {
+	window := make([]float64, tempoFrameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(tempoFrameSize-1))
+	}
+	buf := make([]complex128, tempoFrameSize)
+	prev := make([]float64, tempoFrameSize/2)
+	var env []float64
+	for start := 0; start+tempoFrameSize <= len(samples); start += tempoHop {
+		for i := range buf {
+			buf[i] = complex(samples[start+i]*window[i], 0)
+		}
+		fft(buf)
+		var flux float64
+		for k := range prev {
+			m := math.Log1p(100 * cmplx.Abs(buf[k]))
+			if d := m - prev[k]; d > 0 && start > 0 {
+				flux += d
+			}
+			prev[k] = m
+		}
+		env = append(env, flux)
+	}
+	// Вычитаем скользящее среднее за ~0,5 с, чтобы остались только сами атаки
+	const radius = tempoSampleRate / tempoHop / 4
+	out := make([]float64, len(env))
+	for i := range env {
+		lo, hi := max(0, i-radius), min(len(env), i+radius+1)
+		var sum float64
+		for _, v := range env[lo:hi] {
+			sum += v
+		}
+		out[i] = math.Max(0, env[i]-sum/float64(hi-lo))
+	}
+	return out
+}
+
+// tempoPrior — логнормальный вес с центром в tempoPreferred и шириной в октаву
+func tempoPrior(bpm float64) float64 {
+	x := math.Log2(bpm / tempoPreferred)
+	return math.Exp(-0.5 * x * x)
+}
+
+// detectTempo возвращает темп в ударах в минуту, с точностью до 0,5
+func detectTempo(samples []float64) (float64, error) {
+	env := onsetEnvelope(samples)
+	fps := float64(tempoSampleRate) / tempoHop
+	maxLag := int(4*fps*60/tempoMinBPM) + 2
+	if len(env) < 2*maxLag {
+		return 0, fmt.Errorf("запись слишком короткая для определения темпа")
+	}
+	ac := make([]float64, maxLag+1)
+	for lag := range ac {
+		for i := lag; i < len(env); i++ {
+			ac[lag] += env[i] * env[i-lag]
+		}
+	}
+	at := func(lag float64) float64 {
+		i := int(lag)
+		if i+1 >= len(ac) {
+			return 0
+		}
+		frac := lag - float64(i)
+		return ac[i]*(1-frac) + ac[i+1]*frac
+	}
+	best, bestScore := 0.0, 0.0
+	for bpm := tempoMinBPM; bpm <= tempoMaxBPM; bpm += 0.5 {
+		lag := fps * 60 / bpm
+		score := at(lag) + at(2*lag)/2 + at(lag/2)/2 + at(4*lag)/4
+		if score *= tempoPrior(bpm); score > bestScore {
+			best, bestScore = bpm, score
+		}
+	}
+	if bestScore <= 0 {
+		return 0, fmt.Errorf("в записи не найден ритм")
+	}
+	return best, nil
+}
+
+// Профили Крумхансла–Кесслер, от тоники вверх по полутонам
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+func correlation(a, b [12]float64) float64 {
+	var ma, mb float64
+	for i := range a {
+		ma += a[i] / 12
+		mb += b[i] / 12
+	}
+	var num, da, db float64
+	for i := range a {
+		num += (a[i] - ma) * (b[i] - mb)
+		da += (a[i] - ma) * (a[i] - ma)
+		db += (b[i] - mb) * (b[i] - mb)
+	}
+	if da == 0 || db == 0 {
+		return 0
+	}
+	return num / math.Sqrt(da*db)
+}
+
+// detectKey возвращает тональность в нотации Camelot. Хрома берётся та же, что для
+// отпечатков: в ней полутон 0 — ля, поэтому до — полутон 3.
+func detectKey(samples []float64) (string, error) {
+	var profile [12]float64
+	for _, c := range chromagram(samples) {
+		for n, v := range c {
+			profile[(n+9)%12] += v // от ля к до
+		}
+	}
+	best, bestScore := "", -2.0
+	for tonic := 0; tonic < 12; tonic++ {
+		var rotated [12]float64
+		for i := range rotated {
+			rotated[i] = profile[(tonic+i)%12]
+		}
+		if s := correlation(rotated, majorProfile); s > bestScore {
+			best, bestScore = camelotKey(tonic, false), s
+		}
+		if s := correlation(rotated, minorProfile); s > bestScore {
+			best, bestScore = camelotKey(tonic, true), s
+		}
+	}
+	if bestScore <= 0 {
+		return "", fmt.Errorf("не удалось определить тональность")
+	}
+	return best, nil
+}
+
+// camelotKey — код Camelot тональности с тоникой tonic (0 — до): до мажор — 8B,
+// каждая квинта вверх прибавляет единицу; минор берёт номер параллельного мажора
+func camelotKey(tonic int, minor bool) string {
+	if minor {
+		tonic = (tonic + 3) % 12
+	}
+	n := (tonic*7%12+7)%12 + 1
+	if minor {
+		return fmt.Sprintf("%dA", n)
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+var noteNames = [12]string{"C", "C#", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+
+// camelotKeys — все 24 кода в порядке круга: 1A, 1B, 2A, ...
+func camelotKeys() []string {
+	var keys []string
+	for n := 1; n <= 12; n++ {
+		keys = append(keys, fmt.Sprintf("%dA", n), fmt.Sprintf("%dB", n))
+	}
+	return keys
+}
+
+func parseCamelot(key string) (n int, minor bool, ok bool) {
+	if len(key) < 2 {
+		return 0, false, false
+	}
+	n, err := strconv.Atoi(key[:len(key)-1])
+	letter := strings.ToUpper(key[len(key)-1:])
+	if err != nil || n < 1 || n > 12 || (letter != "A" && letter != "B") {
+		return 0, false, false
+	}
+	return n, letter == "A", true
+}
+
+// keyLabel — "8A (Am)" для показа
+func keyLabel(key string) string {
+	n, minor, ok := parseCamelot(key)
+	if !ok {
+		return key
+	}
+	code := fmt.Sprintf("%dB", n)
+	if minor {
+		code = fmt.Sprintf("%dA", n)
+	}
+	for tonic, name := range noteNames {
+		if camelotKey(tonic, minor) != code {
+			continue
+		}
+		if minor {
+			name += "m"
+		}
+		return fmt.Sprintf("%s (%s)", code, name)
+	}
+	return code
+}
+
+// keyDistance — число шагов по кругу Camelot: 0 — та же тональность, 1 — гармонично совместимые.
+// Неизвестная тональность считается средне совместимой.
+func keyDistance(a, b string) int {
+	na, ma, okA := parseCamelot(a)
+	nb, mb, okB := parseCamelot(b)
+	if !okA || !okB {
+		return 2
+	}
+	d := abs(na - nb)
+	d = min(d, 12-d)
+	if ma != mb {
+		d++
+	}
+	return d
+}
+
+func keysCompatible(a, b string) bool {
+	return keyDistance(a, b) <= 1 && a != "" && b != ""
+}
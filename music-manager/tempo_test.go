@@ -0,0 +1,183 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// clickTrack — щелчки с темпом bpm: затухающая смесь 150 Гц и 2 кГц, как у простой драм-машины
+func clickTrack(bpm, seconds float64) []float64 {
+	out := make([]float64, int(seconds*tempoSampleRate))
+	period := 60 / bpm * tempoSampleRate
+	for beat := 0.0; int(beat) < len(out); beat += period {
+		for i := 0; i < 400 && int(beat)+i < len(out); i++ {
+			t := float64(i) / tempoSampleRate
+			out[int(beat)+i] += math.Exp(-200*t) * (math.Sin(2*math.Pi*2000*t) + math.Sin(2*math.Pi*150*t))
+		}
+	}
+	return out
+}
+
+// chord — синусы нот с MIDI-номерами notes (60 — до первой октавы)
+func chord(seconds float64, notes ...int) []float64 {
+	out := make([]float64, int(seconds*tempoSampleRate))
+	for _, n := range notes {
+		freq := 440 * math.Pow(2, float64(n-69)/12)
+		for i := range out {
+			out[i] += math.Sin(2 * math.Pi * freq * float64(i) / tempoSampleRate)
+		}
+	}
+	return out
+}
+
+// Быстрее ~145 BPM ровный щелчок неотличим от половинного темпа с дроблением доли —
+// detectTempo выбирает половинный, а mixCost считает их совместимыми, поэтому здесь не проверяется
+func TestDetectTempo(t *testing.T) {
+	for _, bpm := range []float64{60, 85, 90, 100, 120, 124, 128, 140} {
+		got, err := detectTempo(clickTrack(bpm, 30))
+		if err != nil {
+			t.Errorf("%g BPM: %v", bpm, err)
+			continue
+		}
+		if math.Abs(got-bpm) > 0.5 {
+			t.Errorf("темп щелчков %g BPM определён как %g", bpm, got)
+		}
+	}
+	if _, err := detectTempo(clickTrack(120, 3)); err == nil {
+		t.Error("темп трёхсекундной записи определён без ошибки")
+	}
+	if _, err := detectTempo(make([]float64, 30*tempoSampleRate)); err == nil {
+		t.Error("темп тишины определён без ошибки")
+	}
+}
+
+func TestDetectKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes []int
+		want  string
+	}{
+		{"до мажор", []int{60, 64, 67}, "8B"},
+		{"ля минор", []int{57, 60, 64}, "8A"},
+		{"соль мажор", []int{55, 59, 62}, "9B"},
+		{"ре минор", []int{62, 65, 69}, "7A"},
+		{"фа-диез мажор", []int{66, 70, 73}, "2B"},
+		{"ми-бемоль минор", []int{63, 66, 70}, "2A"},
+	}
+	for _, tt := range tests {
+		got, err := detectKey(chord(5, tt.notes...))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("%s: тональность %s, want %s", tt.name, keyLabel(got), keyLabel(tt.want))
+		}
+	}
+	if _, err := detectKey(make([]float64, 5*tempoSampleRate)); err == nil {
+		t.Error("тональность тишины определена без ошибки")
+	}
+}
+
+func TestCamelot(t *testing.T) {
+	keys := []struct {
+		tonic int
+		minor bool
+		want  string
+	}{
+		{0, false, "8B"}, // до мажор
+		{9, true, "8A"},  // ля минор — параллельный
+		{7, false, "9B"}, // квинта вверх
+		{5, false, "7B"}, // квинта вниз
+		{6, false, "2B"},
+		{4, true, "9A"},
+	}
+	for _, tt := range keys {
+		if got := camelotKey(tt.tonic, tt.minor); got != tt.want {
+			t.Errorf("camelotKey(%d, %v) = %s, want %s", tt.tonic, tt.minor, got, tt.want)
+		}
+	}
+
+	tests := []struct {
+		a, b       string
+		distance   int
+		compatible bool
+	}{
+		{"8A", "8A", 0, true},
+		{"8A", "8B", 1, true},
+		{"8A", "9A", 1, true},
+		{"8A", "7A", 1, true},
+		{"12B", "1B", 1, true}, // круг замыкается
+		{"8a", "8A", 0, true},
+		{"8A", "9B", 2, false},
+		{"8A", "10A", 2, false},
+		{"2A", "8A", 6, false},
+		{"8A", "", 2, false},
+		{"13A", "8A", 2, false},
+	}
+	for _, tt := range tests {
+		if got := keyDistance(tt.a, tt.b); got != tt.distance {
+			t.Errorf("keyDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.distance)
+		}
+		if got := keysCompatible(tt.a, tt.b); got != tt.compatible {
+			t.Errorf("keysCompatible(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.compatible)
+		}
+	}
+}
+
+func TestMixOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		tracks []Track
+		want   []int
+	}{
+		// От самого медленного к ближайшему по темпу; трек без темпа — в конце
+		{"по темпу", []Track{{ID: 1, BPM: 128}, {ID: 2, BPM: 90}, {ID: 3}, {ID: 4, BPM: 124}, {ID: 5, BPM: 100}}, []int{2, 5, 4, 1, 3}},
+		// 70 и 141 BPM сводятся через двойной темп
+		{"двойной темп", []Track{{ID: 1, BPM: 70}, {ID: 3, BPM: 100}, {ID: 4, BPM: 105}, {ID: 2, BPM: 141}}, []int{1, 2, 3, 4}},
+		// При равном темпе следующим идёт трек в совместимой тональности
+		{"тональность", []Track{{ID: 1, BPM: 120, Key: "8A"}, {ID: 2, BPM: 121, Key: "3B"}, {ID: 3, BPM: 121, Key: "9A"}, {ID: 4, BPM: 122, Key: "3B"}}, []int{1, 3, 2, 4}},
+		{"два трека", []Track{{ID: 1, BPM: 130}, {ID: 2}, {ID: 3, BPM: 95}}, []int{3, 1, 2}},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, tr := range mixOrder(tt.tracks) {
+			got = append(got, tr.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: порядок %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMixedEntries(t *testing.T) {
+	byID := map[int]Track{
+		1: {ID: 1, BPM: 128},
+		2: {ID: 2, BPM: 90},
+		3: {ID: 3},
+		4: {ID: 4, BPM: 124},
+		5: {ID: 5, BPM: 100},
+	}
+	// Позиции с пропусками; трека 6 уже нет в каталоге
+	var entries []EntryRow
+	for i, id := range []int{1, 6, 2, 3, 4, 5} {
+		entries = append(entries, EntryRow{PlaylistID: 7, TrackID: id, AddedBy: id, Position: 10 * (i + 1)})
+	}
+	after, err := mixedEntries(entries, byID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ track, position int }{{2, 10}, {5, 20}, {4, 30}, {1, 40}, {6, 50}, {3, 60}}
+	if len(after) != len(want) {
+		t.Fatalf("вхождений %d, want %d", len(after), len(want))
+	}
+	for i, w := range want {
+		e := after[i]
+		if e.TrackID != w.track || e.Position != w.position || e.AddedBy != w.track || e.PlaylistID != 7 {
+			t.Errorf("вхождение %d = %+v, want трек %d на позиции %d", i, e, w.track, w.position)
+		}
+	}
+
+	if _, err := mixedEntries(entries[1:2], byID); err == nil {
+		t.Error("плейлист без темпа переставлен без ошибки")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Секрет из приложения B к RFC 6238 ("12345678901234567890") в base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Эталонные значения RFC 6238 для SHA1; у нас 6 цифр — последние шесть из восьми
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(rfcTOTPSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("totpCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.code {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	at := time.Unix(1111111111, 0) // шаг 37037037, код 050471
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+		step int64
+	}{
+		{"текущий шаг", "050471", true, 37037037},
+		{"с пробелами", " 050471 ", true, 37037037},
+		{"предыдущий шаг", "081804", true, 37037036},
+		{"чужой код", "005924", false, 0},
+		{"короткий код", "50471", false, 0},
+		{"пустой код", "", false, 0},
+	}
+	for _, tt := range tests {
+		step, ok := verifyTOTP(rfcTOTPSecret, tt.code, at)
+		if ok != tt.ok || step != tt.step {
+			t.Errorf("%s: verifyTOTP(%q) = %d, %v; want %d, %v", tt.name, tt.code, step, ok, tt.step, tt.ok)
+		}
+	}
+}
+
+func TestVerifyTOTPSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	for shift := -3; shift <= 3; shift++ {
+		code, err := totpCode(rfcTOTPSecret, at.Add(time.Duration(shift)*totpPeriod))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok := verifyTOTP(rfcTOTPSecret, code, at)
+		if want := shift >= -totpSkew && shift <= totpSkew; ok != want {
+			t.Errorf("сдвиг %d шагов: принят = %v, ожидалось %v", shift, ok, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// errTOTPRequired — пароль верный, но для входа нужен код второго фактора
+var errTOTPRequired = errors.New("требуется код двухфакторной аутентификации")
+
+const recoveryCodeCount = 10
+
+// checkSecondFactor проверяет TOTP-код или резервный код, если у пользователя включена 2FA
+func checkSecondFactor(userID int, code string) error {
+	secret, enabled, err := repo.GetTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return errTOTPRequired
+	}
+	if step, ok := verifyTOTP(secret, code, nowFunc()); ok {
+		// код годится один раз: шаг должен быть новее последнего принятого
+		fresh, err := repo.UseTOTPStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return fmt.Errorf("этот код уже использован, дождитесь следующего")
+		}
+		return nil
+	}
+	ok, err := repo.UseRecoveryCode(userID, strings.ToLower(code), nowFunc())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("неверный код подтверждения")
+	}
+	return nil
+}
+
+// newRecoveryCodes генерирует резервные коды вида "a1b2-c3d4"
+func newRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		h := hex.EncodeToString(b)
+		codes = append(codes, h[:4]+"-"+h[4:])
+	}
+	return codes, nil
+}
+
+func totpEnabled() bool {
+	_, enabled, err := repo.GetTOTP(currentUser.ID)
+	return err == nil && enabled
+}
+
+// beginTOTPEnrolment готовит новый секрет; 2FA включится только после подтверждения кодом
+func beginTOTPEnrolment() (secret string, qr image.Image, err error) {
+	secret, err = newTOTPSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	q, err := qrcode.New(totpURI(currentUser.Username, secret), qrcode.Medium)
+	if err != nil {
+		return "", nil, err
+	}
+	return secret, q.Image(256), nil
+}
+
+// confirmTOTPEnrolment включает 2FA, если код из приложения совпал; возвращает резервные коды
+func confirmTOTPEnrolment(secret, code string) ([]string, error) {
+	step, ok := verifyTOTP(secret, code, nowFunc())
+	if !ok {
+		return nil, fmt.Errorf("код не совпадает, проверьте время на устройстве")
+	}
+	codes, err := newRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.EnableTOTP(currentUser.ID, secret, step, codes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func disableTOTP(password string) error {
+	if err := repo.CheckPassword(currentUser.ID, password); err != nil {
+		return err
+	}
+	return repo.DisableTOTP(currentUser.ID)
+}
+
+// resetUserTOTP — сброс 2FA администратором, например при потере телефона
+func resetUserTOTP(u User) error {
+	if err := requireAdmin(); err != nil {
+		return err
+	}
+	return repo.DisableTOTP(u.ID)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ACCOUNT TAB
+func createAccountTab(onLogout func()) *container.TabItem {
+	userLabel := widget.NewLabelWithStyle("Вы вошли как: "+currentUser.Username, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+
+	logoutBtn := widget.NewButtonWithIcon("Выйти", theme.LogoutIcon(), func() {
+		logoutUser()
+		onLogout()
+	})
+
+	historyBtn := widget.NewButtonWithIcon("История входов", theme.HistoryIcon(), func() {
+		showLoginHistory()
+	})
+
+	sessionsBtn := widget.NewButtonWithIcon("Сеансы", theme.ComputerIcon(), func() {
+		showSessions(onLogout)
+	})
+
+	// Смена логина
+	usernameEntry := widget.NewEntry()
+	usernameEntry.SetPlaceHolder("Новый логин")
+	changeUsernameBtn := widget.NewButton("Сменить логин", func() {
+		if err := changeUsername(usernameEntry.Text); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		usernameEntry.SetText("")
+		userLabel.SetText("Вы вошли как: " + currentUser.Username)
+		dialog.ShowInformation("Успех", "Логин изменён", mainWindow)
+	})
+
+	// Смена пароля
+	oldPassEntry := widget.NewPasswordEntry()
+	oldPassEntry.SetPlaceHolder("Текущий пароль")
+	newPassEntry := widget.NewPasswordEntry()
+	newPassEntry.SetPlaceHolder("Новый пароль")
+	confirmPassEntry := widget.NewPasswordEntry()
+	confirmPassEntry.SetPlaceHolder("Повторите новый пароль")
+	changePassBtn := widget.NewButton("Сменить пароль", func() {
+		if err := changePassword(oldPassEntry.Text, newPassEntry.Text, confirmPassEntry.Text); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		oldPassEntry.SetText("")
+		newPassEntry.SetText("")
+		confirmPassEntry.SetText("")
+		dialog.ShowInformation("Успех", "Пароль изменён", mainWindow)
+	})
+
+	// Двухфакторная аутентификация
+	twoFAStatus := widget.NewLabel("")
+	twoFAPassEntry := widget.NewPasswordEntry()
+	twoFAPassEntry.SetPlaceHolder("Пароль для отключения 2FA")
+	var enableTwoFABtn, disableTwoFABtn *widget.Button
+	refreshTwoFA := func() {
+		if totpEnabled() {
+			twoFAStatus.SetText("Двухфакторная аутентификация включена")
+			enableTwoFABtn.Hide()
+			twoFAPassEntry.Show()
+			disableTwoFABtn.Show()
+		} else {
+			twoFAStatus.SetText("Двухфакторная аутентификация выключена")
+			enableTwoFABtn.Show()
+			twoFAPassEntry.Hide()
+			disableTwoFABtn.Hide()
+		}
+	}
+	enableTwoFABtn = widget.NewButtonWithIcon("Включить 2FA", theme.ConfirmIcon(), func() {
+		showTOTPEnrolment(refreshTwoFA)
+	})
+	disableTwoFABtn = widget.NewButton("Отключить 2FA", func() {
+		if err := disableTOTP(twoFAPassEntry.Text); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		twoFAPassEntry.SetText("")
+		refreshTwoFA()
+	})
+	refreshTwoFA()
+
+	// Удаление аккаунта
+	deletePassEntry := widget.NewPasswordEntry()
+	deletePassEntry.SetPlaceHolder("Пароль для подтверждения")
+	deleteBtn := widget.NewButtonWithIcon("Удалить аккаунт", theme.DeleteIcon(), func() {
+		confirmDelete("Удаление аккаунта", "Аккаунт и все ваши плейлисты будут удалены без возможности восстановления. Продолжить?", func() {
+			if err := deleteAccount(deletePassEntry.Text); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			onLogout()
+		})
+	})
+	deleteBtn.Importance = widget.DangerImportance
+
+	return container.NewTabItemWithIcon("Аккаунт", theme.AccountIcon(), container.NewVScroll(container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(historyBtn, sessionsBtn, logoutBtn), userLabel),
+		widget.NewSeparator(),
+		widget.NewLabel("Логин:"),
+		container.NewBorder(nil, nil, nil, changeUsernameBtn, usernameEntry),
+		widget.NewSeparator(),
+		widget.NewLabel("Пароль:"),
+		oldPassEntry,
+		newPassEntry,
+		confirmPassEntry,
+		changePassBtn,
+		widget.NewSeparator(),
+		twoFAStatus,
+		enableTwoFABtn,
+		container.NewBorder(nil, nil, nil, disableTwoFABtn, twoFAPassEntry),
+		widget.NewSeparator(),
+		widget.NewLabel("Опасная зона:"),
+		container.NewBorder(nil, nil, nil, deleteBtn, deletePassEntry),
+	)))
+}
+
+// Последние попытки входа в аккаунт
+func showLoginHistory() {
+	attempts, err := repo.GetLoginAttempts(currentUser.Username, 50)
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	list := widget.NewList(
+		func() int { return len(attempts) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			a := attempts[i]
+			status := "успешно"
+			if !a.Success {
+				status = "отказ: " + a.Reason
+			}
+			o.(*widget.Label).SetText(fmt.Sprintf("%s  %s  (%s)", a.AttemptedAt.Format("02.01.2006 15:04:05"), status, a.Source))
+		},
+	)
+	d := dialog.NewCustom("История входов", "Закрыть", list, mainWindow)
+	d.Resize(fyne.NewSize(500, 400))
+	d.Show()
+}
+
+// Активные сеансы "запомнить меня" с возможностью отзыва
+func showSessions(onLogout func()) {
+	var sessions []Session
+	var list *widget.List
+	var d dialog.Dialog
+
+	refresh := func() {
+		var err error
+		sessions, err = getSessions()
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int { return len(sessions) },
+		func() fyne.CanvasObject {
+			btn := widget.NewButtonWithIcon("Отозвать", theme.CancelIcon(), nil)
+			btn.Importance = widget.LowImportance
+			return container.NewHBox(widget.NewLabel(""), layout.NewSpacer(), btn)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(sessions) {
+				return
+			}
+			s := sessions[i]
+			text := fmt.Sprintf("%s — вход %s, активность %s", s.Device,
+				s.CreatedAt.Local().Format("02.01.2006 15:04"), s.LastUsedAt.Local().Format("02.01.2006 15:04"))
+			if s.ID == currentSessionID {
+				text += " (этот сеанс)"
+			}
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(text)
+			row.Objects[2].(*widget.Button).OnTapped = func() {
+				current := s.ID == currentSessionID
+				if err := revokeSession(s.ID); err != nil {
+					dialog.ShowError(err, mainWindow)
+					return
+				}
+				if current {
+					d.Hide()
+					logoutUser()
+					onLogout()
+					return
+				}
+				refresh()
+			}
+		},
+	)
+	refresh()
+
+	d = dialog.NewCustom("Активные сеансы", "Закрыть", list, mainWindow)
+	d.Resize(fyne.NewSize(600, 400))
+	d.Show()
+}
+
+// Подключение приложения-аутентификатора: QR-код, проверка первого кода, показ резервных кодов
+func showTOTPEnrolment(onDone func()) {
+	secret, qr, err := beginTOTPEnrolment()
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	qrImage := canvas.NewImageFromImage(qr)
+	qrImage.FillMode = canvas.ImageFillContain
+	qrImage.SetMinSize(fyne.NewSize(256, 256))
+
+	secretEntry := widget.NewEntry()
+	secretEntry.SetText(secret)
+	secretEntry.Disable()
+
+	codeEntry := widget.NewEntry()
+	codeEntry.SetPlaceHolder("Код из приложения")
+
+	content := container.NewVBox(
+		widget.NewLabel("Отсканируйте QR-код приложением-аутентификатором или введите ключ вручную:"),
+		container.NewCenter(qrImage),
+		secretEntry,
+		codeEntry,
+	)
+	dialog.ShowCustomConfirm("Включение 2FA", "Подтвердить", "Отмена", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		codes, err := confirmTOTPEnrolment(secret, codeEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		onDone()
+		showRecoveryCodes(codes)
+	}, mainWindow)
+}
+
+// Резервные коды показываются один раз — в базе хранятся только их хеши
+func showRecoveryCodes(codes []string) {
+	codesEntry := widget.NewMultiLineEntry()
+	codesEntry.SetText(strings.Join(codes, "\n"))
+	codesEntry.SetMinRowsVisible(len(codes))
+	dialog.ShowCustom("Резервные коды", "Я сохранил коды", container.NewVBox(
+		widget.NewLabel("Сохраните коды: каждый можно использовать один раз вместо кода из приложения."),
+		codesEntry,
+	), mainWindow)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var roleOptions = []string{RoleAdmin, RoleEditor, RoleListener}
+
+// ADMIN TAB
+func createAdminTab() *container.TabItem {
+	var users []User
+	var list *widget.List
+
+	refresh := func() {
+		var err error
+		users, err = getUsers()
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int { return len(users) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			roleSelect := widget.NewSelect(roleOptions, nil)
+			resetTwoFABtn := widget.NewButton("Сбросить 2FA", nil)
+			resetTwoFABtn.Importance = widget.LowImportance
+			deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			deleteBtn.Importance = widget.LowImportance
+			return container.NewHBox(label, layout.NewSpacer(), roleSelect, resetTwoFABtn, deleteBtn)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(users) {
+				return
+			}
+			u := users[i]
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(u.Username)
+
+			roleSelect := row.Objects[2].(*widget.Select)
+			roleSelect.OnChanged = nil
+			roleSelect.SetSelected(u.Role)
+			roleSelect.OnChanged = func(role string) {
+				if role == u.Role {
+					return
+				}
+				if err := setUserRole(u, role); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				refresh()
+			}
+
+			row.Objects[3].(*widget.Button).OnTapped = func() {
+				dialog.ShowConfirm("Сброс 2FA", "Отключить двухфакторную аутентификацию для "+u.Username+"?", func(ok bool) {
+					if !ok {
+						return
+					}
+					if err := resetUserTOTP(u); err != nil {
+						dialog.ShowError(err, mainWindow)
+					}
+				}, mainWindow)
+			}
+
+			row.Objects[4].(*widget.Button).OnTapped = func() {
+				confirmDelete("Удаление", "Удалить пользователя "+u.Username+" и все его плейлисты?", func() {
+					if err := removeUser(u); err != nil {
+						dialog.ShowError(err, mainWindow)
+					}
+					refresh()
+				})
+			}
+		},
+	)
+
+	refresh()
+
+	return container.NewTabItemWithIcon("Пользователи", theme.SettingsIcon(), container.NewBorder(
+		container.NewVBox(
+			widget.NewLabelWithStyle("Управление пользователями", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel("admin — всё, editor — каталог, listener — только свои плейлисты"),
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		list,
+	))
+}
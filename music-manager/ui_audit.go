@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// AUDIT TAB — журнал изменений для администраторов
+func createAuditTab() *container.TabItem {
+	var entries []AuditEntry
+	var list *widget.List
+
+	userEntry := widget.NewEntry()
+	userEntry.SetPlaceHolder("Пользователь")
+	entityIDEntry := widget.NewEntry()
+	entityIDEntry.SetPlaceHolder("№ записи")
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("С (ГГГГ-ММ-ДД)")
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("По (ГГГГ-ММ-ДД)")
+
+	const allOption = "все"
+	operationSelect := widget.NewSelect(append([]string{allOption}, AuditOperations...), nil)
+	operationSelect.SetSelected(allOption)
+	entityOptions := []string{allOption}
+	for _, t := range AuditEntityTypes {
+		entityOptions = append(entityOptions, auditEntityLabel(t))
+	}
+	entitySelect := widget.NewSelect(entityOptions, nil)
+	entitySelect.SetSelected(allOption)
+
+	countLabel := widget.NewLabel("")
+
+	filter := func(limit int) (AuditFilter, error) {
+		op, entity := operationSelect.Selected, ""
+		if op == allOption {
+			op = ""
+		}
+		if i := entitySelect.SelectedIndex(); i > 0 {
+			entity = AuditEntityTypes[i-1]
+		}
+		f, err := auditFilter(userEntry.Text, op, entity, entityIDEntry.Text, fromEntry.Text, toEntry.Text)
+		f.Limit = limit
+		return f, err
+	}
+
+	refresh := func() {
+		f, err := filter(auditViewLimit)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		entries, err = getAuditLog(f)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		countLabel.SetText(fmt.Sprintf("Показано записей: %d (не больше %d)", len(entries), auditViewLimit))
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < len(entries) {
+				o.(*widget.Label).SetText(auditLabel(entries[i]))
+			}
+		},
+	)
+	// По выбору записи показываем состояние до и после изменения
+	list.OnSelected = func(i widget.ListItemID) {
+		list.Unselect(i)
+		if i >= len(entries) {
+			return
+		}
+		showAuditEntry(entries[i])
+	}
+
+	applyBtn := widget.NewButtonWithIcon("Найти", theme.SearchIcon(), refresh)
+
+	exportBtn := widget.NewButtonWithIcon("Экспорт CSV", theme.DownloadIcon(), func() {
+		f, err := filter(auditExportLimit)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil || w == nil {
+				return
+			}
+			defer w.Close()
+			items, err := getAuditLog(f)
+			if err == nil {
+				err = exportAuditCSV(w, items)
+			}
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+		}, mainWindow)
+		save.SetFileName("audit-" + nowFunc().Format(auditDateLayout) + ".csv")
+		save.Show()
+	})
+
+	purgeBtn := widget.NewButtonWithIcon("Очистить старые", theme.DeleteIcon(), func() {
+		daysEntry := widget.NewEntry()
+		daysEntry.SetText(strconv.Itoa(auditRetentionDays))
+		dialog.ShowForm("Очистка журнала", "Удалить", "Отмена",
+			[]*widget.FormItem{widget.NewFormItem("Старше, дней", daysEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				days, _ := strconv.Atoi(daysEntry.Text)
+				n, err := purgeAuditOlderThan(days)
+				if err != nil {
+					dialog.ShowError(err, mainWindow)
+					return
+				}
+				dialog.ShowInformation("Готово", fmt.Sprintf("Удалено записей: %d", n), mainWindow)
+				refresh()
+			}, mainWindow)
+	})
+	purgeBtn.Importance = widget.DangerImportance
+
+	refresh()
+
+	filters := container.NewGridWithColumns(3,
+		userEntry, operationSelect, entitySelect,
+		entityIDEntry, fromEntry, toEntry,
+	)
+	return container.NewTabItemWithIcon("Журнал", theme.HistoryIcon(), container.NewBorder(
+		container.NewVBox(
+			widget.NewLabelWithStyle("Журнал изменений", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			filters,
+			container.NewHBox(applyBtn, exportBtn, purgeBtn),
+			widget.NewSeparator(),
+		),
+		countLabel, nil, nil,
+		list,
+	))
+}
+
+func showAuditEntry(e AuditEntry) {
+	jsonLabel := func(s string) fyne.CanvasObject {
+		if s == "" {
+			s = "—"
+		}
+		l := widget.NewLabel(s)
+		l.Wrapping = fyne.TextWrapWord
+		return l
+	}
+	content := container.NewVBox(
+		widget.NewLabel(auditLabel(e)),
+		widget.NewLabelWithStyle("До:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		jsonLabel(e.Before),
+		widget.NewLabelWithStyle("После:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		jsonLabel(e.After),
+	)
+	scroll := container.NewVScroll(content)
+	scroll.SetMinSize(fyne.NewSize(600, 400))
+	dialog.ShowCustom("Запись журнала", "Закрыть", scroll, mainWindow)
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"time"
 )
 
 // Регистрация пользователя
@@ -10,22 +12,127 @@ func registerUser(u, p string) error {
 	if u == "" || p == "" {
 		return fmt.Errorf("логин и пароль не могут быть пустыми")
 	}
+	if err := validateUsername(u); err != nil {
+		return err
+	}
+	if err := validatePassword(p); err != nil {
+		return err
+	}
 
 	// Вызываем метод репозитория.
 	// Репозиторий сам захеширует пароль и выполнит INSERT.
 	return repo.RegisterUser(u, p)
 }
 
-// Вход пользователя
-func loginUser(u, p string) error {
+// Вход пользователя; code — TOTP или резервный код (нужен, если включена 2FA),
+// при remember сеанс сохраняется для автоматического входа
+func loginUser(u, p, code string, remember bool) error {
+	source := loginSource()
+	userKey, sourceKey := userThrottleKey(u), sourceThrottleKey(source)
+	attempt := LoginAttempt{Username: u, Source: source, AttemptedAt: nowFunc()}
+
+	// Сначала проверяем, не заблокирован ли вход для аккаунта или источника
+	lockedUntil, err := repo.GetLockedUntil(userKey, sourceKey)
+	if err != nil {
+		return err
+	}
+	if wait := lockedUntil.Sub(attempt.AttemptedAt); wait > 0 {
+		attempt.Reason = "заблокирован"
+		repo.RecordLoginAttempt(attempt)
+		return fmt.Errorf("слишком много неудачных попыток, повторите через %s", wait.Round(time.Second))
+	}
+
 	// Вызываем метод репозитория.
 	// Он проверит существование пользователя и совпадение хеша пароля.
 	user, err := repo.LoginUser(u, p)
 	if err != nil {
+		attempt.Reason = "неверный логин или пароль"
+		repo.RecordLoginAttempt(attempt)
+		registerLoginFailure(userKey, sourceKey)
 		return err
 	}
 
-	// Если ошибок нет, сохраняем пользователя в глобальную переменную
+	// Второй фактор; отсутствие кода не считается неудачной попыткой
+	if err := checkSecondFactor(user.ID, code); err != nil {
+		if errors.Is(err, errTOTPRequired) {
+			return err
+		}
+		attempt.Reason = "неверный код 2FA"
+		repo.RecordLoginAttempt(attempt)
+		registerLoginFailure(userKey, sourceKey)
+		return err
+	}
+
+	attempt.Success = true
+	repo.RecordLoginAttempt(attempt)
+	repo.ResetLoginFailures(userKey)
+	repo.ResetLoginFailures(sourceKey)
+
+	sessionID := 0
+	if remember {
+		if sessionID, err = rememberSession(user.ID); err != nil {
+			return err
+		}
+	}
+
+	// Пользователь считается вошедшим, только когда все шаги прошли успешно
 	currentUser = user
+	currentSessionID = sessionID
+	repo.SetActor(user.ID, user.Username)
+	return nil
+}
+
+// Выход из аккаунта
+func logoutUser() {
+	stopHistory()
+	forgetSession()
+	playlistLinks = map[int]string{}
+	currentUser = nil
+	repo.SetActor(0, "")
+}
+
+// Смена пароля с проверкой старого
+func changePassword(oldPass, newPass, confirm string) error {
+	if newPass == "" {
+		return fmt.Errorf("новый пароль не может быть пустым")
+	}
+	if newPass != confirm {
+		return fmt.Errorf("пароли не совпадают")
+	}
+	if err := validatePassword(newPass); err != nil {
+		return err
+	}
+	if err := repo.ChangePassword(currentUser.ID, oldPass, newPass); err != nil {
+		return err
+	}
+	// После смены пароля остальные устройства должны войти заново
+	return repo.RevokeOtherSessions(currentUser.ID, currentSessionID)
+}
+
+// Смена логина
+func changeUsername(username string) error {
+	if username == "" {
+		return fmt.Errorf("логин не может быть пустым")
+	}
+	if err := validateUsername(username); err != nil {
+		return err
+	}
+	if err := repo.ChangeUsername(currentUser.ID, username); err != nil {
+		return err
+	}
+	currentUser.Username = username
+	repo.SetActor(currentUser.ID, username)
+	return nil
+}
+
+// Удаление аккаунта вместе с плейлистами
+func deleteAccount(password string) error {
+	if err := ensureNotLastAdmin(*currentUser); err != nil {
+		return err
+	}
+	if err := repo.DeleteUser(currentUser.ID, password); err != nil {
+		return err
+	}
+	logoutUser()
 	return nil
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// mainMenu — меню окна: правка для всех, резервные копии для администраторов
+func mainMenu() *fyne.MainMenu {
+	edit := fyne.NewMenu("Правка",
+		fyne.NewMenuItem("Отменить", undoAction),
+		fyne.NewMenuItem("Повторить", redoAction),
+	)
+	if !isAdmin() {
+		return fyne.NewMainMenu(edit)
+	}
+	file := fyne.NewMenu("Файл",
+		fyne.NewMenuItem("Резервная копия...", showBackupDialog),
+		fyne.NewMenuItem("Восстановить из копии...", showRestoreDialog),
+	)
+	return fyne.NewMainMenu(file, edit)
+}
+
+func showBackupDialog() {
+	withHashes := widget.NewCheck("Сохранить хеши паролей", nil)
+	hint := widget.NewLabel("Без хешей пользователи при восстановлении получат временные пароли.")
+	hint.Wrapping = fyne.TextWrapWord
+	dialog.ShowCustomConfirm("Резервная копия", "Сохранить...", "Отмена",
+		container.NewVBox(withHashes, hint),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+				if err != nil || w == nil {
+					return
+				}
+				defer w.Close()
+				if err := exportBackup(w, withHashes.Checked); err != nil {
+					dialog.ShowError(err, mainWindow)
+					return
+				}
+				dialog.ShowInformation("Готово", "Резервная копия сохранена", mainWindow)
+			}, mainWindow)
+			save.SetFileName("music-backup-" + nowFunc().Format("2006-01-02") + ".json")
+			save.Show()
+		}, mainWindow)
+}
+
+func showRestoreDialog() {
+	modeLabels := map[string]string{
+		"Дополнить (merge)":  RestoreMerge,
+		"Заменить (replace)": RestoreReplace,
+	}
+	mode := widget.NewRadioGroup([]string{"Дополнить (merge)", "Заменить (replace)"}, nil)
+	mode.SetSelected("Дополнить (merge)")
+	hint := widget.NewLabel("Дополнение добавляет недостающие записи. Замена удаляет весь каталог и плейлисты " +
+		"перед восстановлением. Учётные записи не удаляются ни в одном режиме.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	restore := func() {
+		open := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+			if err != nil || r == nil {
+				return
+			}
+			defer r.Close()
+			rep, err := importBackup(r, modeLabels[mode.Selected])
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			report := widget.NewLabel(describeRestore(rep))
+			report.Wrapping = fyne.TextWrapWord
+			scroll := container.NewVScroll(report)
+			scroll.SetMinSize(fyne.NewSize(500, 250))
+			dialog.ShowCustom("Восстановление завершено", "Закрыть", scroll, mainWindow)
+		}, mainWindow)
+		open.Show()
+	}
+
+	dialog.ShowCustomConfirm("Восстановление", "Выбрать файл...", "Отмена",
+		container.NewVBox(mode, hint),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if modeLabels[mode.Selected] == RestoreReplace {
+				confirmDelete("Замена данных", "Текущий каталог и все плейлисты будут удалены и заменены содержимым архива. Продолжить?", restore)
+				return
+			}
+			restore()
+		}, mainWindow)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var browseSortLabels = []string{"По подписчикам", "По названию", "По числу треков"}
+var browseSortKeys = []string{SortByFollowers, SortByTitle, SortByTracks}
+
+// BROWSE TAB — обзор публичных плейлистов других пользователей
+func createBrowseTab() *container.TabItem {
+	var items []PublicPlaylist
+	var list *widget.List
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Поиск по названию или автору...")
+	sortSelect := widget.NewSelect(browseSortLabels, nil)
+	sortSelect.SetSelectedIndex(0)
+	onlyFollowed := widget.NewCheck("Только от моих подписок", nil)
+
+	refresh := func() {
+		var err error
+		items, err = getPublicPlaylists(search.Text, browseSortKeys[sortSelect.SelectedIndex()], onlyFollowed.Checked)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			followBtn := widget.NewButton("", nil)
+			followBtn.Importance = widget.LowImportance
+			openBtn := widget.NewButtonWithIcon("", theme.VisibilityIcon(), nil)
+			openBtn.Importance = widget.LowImportance
+			return container.NewHBox(widget.NewLabel(""), layout.NewSpacer(), followBtn, openBtn)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(items) {
+				return
+			}
+			p := items[i]
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s — %s · подписчиков: %d · треков: %d",
+				p.Title, p.OwnerName, p.Followers, p.TrackCount))
+			followBtn := row.Objects[2].(*widget.Button)
+			followBtn.SetText(followLabel(p.Following))
+			followBtn.OnTapped = func() {
+				if err := setFollowPlaylist(p.ID, !p.Following); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				refresh()
+			}
+			row.Objects[3].(*widget.Button).OnTapped = func() {
+				showPlaylistView(p.ID, refresh)
+			}
+		},
+	)
+
+	// Плейлисты "по ссылке" не видны в обзоре, их открывают по коду, который раздаёт владелец
+	openLinkEntry := widget.NewEntry()
+	openLinkEntry.SetPlaceHolder("Код ссылки на плейлист")
+	openLinkBtn := widget.NewButton("Открыть", func() {
+		id, err := openPlaylistLink(openLinkEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		showPlaylistView(id, refresh)
+	})
+
+	search.OnChanged = func(string) { refresh() }
+	sortSelect.OnChanged = func(string) { refresh() }
+	onlyFollowed.OnChanged = func(bool) { refresh() }
+
+	onDataChanged(refresh)
+	refresh()
+
+	return container.NewTabItemWithIcon("Обзор", theme.SearchIcon(), container.NewBorder(
+		container.NewVBox(
+			widget.NewLabelWithStyle("Публичные плейлисты", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			search,
+			container.NewHBox(sortSelect, onlyFollowed),
+			container.NewBorder(nil, nil, nil, openLinkBtn, openLinkEntry),
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		list,
+	))
+}
+
+func followLabel(following bool) string {
+	if following {
+		return "Отписаться"
+	}
+	return "Подписаться"
+}
+
+// Просмотр чужого плейлиста только для чтения
+func showPlaylistView(pID int, onChange func()) {
+	p, err := openVisiblePlaylist(pID)
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	entries, names := getPlaylistEntries(p.ID)
+
+	tracks := widget.NewList(
+		func() int { return len(names) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(names[i]) },
+	)
+	// Клик по треку открывает его карточку
+	tracks.OnSelected = func(i widget.ListItemID) {
+		tracks.Unselect(i)
+		showTrackPage(entries[i].ID)
+	}
+
+	var followBtn, followOwnerBtn *widget.Button
+	followBtn = widget.NewButton(followLabel(p.Following), func() {
+		if err := setFollowPlaylist(p.ID, !p.Following); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		p.Following = !p.Following
+		followBtn.SetText(followLabel(p.Following))
+		onChange()
+	})
+	followOwnerBtn = widget.NewButton(followLabel(p.FollowingOwner)+" на "+p.OwnerName, func() {
+		if err := setFollowUser(p.OwnerID, !p.FollowingOwner); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		p.FollowingOwner = !p.FollowingOwner
+		followOwnerBtn.SetText(followLabel(p.FollowingOwner) + " на " + p.OwnerName)
+		onChange()
+	})
+	copyBtn := widget.NewButtonWithIcon("Копировать в мои плейлисты", theme.ContentCopyIcon(), func() {
+		title, err := copyPlaylistToMine(p.Playlist)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		dialog.ShowInformation("Готово", "Создан плейлист «"+title+"»", mainWindow)
+	})
+	if p.OwnerID == currentUser.ID {
+		followBtn.Disable()
+		followOwnerBtn.Disable()
+	}
+
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("Автор: %s · %s · подписчиков: %d", p.OwnerName, visibilityLabels[p.Visibility], p.Followers)),
+			container.NewHBox(followBtn, followOwnerBtn, copyBtn),
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		tracks,
+	)
+	d := dialog.NewCustom(p.Title, "Закрыть", content, mainWindow)
+	d.Resize(fyne.NewSize(600, 450))
+	d.Show()
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// selectableRow — строка списка: флажок выбора, название, кнопка удаления
+// и дополнительные кнопки с иконками extra (Objects[4], Objects[5], ...)
+func selectableRow(canDelete bool, extra ...fyne.Resource) fyne.CanvasObject {
+	label := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+	deleteBtn.Importance = widget.LowImportance
+	if !canDelete {
+		deleteBtn.Hide()
+	}
+	row := container.NewHBox(widget.NewCheck("", nil), label, layout.NewSpacer(), deleteBtn)
+	for _, icon := range extra {
+		btn := widget.NewButtonWithIcon("", icon, nil)
+		btn.Importance = widget.LowImportance
+		row.Add(btn)
+	}
+	return row
+}
+
+// updateSelectableRow заполняет строку, созданную selectableRow
+func updateSelectableRow(o fyne.CanvasObject, text string, id int, sel *multiSelection, onDelete func()) {
+	row := o.(*fyne.Container)
+	check := row.Objects[0].(*widget.Check)
+	check.OnChanged = nil
+	check.SetChecked(sel.isSelected(id))
+	check.OnChanged = func(on bool) { sel.toggle(id, on) }
+	row.Objects[1].(*widget.Label).SetText(text)
+	row.Objects[3].(*widget.Button).OnTapped = onDelete
+}
+
+// bindMultiSelect подключает к списку выбор кликом с Ctrl/Shift
+func bindMultiSelect(list *widget.List, sel *multiSelection, idAt func(int) int) {
+	list.OnSelected = func(i widget.ListItemID) {
+		sel.click(i, idAt)
+		// подсветку заменяют флажки, поэтому стандартное выделение снимаем
+		list.Unselect(i)
+	}
+}
+
+// bulkToolbar — счётчик выбранного, "выделить всё", "снять выделение" и массовые действия
+func bulkToolbar(sel *multiSelection, visibleIDs func() []int, actions ...fyne.CanvasObject) (fyne.CanvasObject, func()) {
+	countLabel := widget.NewLabel("")
+	selectAllBtn := widget.NewButtonWithIcon("", theme.CheckButtonCheckedIcon(), func() { sel.selectAll(visibleIDs()) })
+	clearBtn := widget.NewButtonWithIcon("", theme.CheckButtonIcon(), func() { sel.clear() })
+	update := func() {
+		countLabel.SetText(fmt.Sprintf("Выбрано: %d", sel.count()))
+		for _, a := range actions {
+			if d, ok := a.(fyne.Disableable); ok {
+				setEnabled(d, sel.count() > 0)
+			}
+		}
+	}
+	update()
+	objects := append([]fyne.CanvasObject{selectAllBtn, clearBtn, countLabel, layout.NewSpacer()}, actions...)
+	return container.NewHBox(objects...), update
+}
+
+// confirmCatalogDelete показывает, что затронет удаление, и удаляет после одного подтверждения
+func confirmCatalogDelete(kind string, ids []int, onDone func()) {
+	if len(ids) == 0 {
+		return
+	}
+	impact, err := catalogDeleteImpact(kind, ids)
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+
+	summary := widget.NewLabel(describeDeleteImpact(kind, len(ids), impact))
+	summary.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(summary)
+	if len(impact.AffectedPlaylists) > 0 {
+		affected := widget.NewList(
+			func() int { return len(impact.AffectedPlaylists) },
+			func() fyne.CanvasObject { return widget.NewLabel("") },
+			func(i widget.ListItemID, o fyne.CanvasObject) {
+				a := impact.AffectedPlaylists[i]
+				o.(*widget.Label).SetText(fmt.Sprintf("%s — %s: %d", a.Owner, a.Title, a.Entries))
+			},
+		)
+		scroll := container.NewVScroll(affected)
+		scroll.SetMinSize(fyne.NewSize(400, 150))
+		content.Add(widget.NewLabel("Затронутые плейлисты:"))
+		content.Add(scroll)
+	}
+
+	dialog.ShowCustomConfirm("Удаление", "Удалить", "Отмена", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := bulkDelete(kind, ids); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		onDone()
+	}, mainWindow)
+}
+
+// Массовое добавление треков в плейлист, куда у пользователя есть право добавлять
+func showAddToPlaylistDialog(trackIDs []int) {
+	owned, _ := getPlaylists()
+	var targets []Playlist
+	var names []string
+	for _, p := range append(owned, getSharedPlaylists()...) {
+		if canPlaylist(p.Permission, PermAdd) {
+			targets = append(targets, p)
+			names = append(names, playlistLabel(p))
+		}
+	}
+	target := widget.NewSelect(names, nil)
+	dialog.ShowForm(fmt.Sprintf("Добавить треков: %d", len(trackIDs)), "Добавить", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("Плейлист", target)},
+		func(ok bool) {
+			if !ok || target.SelectedIndex() < 0 {
+				return
+			}
+			p := targets[target.SelectedIndex()]
+			addTracksToPlaylist(&p, trackIDs, func(added, skipped int, _ bool) {
+				dialog.ShowInformation("Готово", describeBulkAdd(len(trackIDs), added, skipped), mainWindow)
+			})
+		}, mainWindow)
+}
+
+// addTracksToPlaylist добавляет треки в плейлист: отпечатки сравниваются в фоне,
+// сама запись и onDone — в потоке интерфейса
+func addTracksToPlaylist(p *Playlist, trackIDs []int, onDone func(added, skipped int, changedByOthers bool)) {
+	progress := dialog.NewCustomWithoutButtons("Проверка звучания", widget.NewProgressBarInfinite(), mainWindow)
+	progress.Show()
+	go func() {
+		plan, err := planBulkAdd(p.ID, trackIDs)
+		fyne.Do(func() {
+			progress.Hide()
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			added, changedByOthers, err := bulkAddToPlaylist(p, plan)
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			onDone(added, plan.Skipped, changedByOthers)
+		})
+	}()
+}
+
+func describeBulkAdd(selected, added, skipped int) string {
+	s := fmt.Sprintf("Добавлено треков: %d из %d.", added, selected)
+	if skipped > 0 {
+		s += fmt.Sprintf("\nПропущено как совпадающие по звучанию: %d.", skipped)
+	}
+	if present := selected - added - skipped; present > 0 {
+		s += fmt.Sprintf("\nУже были в плейлисте: %d.", present)
+	}
+	return s
+}
+
+// Массовое назначение жанра и тегов
+func showTagTracksDialog(trackIDs []int, onDone func()) {
+	setGenre := widget.NewCheck("Заменить жанр", nil)
+	genreEntry := widget.NewEntry()
+	genreEntry.SetPlaceHolder("Жанр (пусто — убрать)")
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetPlaceHolder("теги через запятую")
+	dialog.ShowForm(fmt.Sprintf("Жанр и теги: треков %d", len(trackIDs)), "Применить", "Отмена",
+		[]*widget.FormItem{
+			widget.NewFormItem("", setGenre),
+			widget.NewFormItem("Жанр", genreEntry),
+			widget.NewFormItem("Добавить теги", tagsEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := bulkTagTracks(trackIDs, setGenre.Checked, genreEntry.Text, tagsEntry.Text); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+			onDone()
+		}, mainWindow)
+}
+
+// Массовый перенос треков в другой альбом
+func showMoveTracksDialog(trackIDs []int, onDone func()) {
+	albums, names := getAlbums()
+	target := widget.NewSelect(names, nil)
+	dialog.ShowForm(fmt.Sprintf("Перенести треков: %d", len(trackIDs)), "Перенести", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("В альбом", target)},
+		func(ok bool) {
+			if !ok || target.SelectedIndex() < 0 {
+				return
+			}
+			if err := bulkMoveTracks(trackIDs, albums[target.SelectedIndex()].ID); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+			onDone()
+		}, mainWindow)
+}
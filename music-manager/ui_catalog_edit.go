@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strconv"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Редактирование записей каталога; каждое изменение можно отменить
+
+func showEditArtistDialog(a Artist, onDone func()) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(a.Name)
+	dialog.ShowForm("Изменить артиста", "Сохранить", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("Имя", nameEntry)},
+		func(ok bool) {
+			if !ok || nameEntry.Text == a.Name {
+				return
+			}
+			if err := editArtist(a, nameEntry.Text); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			onDone()
+		}, mainWindow)
+}
+
+func showEditAlbumDialog(a Album, onDone func()) {
+	titleEntry := widget.NewEntry()
+	titleEntry.SetText(a.Title)
+	yearEntry := widget.NewEntry()
+	if a.Year != 0 {
+		yearEntry.SetText(strconv.Itoa(a.Year))
+	}
+	dialog.ShowForm("Изменить альбом", "Сохранить", "Отмена",
+		[]*widget.FormItem{
+			widget.NewFormItem("Название", titleEntry),
+			widget.NewFormItem("Год", yearEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			year, err := parseYear(yearEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			if titleEntry.Text == a.Title && year == a.Year {
+				return
+			}
+			if err := editAlbum(a, titleEntry.Text, year); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			onDone()
+		}, mainWindow)
+}
+
+func showEditTrackDialog(t Track, onDone func()) {
+	titleEntry := widget.NewEntry()
+	titleEntry.SetText(t.Title)
+	durationEntry := widget.NewEntry()
+	durationEntry.SetText(formatDuration(t.Duration))
+	dialog.ShowForm("Изменить трек", "Сохранить", "Отмена",
+		[]*widget.FormItem{
+			widget.NewFormItem("Название", titleEntry),
+			widget.NewFormItem("Длительность", durationEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			dur, err := parseDuration(durationEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			if titleEntry.Text == t.Title && dur == t.Duration {
+				return
+			}
+			if err := editTrack(t, titleEntry.Text, dur); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			onDone()
+		}, mainWindow)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 
@@ -14,106 +15,384 @@ import (
 
 // PLAYLIST TAB
 func createPlaylistTab() *container.TabItem {
-	var playlists []Playlist
-	var playlistNames []string
+	var tree *playlistTree
 	var allTracksCached []Track
 	var filteredTracks []Track
 	var filteredTrackNames []string
-	var playlistTracks []Track
+	var playlistTracks []PlaylistEntry
+	var playlistTrackNames []string
 
 	var selectedPlaylist *Playlist
+	var selectedFolderID int // папка, выбранная в дереве (0 — ни одна)
 	var selectedTrack *Track
 
 	var list *widget.List
 	var trackSelect *widget.Select
-	var playlistSelect *widget.Select
+	var playlistTreeView *widget.Tree
+	var deletePlaylistBtn, collaboratorsBtn, linkBtn, addTrackBtn, movePlaylistBtn, mixOrderBtn, suggestBtn *widget.Button
+	var renameFolderBtn, deleteFolderBtn, playAllBtn, exportFolderBtn *widget.Button
+
+	currentPlaylistLabel := widget.NewLabelWithStyle("Плейлист не выбран", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	summaryLabel := widget.NewLabel("")
+	summaryLabel.Wrapping = fyne.TextWrapWord
 
 	searchTrack := widget.NewEntry()
 	searchTrack.SetPlaceHolder("Поиск трека для добавления...")
 
+	// Подбор для сведения: диапазон темпа, совместимая тональность и сортировка
+	minBPMEntry := widget.NewEntry()
+	minBPMEntry.SetPlaceHolder("BPM от")
+	maxBPMEntry := widget.NewEntry()
+	maxBPMEntry.SetPlaceHolder("BPM до")
+	const anyKey = "Любая тональность"
+	keySelect := widget.NewSelect([]string{anyKey}, nil)
+	for _, k := range camelotKeys() {
+		keySelect.Options = append(keySelect.Options, keyLabel(k))
+	}
+	keySelect.SetSelected(anyKey)
+	sortSelect := widget.NewSelect(nil, nil)
+	for _, o := range trackSortOptions {
+		sortSelect.Options = append(sortSelect.Options, trackSortLabels[o])
+	}
+	sortSelect.SetSelectedIndex(0)
+
+	visibilitySelect := widget.NewSelect(nil, nil)
+	for _, v := range visibilityOptions {
+		visibilitySelect.Options = append(visibilitySelect.Options, visibilityLabels[v])
+	}
+
+	// Кнопки доступны в зависимости от прав на выбранный плейлист и выбора папки
+	updateControls := func() {
+		perm := ""
+		if selectedPlaylist != nil {
+			perm = selectedPlaylist.Permission
+			currentPlaylistLabel.SetText(playlistLabel(*selectedPlaylist))
+		} else {
+			currentPlaylistLabel.SetText("Плейлист не выбран")
+		}
+		visibilitySelect.OnChanged = nil
+		if selectedPlaylist != nil && selectedPlaylist.Visibility != "" {
+			visibilitySelect.SetSelected(visibilityLabels[selectedPlaylist.Visibility])
+		} else {
+			visibilitySelect.ClearSelected()
+		}
+		visibilitySelect.OnChanged = func(string) {
+			v := visibilityOptions[visibilitySelect.SelectedIndex()]
+			if selectedPlaylist == nil || v == selectedPlaylist.Visibility {
+				return
+			}
+			if err := setPlaylistVisibility(selectedPlaylist.ID, v); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			selectedPlaylist.Visibility = v
+			setEnabled(linkBtn, v == VisibilityUnlisted)
+			if v == VisibilityUnlisted {
+				showPlaylistLink(*selectedPlaylist)
+			}
+		}
+		setEnabled(visibilitySelect, canPlaylist(perm, PermOwner))
+		setEnabled(linkBtn, canPlaylist(perm, PermOwner) && selectedPlaylist.Visibility == VisibilityUnlisted)
+		setEnabled(deletePlaylistBtn, canPlaylist(perm, PermOwner))
+		setEnabled(collaboratorsBtn, canPlaylist(perm, PermOwner))
+		setEnabled(movePlaylistBtn, canPlaylist(perm, PermOwner) || selectedFolderID != 0)
+		setEnabled(addTrackBtn, canPlaylist(perm, PermAdd))
+		setEnabled(mixOrderBtn, canPlaylist(perm, PermEdit))
+		setEnabled(suggestBtn, selectedPlaylist != nil)
+		for _, b := range []*widget.Button{renameFolderBtn, deleteFolderBtn, playAllBtn, exportFolderBtn} {
+			setEnabled(b, selectedFolderID != 0)
+		}
+	}
+
+	var entrySel *multiSelection
+
+	loadPlaylistTracks := func() {
+		summaryLabel.SetText("")
+		if selectedPlaylist != nil {
+			playlistTracks, playlistTrackNames = getPlaylistEntries(selectedPlaylist.ID)
+			if s, err := getPlaylistSummary(selectedPlaylist.ID); err == nil {
+				summaryLabel.SetText(describePlaylistSummary(s))
+			}
+		} else {
+			playlistTracks, playlistTrackNames = nil, nil
+		}
+		if entrySel != nil {
+			ids := make([]int, len(playlistTracks))
+			for i, e := range playlistTracks {
+				ids[i] = e.ID
+			}
+			entrySel.retain(ids)
+			entrySel.changed()
+		}
+	}
+
 	// ФУНКЦИЯ ОБНОВЛЕНИЯ (Refresh)
 	refresh := func() {
-		playlists, playlistNames = getPlaylists()
+		owned, _ := getPlaylists()
+		tree = buildPlaylistTree(owned, getFolders(), map[string][]Playlist{
+			sectionShared:   getSharedPlaylists(),
+			sectionFollowed: getFollowedPlaylists(),
+		})
+
+		// Плейлист могли удалить или закрыть к нему доступ
+		if selectedPlaylist != nil {
+			found := false
+			for _, p := range tree.playlists {
+				if p.ID == selectedPlaylist.ID {
+					selectedPlaylist.Permission = p.Permission
+					selectedPlaylist.Visibility = p.Visibility
+					found = true
+					break
+				}
+			}
+			if !found {
+				selectedPlaylist = nil
+				playlistTreeView.UnselectAll()
+			}
+		}
+		if _, ok := tree.folders[selectedFolderID]; !ok {
+			selectedFolderID = 0
+		}
+
 		if len(allTracksCached) == 0 {
 			allTracksCached, _ = getTracks()
 		}
 
-		filteredTracks = nil
+		filter := TrackFilter{Text: strings.TrimSpace(searchTrack.Text), Sort: trackSortOptions[sortSelect.SelectedIndex()]}
+		filter.MinBPM, _ = strconv.ParseFloat(strings.TrimSpace(minBPMEntry.Text), 64)
+		filter.MaxBPM, _ = strconv.ParseFloat(strings.TrimSpace(maxBPMEntry.Text), 64)
+		if i := keySelect.SelectedIndex(); i > 0 {
+			filter.Key = camelotKeys()[i-1]
+		}
+		filteredTracks = filterTracks(allTracksCached, filter)
 		filteredTrackNames = nil
-		searchText := strings.ToLower(searchTrack.Text)
-		for _, t := range allTracksCached {
-			if searchText == "" || strings.Contains(strings.ToLower(t.Title), searchText) {
-				filteredTracks = append(filteredTracks, t)
-				filteredTrackNames = append(filteredTrackNames, t.Title)
-			}
+		for _, t := range filteredTracks {
+			filteredTrackNames = append(filteredTrackNames, trackPickerLabel(t))
 		}
-
-		playlistSelect.Options = playlistNames
 		trackSelect.Options = filteredTrackNames
-
-		if selectedPlaylist != nil {
-			// Принимаем два значения: список объектов и список имен
-			playlistTracks, _ = getTracksFromPlaylist(selectedPlaylist.ID)
+		// Выбранный трек остаётся выбранным, пока он проходит фильтр
+		keep := -1
+		for i, t := range filteredTracks {
+			if selectedTrack != nil && t.ID == selectedTrack.ID {
+				keep = i
+			}
+		}
+		if keep >= 0 {
+			trackSelect.SetSelectedIndex(keep)
 		} else {
-			playlistTracks = nil
+			selectedTrack = nil
+			trackSelect.ClearSelected()
 		}
 
-		playlistSelect.Refresh()
+		loadPlaylistTracks()
+		updateControls()
+
+		playlistTreeView.Refresh()
 		trackSelect.Refresh()
 		list.Refresh()
 	}
 
-	playlistSelect = widget.NewSelect(nil, func(s string) {
-		for _, p := range playlists {
-			if p.Title == s {
-				selectedPlaylist = &p
-				playlistTracks, _ = getTracksFromPlaylist(p.ID)
-				break
+	// notifyConflict сообщает, что в плейлист параллельно вносил правки другой участник
+	notifyConflict := func(changedByOthers bool) {
+		if changedByOthers {
+			dialog.ShowInformation("Плейлист обновлён", "Пока вы работали, плейлист изменил другой участник. Список обновлён.", mainWindow)
+		}
+	}
+
+	// ДЕРЕВО ПАПОК И ПЛЕЙЛИСТОВ
+	playlistTreeView = widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			if tree == nil {
+				return nil
 			}
+			return tree.children[id]
+		},
+		func(id widget.TreeNodeID) bool { return tree != nil && tree.isBranch(id) },
+		func(branch bool) fyne.CanvasObject {
+			icon := theme.FolderIcon()
+			if !branch {
+				icon = theme.MediaMusicIcon()
+			}
+			return container.NewHBox(widget.NewIcon(icon), widget.NewLabel(""))
+		},
+		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			o.(*fyne.Container).Objects[1].(*widget.Label).SetText(tree.labels[id])
+		},
+	)
+	playlistTreeView.OnSelected = func(id widget.TreeNodeID) {
+		selectedFolderID = 0
+		selectedPlaylist = nil
+		if p, ok := tree.playlists[id]; ok {
+			selectedPlaylist = &p
+		} else if fid, ok := tree.folderID(id); ok {
+			selectedFolderID = fid
 		}
+		loadPlaylistTracks()
+		updateControls()
 		list.Refresh()
+	}
+
+	// ДЕЙСТВИЯ С ПАПКАМИ
+	newFolderBtn := widget.NewButtonWithIcon("", theme.FolderNewIcon(), func() {
+		nameEntry := widget.NewEntry()
+		dialog.ShowForm("Новая папка", "Создать", "Отмена",
+			[]*widget.FormItem{widget.NewFormItem("Название", nameEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				if err := createFolder(nameEntry.Text, selectedFolderID); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				refresh()
+			}, mainWindow)
 	})
-	playlistSelect.PlaceHolder = "Выберите плейлист"
+
+	renameFolderBtn = widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(tree.folders[selectedFolderID].Name)
+		dialog.ShowForm("Переименовать папку", "Сохранить", "Отмена",
+			[]*widget.FormItem{widget.NewFormItem("Название", nameEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				if err := renameFolder(selectedFolderID, nameEntry.Text); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				refresh()
+			}, mainWindow)
+	})
+
+	deleteFolderBtn = widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		confirmDelete("Удаление", "Удалить папку '"+tree.folders[selectedFolderID].Name+"'? Её содержимое переместится на уровень выше.", func() {
+			if err := deleteFolder(selectedFolderID); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+			selectedFolderID = 0
+			playlistTreeView.UnselectAll()
+			refresh()
+		})
+	})
+
+	// Перемещение выбранного плейлиста или папки в другую папку
+	movePlaylistBtn = widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+		ids, names := tree.folderChoices(selectedFolderID)
+		target := widget.NewSelect(names, nil)
+		dialog.ShowForm("Переместить", "Переместить", "Отмена",
+			[]*widget.FormItem{widget.NewFormItem("В папку", target)},
+			func(ok bool) {
+				if !ok || target.SelectedIndex() < 0 {
+					return
+				}
+				dest := ids[target.SelectedIndex()]
+				var err error
+				if selectedPlaylist != nil {
+					err = movePlaylistToFolder(selectedPlaylist.ID, dest)
+				} else if selectedFolderID != 0 {
+					err = moveFolder(tree, selectedFolderID, dest)
+				}
+				if err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				refresh()
+			}, mainWindow)
+	})
+
+	playAllBtn = widget.NewButtonWithIcon("", theme.MediaPlayIcon(), func() {
+		showFolderPlayQueue(tree, selectedFolderID)
+	})
+
+	exportFolderBtn = widget.NewButtonWithIcon("", theme.DownloadIcon(), func() {
+		t, folderID := tree, selectedFolderID
+		save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil || w == nil {
+				return
+			}
+			defer w.Close()
+			if err := exportFolderZip(t, folderID, w); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+		}, mainWindow)
+		save.SetFileName(safeFileName(t.folders[folderID].Name) + ".zip")
+		save.Show()
+	})
+
+	folderToolbar := container.NewHBox(newFolderBtn, renameFolderBtn, movePlaylistBtn, playAllBtn, exportFolderBtn, deleteFolderBtn)
 
 	// КНОПКА УДАЛЕНИЯ ПЛЕЙЛИСТА (Теперь она здесь)
-	deletePlaylistBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+	deletePlaylistBtn = widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
 		if selectedPlaylist == nil {
 			dialog.ShowInformation("Внимание", "Выберите плейлист для удаления", mainWindow)
 			return
 		}
 		confirmDelete("Удаление", "Удалить плейлист '"+selectedPlaylist.Title+"'?", func() {
-			deletePlaylist(selectedPlaylist.ID)
+			if err := deletePlaylist(selectedPlaylist.ID); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
 			selectedPlaylist = nil
-			playlistSelect.ClearSelected()
+			playlistTreeView.UnselectAll()
 			refresh()
 		})
 	})
 	deletePlaylistBtn.Importance = widget.DangerImportance
 
-	trackSelect = widget.NewSelect(nil, func(s string) {
-		for _, t := range filteredTracks {
-			if t.Title == s {
-				selectedTrack = &t
-				break
-			}
+	collaboratorsBtn = widget.NewButtonWithIcon("", theme.AccountIcon(), func() {
+		if selectedPlaylist != nil {
+			showCollaborators(*selectedPlaylist)
+		}
+	})
+
+	linkBtn = widget.NewButtonWithIcon("", theme.MailForwardIcon(), func() {
+		if selectedPlaylist != nil {
+			showPlaylistLink(*selectedPlaylist)
+		}
+	})
+
+	// Под выбранным треком — ссылки на его артиста и альбом
+	selectedTrackLinks := newTrackLinks()
+	trackSelect = widget.NewSelect(nil, func(string) {
+		i := trackSelect.SelectedIndex()
+		if i < 0 || i >= len(filteredTracks) {
+			setTrackLinks(selectedTrackLinks, TrackInfo{})
+			return
+		}
+		t := filteredTracks[i]
+		selectedTrack = &t
+		if infos, err := repo.GetTrackInfos([]int{t.ID}); err == nil && len(infos) > 0 {
+			setTrackLinks(selectedTrackLinks, infos[0])
 		}
 	})
 	trackSelect.PlaceHolder = "Выберите трек"
 
-	addTrackBtn := widget.NewButtonWithIcon("Добавить в плейлист", theme.ContentAddIcon(), func() {
+	suggestBtn = widget.NewButtonWithIcon("Похожие", theme.SearchIcon(), func() {
+		if selectedPlaylist == nil {
+			return
+		}
+		showSuggestions(selectedPlaylist, func(changedByOthers bool) {
+			refresh()
+			notifyConflict(changedByOthers)
+		})
+	})
+
+	addTrackBtn = widget.NewButtonWithIcon("Добавить в плейлист", theme.ContentAddIcon(), func() {
 		if selectedPlaylist == nil || selectedTrack == nil {
 			dialog.ShowInformation("Внимание", "Выберите плейлист и трек", mainWindow)
 			return
 		}
-		err := repo.AddTrackToPlaylist(selectedPlaylist.ID, selectedTrack.ID)
+		changedByOthers, err := addTrackToPlaylist(selectedPlaylist, selectedTrack.ID)
 		if err != nil {
 			dialog.ShowError(err, mainWindow)
 		}
 		refresh()
+		notifyConflict(changedByOthers)
 	})
 
 	newPlaylistEntry := widget.NewEntry()
 	newPlaylistEntry.SetPlaceHolder("Название нового плейлиста")
+	radioBtn := widget.NewButtonWithIcon("Радио…", theme.MediaMusicIcon(), func() { showRadioDialog(refresh) })
+	generateBtn := widget.NewButtonWithIcon("По длительности…", theme.HistoryIcon(), func() { showGeneratorDialog(refresh) })
 	addPlaylistBtn := widget.NewButtonWithIcon("Создать плейлист", theme.DocumentCreateIcon(), func() {
 		if newPlaylistEntry.Text != "" {
 			createPlaylist(newPlaylistEntry.Text)
@@ -122,66 +401,180 @@ func createPlaylistTab() *container.TabItem {
 		}
 	})
 
+	// Множественный выбор треков плейлиста для массового удаления
+	entrySel = newMultiSelection(nil)
+
 	list = widget.NewList(
 		func() int { return len(playlistTracks) },
 		func() fyne.CanvasObject {
-			return listRowWithDelete("Название трека", func() {})
+			return selectableRow(true, theme.MoveUpIcon(), theme.MoveDownIcon(), theme.InfoIcon())
 		},
 		func(i widget.ListItemID, o fyne.CanvasObject) {
 			if i >= len(playlistTracks) {
 				return
 			}
 			track := playlistTracks[i]
-			min := track.Duration / 60
-			sec := track.Duration % 60
-			title := fmt.Sprintf("%s (%d:%02d)", track.Title, min, sec)
-			o.(*fyne.Container).Objects[0].(*widget.Label).SetText(title)
-			o.(*fyne.Container).Objects[2].(*widget.Button).OnTapped = func() {
+			updateSelectableRow(o, playlistTrackNames[i], track.ID, entrySel, func() {
 				confirmDelete("Удаление", "Удалить трек из плейлиста?", func() {
-					repo.RemoveTrackFromPlaylist(selectedPlaylist.ID, track.ID)
+					changedByOthers, err := removeTrackFromPlaylist(selectedPlaylist, track.ID)
+					if err != nil {
+						dialog.ShowError(err, mainWindow)
+					}
 					refresh()
+					notifyConflict(changedByOthers)
 				})
+			})
+			// Порядок треков меняется кнопками вверх/вниз
+			move := func(delta int) func() {
+				return func() {
+					changedByOthers, err := moveEntry(selectedPlaylist, track.ID, delta)
+					if err != nil {
+						dialog.ShowError(err, mainWindow)
+					}
+					refresh()
+					notifyConflict(changedByOthers)
+				}
+			}
+			row := o.(*fyne.Container)
+			row.Objects[4].(*widget.Button).OnTapped = move(-1)
+			row.Objects[5].(*widget.Button).OnTapped = move(1)
+			row.Objects[6].(*widget.Button).OnTapped = func() { showTrackPage(track.ID) }
+			// Карточка трека открывается всем, правка — по правам на плейлист
+			for _, btn := range row.Objects[3:6] {
+				if selectedPlaylist == nil || !canPlaylist(selectedPlaylist.Permission, PermEdit) {
+					btn.Hide()
+				} else {
+					btn.Show()
+				}
 			}
 		},
 	)
+	bindMultiSelect(list, entrySel, func(i int) int { return playlistTracks[i].ID })
+
+	removeSelectedBtn := widget.NewButtonWithIcon("Убрать выбранные", theme.DeleteIcon(), func() {
+		if selectedPlaylist == nil {
+			return
+		}
+		confirmDelete("Удаление", fmt.Sprintf("Убрать из плейлиста треков: %d?", entrySel.count()), func() {
+			changedByOthers, err := bulkRemoveFromPlaylist(selectedPlaylist, entrySel.ids())
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+			entrySel.clear()
+			refresh()
+			notifyConflict(changedByOthers)
+		})
+	})
+	entryToolbar, updateEntryToolbar := bulkToolbar(entrySel, func() []int {
+		ids := make([]int, len(playlistTracks))
+		for i, e := range playlistTracks {
+			ids[i] = e.ID
+		}
+		return ids
+	}, removeSelectedBtn)
+	entrySel.onChange = func() {
+		updateEntryToolbar()
+		if selectedPlaylist == nil || !canPlaylist(selectedPlaylist.Permission, PermEdit) {
+			removeSelectedBtn.Disable()
+		}
+		list.Refresh()
+	}
 
 	searchTrack.OnChanged = func(string) { refresh() }
+	minBPMEntry.OnChanged = func(string) { refresh() }
+	maxBPMEntry.OnChanged = func(string) { refresh() }
+	keySelect.OnChanged = func(string) { refresh() }
+	sortSelect.OnChanged = func(string) { refresh() }
+
+	mixOrderBtn = widget.NewButtonWithIcon("", theme.MediaFastForwardIcon(), func() {
+		if selectedPlaylist == nil {
+			return
+		}
+		dialog.ShowConfirm("Порядок для сведения",
+			"Переставить треки так, чтобы соседние были близки по темпу и совместимы по тональности?\n"+
+				"Треки без темпа окажутся в конце. Правку можно отменить.", func(ok bool) {
+				if !ok {
+					return
+				}
+				changedByOthers, err := autoOrderForMixing(selectedPlaylist)
+				if err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				refresh()
+				notifyConflict(changedByOthers)
+			}, mainWindow)
+	})
+
+	exportPlaylistBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
+		if selectedPlaylist != nil {
+			showExportPlaylist(*selectedPlaylist)
+		}
+	})
+
+	opsBtn := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		showPlaylistOps(tree, selectedPlaylist, refresh)
+	})
+
+	// Обновление вручную подтягивает правки других участников
+	reloadBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		if selectedPlaylist != nil {
+			playlistChangedByOthers(selectedPlaylist)
+		}
+		refresh()
+	})
 
-	// Компоновка верхней части (Селектор + Кнопка удаления в одной строке)
-	playlistHeader := container.NewBorder(nil, nil, nil, deletePlaylistBtn, playlistSelect)
+	// После отмены или повтора правок перечитываем каталог и плейлисты
+	onDataChanged(func() {
+		allTracksCached = nil
+		refresh()
+	})
+
+	// Компоновка верхней части (Название + Кнопки управления в одной строке)
+	playlistHeader := container.NewBorder(nil, nil, nil,
+		container.NewHBox(visibilitySelect, linkBtn, reloadBtn, mixOrderBtn, exportPlaylistBtn, opsBtn, collaboratorsBtn, deletePlaylistBtn), currentPlaylistLabel)
 
 	refresh()
 
-	return container.NewTabItemWithIcon("Плейлисты", theme.StorageIcon(), container.NewBorder(
+	treePanel := container.NewBorder(
+		widget.NewLabelWithStyle("Мои плейлисты", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		folderToolbar, nil, nil,
+		playlistTreeView,
+	)
+
+	playlistPanel := container.NewBorder(
 		container.NewVBox(
 			widget.NewLabelWithStyle("Управление плейлистами", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-			container.NewBorder(nil, nil, nil, addPlaylistBtn, newPlaylistEntry),
+			container.NewBorder(nil, nil, nil, container.NewHBox(addPlaylistBtn, radioBtn, generateBtn), newPlaylistEntry),
 			widget.NewSeparator(),
 			widget.NewLabel("Текущий плейлист:"),
 			playlistHeader,
+			summaryLabel,
 			widget.NewSeparator(),
 			widget.NewLabel("Добавить треки:"),
 			searchTrack,
-			container.NewBorder(nil, nil, nil, addTrackBtn, trackSelect),
+			container.NewGridWithColumns(4, minBPMEntry, maxBPMEntry, keySelect, sortSelect),
+			container.NewBorder(nil, nil, nil, container.NewHBox(addTrackBtn, suggestBtn), trackSelect),
+			selectedTrackLinks,
 			widget.NewSeparator(),
 		),
-		nil, nil, nil,
+		entryToolbar, nil, nil,
 		list,
-	))
+	)
+
+	split := container.NewHSplit(treePanel, playlistPanel)
+	split.Offset = 0.3
+
+	return container.NewTabItemWithIcon("Плейлисты", theme.StorageIcon(), split)
 }
 
 // DATABASE TAB
 func createDatabaseTab() *container.TabItem {
+	var artistRows []ArtistRow
 	var artists []Artist
-	var artistNames []string
+	var albumRows []AlbumRow
 	var albums []Album
-	var albumNames []string
+	var trackRows []TrackRow
 	var tracks []Track
-	var trackNames []string
-
-	artistList := widget.NewList(nil, nil, nil)
-	albumList := widget.NewList(nil, nil, nil)
-	trackList := widget.NewList(nil, nil, nil)
 
 	newArtistEntry := widget.NewEntry()
 	newArtistEntry.SetPlaceHolder("Имя артиста")
@@ -192,108 +585,215 @@ func createDatabaseTab() *container.TabItem {
 	newTrackEntry := widget.NewEntry()
 	newTrackEntry.SetPlaceHolder("Название трека")
 	newTrackDurationEntry := widget.NewEntry()
-	newTrackDurationEntry.SetPlaceHolder("Секунды")
+	newTrackDurationEntry.SetPlaceHolder(durationPlaceholder)
 
 	searchArtist := widget.NewEntry()
 	searchArtist.SetPlaceHolder("Поиск...")
 	searchAlbum := widget.NewEntry()
-	searchAlbum.SetPlaceHolder("Поиск...")
+	searchAlbum.SetPlaceHolder("Поиск по альбому или артисту...")
 	searchTrack := widget.NewEntry()
-	searchTrack.SetPlaceHolder("Поиск...")
+	searchTrack.SetPlaceHolder("Поиск по названию, альбому или артисту...")
 
 	albumSelectArtist := widget.NewSelect(nil, nil)
 	trackSelectAlbum := widget.NewSelect(nil, nil)
 
-	refreshAll := func() {
-		// Артисты
-		allA, allAN := getArtists()
-		artists, artistNames = nil, nil
-		for i, n := range allAN {
-			if containsIgnoreCase(n, searchArtist.Text) {
-				artists = append(artists, allA[i])
-				artistNames = append(artistNames, n)
-			}
+	// Множественный выбор: ID выбранных записей не теряются при поиске
+	artistSel := newMultiSelection(nil)
+	albumSel := newMultiSelection(nil)
+	trackSel := newMultiSelection(nil)
+	canEdit := canEditCatalog()
+
+	var refreshAll func()
+	// Страница записи открывается всем, удаление и редактирование доступны только редакторам
+	rowActions := func(kind string, idAt func(int) int, edit func(int)) []tableAction {
+		open := tableAction{Icon: theme.InfoIcon(), OnTapped: func(i int) { showCatalogPage(kind, idAt(i)) }}
+		if !canEdit {
+			return []tableAction{open}
 		}
-		albumSelectArtist.Options = allAN
-
-		// Альбомы
-		allAlb, allAlbN := getAlbums()
-		albums, albumNames = nil, nil
-		for i, n := range allAlbN {
-			if containsIgnoreCase(n, searchAlbum.Text) {
-				albums = append(albums, allAlb[i])
-				albumNames = append(albumNames, n)
-			}
+		return []tableAction{
+			{Icon: theme.DeleteIcon(), OnTapped: func(i int) { confirmCatalogDelete(kind, []int{idAt(i)}, refreshAll) }},
+			{Icon: theme.DocumentCreateIcon(), OnTapped: edit},
+			open,
 		}
-		trackSelectAlbum.Options = allAlbN
-
-		// Треки
-		allT, allTN := getTracks()
-		tracks, trackNames = nil, nil
-		for i, n := range allTN {
-			if containsIgnoreCase(n, searchTrack.Text) {
-				tracks = append(tracks, allT[i])
-				trackNames = append(trackNames, n)
-			}
+	}
+	optional := func(n int) string {
+		if n == 0 {
+			return ""
 		}
-
-		artistList.Refresh()
-		albumList.Refresh()
-		trackList.Refresh()
+		return strconv.Itoa(n)
 	}
 
-	// Настройка списков
-	artistList.Length = func() int { return len(artistNames) }
-	artistList.CreateItem = func() fyne.CanvasObject { return listRowWithDelete("", func() {}) }
-	artistList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
-		if id >= len(artists) {
-			return
+	// Таблицы
+	artistID := func(i int) int { return artistRows[i].ID }
+	artistTable := newCatalogTable("artists", []tableColumn{
+		{Key: "name", Title: "Артист", Width: 280},
+		{Key: "albums", Title: "Альбомов", Width: 100},
+		{Key: "tracks", Title: "Треков", Width: 90},
+	}, artistSel, artistID,
+		func() int { return len(artistRows) },
+		func(i int, key string) string {
+			a := artistRows[i]
+			switch key {
+			case "albums":
+				return strconv.Itoa(a.Albums)
+			case "tracks":
+				return strconv.Itoa(a.Tracks)
+			}
+			return a.Name
+		},
+		rowActions(KindArtist, artistID, func(i int) { showEditArtistDialog(artistRows[i].Artist, refreshAll) })...)
+
+	albumID := func(i int) int { return albumRows[i].ID }
+	albumTable := newCatalogTable("albums", []tableColumn{
+		{Key: "title", Title: "Альбом", Width: 240},
+		{Key: "artist", Title: "Артист", Width: 180},
+		{Key: "year", Title: "Год", Width: 70},
+		{Key: "tracks", Title: "Треков", Width: 90},
+		{Key: "duration", Title: "Длительность", Width: 120},
+	}, albumSel, albumID,
+		func() int { return len(albumRows) },
+		func(i int, key string) string {
+			a := albumRows[i]
+			switch key {
+			case "artist":
+				return a.ArtistName
+			case "year":
+				return optional(a.Year)
+			case "tracks":
+				return strconv.Itoa(a.Tracks)
+			case "duration":
+				return formatDuration(a.Duration)
+			}
+			return a.Title
+		},
+		rowActions(KindAlbum, albumID, func(i int) { showEditAlbumDialog(albumRows[i].Album, refreshAll) })...)
+
+	trackID := func(i int) int { return trackRows[i].ID }
+	trackTable := newCatalogTable("tracks", []tableColumn{
+		{Key: "title", Title: "Название", Width: 240},
+		{Key: "artist", Title: "Артист", Width: 160},
+		{Key: "album", Title: "Альбом", Width: 180},
+		{Key: "year", Title: "Год", Width: 70},
+		{Key: "number", Title: "№", Width: 50, Hidden: true},
+		{Key: "duration", Title: "Длительность", Width: 120},
+		{Key: "genre", Title: "Жанр", Width: 120},
+		{Key: "rating", Title: "Оценка", Width: 100},
+		{Key: "bpm", Title: "BPM", Width: 70, Hidden: true},
+		{Key: "key", Title: "Тональность", Width: 120, Hidden: true},
+	}, trackSel, trackID,
+		func() int { return len(trackRows) },
+		func(i int, key string) string {
+			t := trackRows[i]
+			switch key {
+			case "artist":
+				return t.ArtistName
+			case "album":
+				return t.AlbumTitle
+			case "year":
+				return optional(t.Year)
+			case "number":
+				return optional(t.Number)
+			case "duration":
+				return formatDuration(t.Duration)
+			case "genre":
+				return t.Genre
+			case "rating":
+				return ratingStars(t.Rating)
+			case "bpm":
+				if t.BPM == 0 {
+					return ""
+				}
+				return fmt.Sprintf("%g", t.BPM)
+			case "key":
+				return keyLabel(t.Key)
+			}
+			return t.Title
+		},
+		rowActions(KindTrack, trackID, func(i int) { showEditTrackDialog(trackRows[i].Track, refreshAll) })...)
+
+	refreshAll = func() {
+		allA, allAN := getArtists()
+		albumSelectArtist.Options = allAN
+		allAlb, allAlbN := getAlbums()
+		trackSelectAlbum.Options = allAlbN
+		allT, _ := getTracks()
+
+		// Поиск и сортировка таблиц выполняются в базе
+		artistRows, _ = getArtistRows(artistTable.query(searchArtist.Text))
+		albumRows, _ = getAlbumRows(albumTable.query(searchAlbum.Text))
+		trackRows, _ = getTrackRows(trackTable.query(searchTrack.Text))
+		artists, albums, tracks = nil, nil, nil
+		for _, a := range artistRows {
+			artists = append(artists, a.Artist)
 		}
-		a := artists[id]
-		o.(*fyne.Container).Objects[0].(*widget.Label).SetText(a.Name)
-		o.(*fyne.Container).Objects[2].(*widget.Button).OnTapped = func() {
-			confirmDelete("Удаление", "Удалить артиста "+a.Name+"?", func() {
-				deleteArtist(a.ID)
-				refreshAll()
-			})
+		for _, a := range albumRows {
+			albums = append(albums, a.Album)
 		}
-	}
-	albumList.Length = func() int { return len(albumNames) }
-	albumList.CreateItem = func() fyne.CanvasObject { return listRowWithDelete("", func() {}) }
-	albumList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
-		if id >= len(albums) {
-			return
+		for _, t := range trackRows {
+			tracks = append(tracks, t.Track)
 		}
-		a := albums[id]
-		o.(*fyne.Container).Objects[0].(*widget.Label).SetText(albumNames[id])
-		o.(*fyne.Container).Objects[2].(*widget.Button).OnTapped = func() {
-			confirmDelete("Удаление", "Удалить альбом?", func() {
-				deleteAlbum(a.ID)
+
+		artistSel.retain(artistIDs(allA))
+		albumSel.retain(albumIDs(allAlb))
+		trackSel.retain(trackIDs(allT))
+
+		artistTable.Refresh()
+		albumTable.Refresh()
+		trackTable.Refresh()
+	}
+	artistTable.OnSortChanged = refreshAll
+	albumTable.OnSortChanged = refreshAll
+	trackTable.OnSortChanged = refreshAll
+
+	// Массовые действия
+	bulkDeleteBtn := func(kind string, sel *multiSelection) *widget.Button {
+		btn := widget.NewButtonWithIcon("Удалить выбранные", theme.DeleteIcon(), func() {
+			confirmCatalogDelete(kind, sel.ids(), func() {
+				sel.clear()
 				refreshAll()
 			})
+		})
+		btn.Importance = widget.DangerImportance
+		if !canEdit {
+			btn.Hide()
 		}
+		return btn
 	}
+	artistToolbar, updateArtistToolbar := bulkToolbar(artistSel, func() []int { return artistIDs(artists) },
+		bulkDeleteBtn(KindArtist, artistSel))
+	albumToolbar, updateAlbumToolbar := bulkToolbar(albumSel, func() []int { return albumIDs(albums) },
+		bulkDeleteBtn(KindAlbum, albumSel))
 
-	trackList.Length = func() int { return len(trackNames) }
-	trackList.CreateItem = func() fyne.CanvasObject { return listRowWithDelete("", func() {}) }
-	trackList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
-		if id >= len(tracks) {
-			return
-		}
-		t := tracks[id]
-		o.(*fyne.Container).Objects[0].(*widget.Label).SetText(trackNames[id])
-		o.(*fyne.Container).Objects[2].(*widget.Button).OnTapped = func() {
-			confirmDelete("Удаление", "Удалить трек?", func() {
-				deleteTrack(t.ID)
-				refreshAll()
-			})
-		}
+	addToPlaylistBtn := widget.NewButtonWithIcon("В плейлист", theme.ContentAddIcon(), func() {
+		showAddToPlaylistDialog(trackSel.ids())
+	})
+	tagBtn := widget.NewButton("Жанр и теги", func() {
+		showTagTracksDialog(trackSel.ids(), refreshAll)
+	})
+	moveBtn := widget.NewButton("В другой альбом", func() {
+		showMoveTracksDialog(trackSel.ids(), func() {
+			trackSel.clear()
+			refreshAll()
+		})
+	})
+	if !canEdit {
+		tagBtn.Hide()
+		moveBtn.Hide()
 	}
+	trackToolbar, updateTrackToolbar := bulkToolbar(trackSel, func() []int { return trackIDs(tracks) },
+		addToPlaylistBtn, tagBtn, moveBtn, bulkDeleteBtn(KindTrack, trackSel))
+
+	artistSel.onChange = func() { updateArtistToolbar(); artistTable.Refresh() }
+	albumSel.onChange = func() { updateAlbumToolbar(); albumTable.Refresh() }
+	trackSel.onChange = func() { updateTrackToolbar(); trackTable.Refresh() }
 
 	// Кнопки добавления
 	addArtBtn := widget.NewButton("Добавить", func() {
 		if newArtistEntry.Text != "" {
-			addArtist(newArtistEntry.Text)
+			if err := addArtist(newArtistEntry.Text); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
 			newArtistEntry.SetText("")
 			refreshAll()
 		}
@@ -308,8 +808,15 @@ func createDatabaseTab() *container.TabItem {
 				break
 			}
 		}
-		year, _ := strconv.Atoi(newAlbumYearEntry.Text)
-		addAlbum(newAlbumEntry.Text, artID, year)
+		year, err := parseYear(newAlbumYearEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if err := addAlbum(newAlbumEntry.Text, artID, year); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
 		newAlbumEntry.SetText("")
 		refreshAll()
 	})
@@ -323,8 +830,15 @@ func createDatabaseTab() *container.TabItem {
 				break
 			}
 		}
-		dur, _ := strconv.Atoi(newTrackDurationEntry.Text)
-		addTrack(newTrackEntry.Text, alID, dur)
+		dur, err := parseDuration(newTrackDurationEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if err := addTrack(newTrackEntry.Text, alID, dur); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
 		newTrackEntry.SetText("")
 		refreshAll()
 	})
@@ -333,11 +847,39 @@ func createDatabaseTab() *container.TabItem {
 	searchAlbum.OnChanged = func(string) { refreshAll() }
 	searchTrack.OnChanged = func(string) { refreshAll() }
 
+	onDataChanged(refreshAll)
 	refreshAll()
 
-	return container.NewTabItemWithIcon("База данных", theme.InfoIcon(), container.NewAppTabs(
-		container.NewTabItem("Артисты", container.NewBorder(container.NewVBox(newArtistEntry, addArtBtn, searchArtist), nil, nil, nil, artistList)),
-		container.NewTabItem("Альбомы", container.NewBorder(container.NewVBox(albumSelectArtist, newAlbumEntry, newAlbumYearEntry, addAlbBtn, searchAlbum), nil, nil, nil, albumList)),
-		container.NewTabItem("Треки", container.NewBorder(container.NewVBox(trackSelectAlbum, newTrackEntry, newTrackDurationEntry, addTrackBtn, searchTrack), nil, nil, nil, trackList)),
-	))
+	// Выбор колонок и выгрузка в CSV того, что сейчас отфильтровано в таблице
+	artistSearchRow := container.NewBorder(nil, nil, nil, container.NewHBox(artistTable.columnsButton(),
+		csvExportButton("artists.csv", func(w io.Writer) error { return exportArtistsCSV(w, artists) })), searchArtist)
+	albumSearchRow := container.NewBorder(nil, nil, nil, container.NewHBox(albumTable.columnsButton(),
+		csvExportButton("albums.csv", func(w io.Writer) error { return exportAlbumsCSV(w, albums) })), searchAlbum)
+	trackSearchRow := container.NewBorder(nil, nil, nil, container.NewHBox(trackTable.columnsButton(),
+		csvExportButton("tracks.csv", func(w io.Writer) error { return exportTracksCSV(w, tracks) })), searchTrack)
+
+	// Слушатели видят каталог только для чтения
+	artistTop := container.NewVBox(newArtistEntry, addArtBtn, artistSearchRow)
+	albumTop := container.NewVBox(albumSelectArtist, newAlbumEntry, newAlbumYearEntry, addAlbBtn, albumSearchRow)
+	trackTop := container.NewVBox(trackSelectAlbum, newTrackEntry, newTrackDurationEntry, addTrackBtn, trackSearchRow)
+	if !canEdit {
+		artistTop = container.NewVBox(artistSearchRow)
+		albumTop = container.NewVBox(albumSearchRow)
+		trackTop = container.NewVBox(trackSearchRow)
+	}
+
+	var content fyne.CanvasObject = container.NewAppTabs(
+		container.NewTabItem("Артисты", container.NewBorder(artistTop, artistToolbar, nil, nil, artistTable)),
+		container.NewTabItem("Альбомы", container.NewBorder(albumTop, albumToolbar, nil, nil, albumTable)),
+		container.NewTabItem("Треки", container.NewBorder(trackTop, trackToolbar, nil, nil, trackTable)),
+	)
+	if canEdit {
+		importBtn := widget.NewButtonWithIcon("Импорт CSV", theme.UploadIcon(), func() { showCSVImport(refreshAll) })
+		metadataBtn := widget.NewButtonWithIcon("Уточнить метаданные", theme.SearchIcon(), func() { showMetadataDialog(refreshAll) })
+		loudnessBtn := widget.NewButtonWithIcon("Громкость…", theme.VolumeUpIcon(), func() { showLoudnessDialog(refreshAll) })
+		tempoBtn := widget.NewButtonWithIcon("Темп и тональность…", theme.MediaMusicIcon(), func() { showTempoDialog(refreshAll) })
+		content = container.NewBorder(container.NewHBox(importBtn, metadataBtn, loudnessBtn, tempoBtn), nil, nil, nil, content)
+	}
+
+	return container.NewTabItemWithIcon("База данных", theme.InfoIcon(), content)
 }
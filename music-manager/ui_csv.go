@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// csvExportButton сохраняет в CSV то, что сейчас показано в списке
+func csvExportButton(fileName string, write func(w io.Writer) error) *widget.Button {
+	return widget.NewButtonWithIcon("", theme.DownloadIcon(), func() {
+		save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil || w == nil {
+				return
+			}
+			defer w.Close()
+			if err := write(w); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+		}, mainWindow)
+		save.SetFileName(fileName)
+		save.Show()
+	})
+}
+
+// showCSVImport — выбор файла, сопоставление столбцов и импорт
+func showCSVImport(onDone func()) {
+	open := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil || r == nil {
+			return
+		}
+		defer r.Close()
+		header, records, err := readCSV(r)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		showCSVMapping(header, records, onDone)
+	}, mainWindow)
+	open.Show()
+}
+
+func showCSVMapping(header []string, records [][]string, onDone func()) {
+	const none = "—"
+	columns := []string{none}
+	for i, h := range header {
+		columns = append(columns, fmt.Sprintf("%d: %s", i+1, h))
+	}
+
+	guess := guessMapping(header)
+	selects := map[string]*widget.Select{}
+	var items []*widget.FormItem
+	for _, f := range csvFields {
+		sel := widget.NewSelect(columns, nil)
+		sel.SetSelected(none)
+		if i, ok := guess[f]; ok {
+			sel.SetSelected(columns[i+1])
+		}
+		selects[f] = sel
+		items = append(items, widget.NewFormItem(csvFieldLabels[f], sel))
+	}
+
+	const (
+		modeAtomic     = "Всё или ничего"
+		modeBestEffort = "Пропускать ошибочные строки"
+	)
+	mode := widget.NewRadioGroup([]string{modeAtomic, modeBestEffort}, nil)
+	mode.SetSelected(modeAtomic)
+	items = append(items,
+		widget.NewFormItem("Режим", mode),
+		widget.NewFormItem("", widget.NewLabel(fmt.Sprintf("Строк в файле: %d. Длительность — секунды, м:сс или ч:мм:сс.", len(records)))),
+	)
+
+	dialog.ShowForm("Импорт CSV", "Импортировать", "Отмена", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		m := CSVMapping{}
+		for f, sel := range selects {
+			if i := sel.SelectedIndex(); i > 0 {
+				m[f] = i - 1
+			}
+		}
+		atomic := mode.Selected == modeAtomic
+		res, err := importCatalogCSV(records, m, atomic)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		onDone()
+		showImportResult(res, atomic, header, records)
+	}, mainWindow)
+}
+
+// showImportResult показывает итог и предлагает сохранить отчёт об ошибках
+func showImportResult(res *ImportResult, atomic bool, header []string, records [][]string) {
+	summary := widget.NewLabel(describeImport(res, atomic))
+	summary.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(summary)
+	if len(res.Errors) > 0 {
+		reportBtn := widget.NewButtonWithIcon("Сохранить отчёт об ошибках", theme.DocumentSaveIcon(), func() {
+			save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+				if err != nil || w == nil {
+					return
+				}
+				defer w.Close()
+				if err := writeImportErrors(w, header, records, res.Errors); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+			}, mainWindow)
+			save.SetFileName("import-errors.csv")
+			save.Show()
+		})
+		content.Add(reportBtn)
+	}
+	dialog.ShowCustom("Импорт CSV", "Закрыть", content, mainWindow)
+}
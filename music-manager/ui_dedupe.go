@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// DUPLICATES TAB — поиск похожих записей каталога и их слияние
+func createDuplicatesTab() *container.TabItem {
+	var groups []DuplicateGroup
+	var list *widget.List
+
+	// "По звучанию" — треки с совпадающими акустическими отпечатками
+	const bySound = "Треки по звучанию"
+	kindLabels := map[string]string{"Артисты": KindArtist, "Альбомы": KindAlbum, "Треки": KindTrack}
+	kindSelect := widget.NewSelect([]string{"Артисты", "Альбомы", "Треки", bySound}, nil)
+	kindSelect.SetSelected("Артисты")
+	countLabel := widget.NewLabel("")
+
+	// Правая часть: какую запись оставить и какие слить в неё
+	keepRadio := widget.NewRadioGroup(nil, nil)
+	mergeChecks := widget.NewCheckGroup(nil, nil)
+	mergeBtn := widget.NewButtonWithIcon("Слить", theme.ContentPasteIcon(), nil)
+	mergeBtn.Importance = widget.HighImportance
+	mergeBtn.Disable()
+	// Ссылки на страницы записей группы, чтобы сравнить их перед слиянием
+	pages := container.NewVBox()
+	details := container.NewVBox(
+		widget.NewLabelWithStyle("Записи", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		pages,
+		widget.NewLabelWithStyle("Оставить", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		keepRadio,
+		widget.NewLabelWithStyle("Слить в неё", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		mergeChecks,
+		mergeBtn,
+	)
+	details.Hide()
+
+	var search func()
+
+	showGroup := func(g DuplicateGroup) {
+		ids := map[string]int{}
+		var labels []string
+		for _, it := range g.Items {
+			l := fmt.Sprintf("№%d %s", it.ID, duplicateItemLabel(g.Kind, it))
+			ids[l] = it.ID
+			labels = append(labels, l)
+		}
+		showGroupPages(pages, g)
+
+		updateChecks := func(keep string) {
+			var others []string
+			for _, l := range labels {
+				if l != keep {
+					others = append(others, l)
+				}
+			}
+			mergeChecks.Options = others
+			mergeChecks.SetSelected(others)
+			mergeChecks.Refresh()
+		}
+		keepRadio.OnChanged = nil
+		keepRadio.Options = labels
+		keepRadio.SetSelected(labels[0])
+		keepRadio.Required = true
+		keepRadio.OnChanged = updateChecks
+		keepRadio.Refresh()
+		updateChecks(labels[0])
+
+		mergeBtn.OnTapped = func() {
+			keepID := ids[keepRadio.Selected]
+			var merge []int
+			for _, l := range mergeChecks.Selected {
+				merge = append(merge, ids[l])
+			}
+			if len(merge) == 0 {
+				dialog.ShowInformation("Слияние", "Отметьте записи, которые нужно слить.", mainWindow)
+				return
+			}
+			msg := fmt.Sprintf("Слить записей: %d в «%s»?\n\n"+
+				"Альбомы, треки и вхождения в плейлисты всех пользователей перейдут к оставшейся записи,\n"+
+				"а дубликаты будут удалены. Слияние нельзя отменить.", len(merge), keepRadio.Selected)
+			dialog.ShowConfirm("Слияние дубликатов", msg, func(ok bool) {
+				if !ok {
+					return
+				}
+				playlists, err := mergeDuplicates(g.Kind, keepID, merge)
+				if err != nil {
+					dialog.ShowError(err, mainWindow)
+					return
+				}
+				dialog.ShowInformation("Слияние",
+					fmt.Sprintf("Слито записей: %d. Изменено плейлистов: %d.", len(merge), playlists), mainWindow)
+				search()
+			}, mainWindow)
+		}
+		mergeBtn.Enable()
+		details.Show()
+	}
+
+	search = func() {
+		var err error
+		if kindSelect.Selected == bySound {
+			groups, err = findAcousticDuplicates()
+		} else {
+			groups, err = findDuplicates(kindLabels[kindSelect.Selected])
+		}
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		countLabel.SetText(fmt.Sprintf("Найдено групп: %d", len(groups)))
+		list.UnselectAll()
+		details.Hide()
+		mergeBtn.Disable()
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int { return len(groups) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(groups) {
+				return
+			}
+			g := groups[i]
+			o.(*widget.Label).SetText(fmt.Sprintf("%s и ещё %d (сходство %d%%)",
+				g.Items[0].Label, len(g.Items)-1, g.Similarity))
+		},
+	)
+	list.OnSelected = func(i widget.ListItemID) {
+		if i < len(groups) {
+			showGroup(groups[i])
+		}
+	}
+
+	kindSelect.OnChanged = func(string) { search() }
+	searchBtn := widget.NewButtonWithIcon("Найти", theme.SearchIcon(), search)
+	fingerprintBtn := widget.NewButtonWithIcon("Снять отпечатки…", theme.FolderOpenIcon(), func() {
+		showFingerprintFolder(func() {
+			if kindSelect.Selected == bySound {
+				search()
+			} else {
+				kindSelect.SetSelected(bySound) // выбор сам запускает поиск
+			}
+		})
+	})
+	top := container.NewHBox(kindSelect, searchBtn, countLabel, layout.NewSpacer(), fingerprintBtn)
+
+	split := container.NewHSplit(list, container.NewVScroll(details))
+	split.Offset = 0.5
+	return container.NewTabItemWithIcon("Дубликаты", theme.ContentCopyIcon(), container.NewBorder(top, nil, nil, nil, split))
+}
+
+// showGroupPages выводит ссылки на страницы записей группы; у треков — ещё и на их артистов и альбомы
+func showGroupPages(pages *fyne.Container, g DuplicateGroup) {
+	pages.RemoveAll()
+	var infos map[int]TrackInfo
+	if g.Kind == KindTrack {
+		ids := make([]int, len(g.Items))
+		for i, it := range g.Items {
+			ids[i] = it.ID
+		}
+		list, _ := repo.GetTrackInfos(ids)
+		infos = map[int]TrackInfo{}
+		for _, t := range list {
+			infos[t.ID] = t
+		}
+	}
+	for _, it := range g.Items {
+		label := fmt.Sprintf("№%d %s", it.ID, it.Label)
+		switch g.Kind {
+		case KindArtist:
+			pages.Add(pageLink(label, func() { showArtistPage(it.ID) }))
+		case KindAlbum:
+			pages.Add(pageLink(label, func() { showAlbumPage(it.ID) }))
+		default:
+			links := newTrackLinks()
+			setTrackLinks(links, infos[it.ID])
+			pages.Add(container.NewBorder(nil, nil, nil, links, pageLink(label, func() { showTrackPage(it.ID) })))
+		}
+	}
+}
+
+// showFingerprintFolder снимает отпечатки с файлов выбранной папки в фоне, с индикатором хода
+func showFingerprintFolder(onDone func()) {
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil || dir == nil {
+			return
+		}
+		bar := widget.NewProgressBar()
+		progress := dialog.NewCustomWithoutButtons("Снятие отпечатков", bar, mainWindow)
+		progress.Show()
+		go func() {
+			rep, err := fingerprintFolder(dir.Path(), func(done, total int) {
+				fyne.Do(func() { bar.SetValue(float64(done) / float64(total)) })
+			})
+			fyne.Do(func() {
+				progress.Hide()
+				if err != nil {
+					dialog.ShowError(err, mainWindow)
+					return
+				}
+				text := widget.NewLabel(describeFingerprintReport(rep))
+				text.Wrapping = fyne.TextWrapWord
+				scroll := container.NewVScroll(text)
+				scroll.SetMinSize(fyne.NewSize(560, 320))
+				dialog.ShowCustom("Отпечатки", "Закрыть", scroll, mainWindow)
+				onDone()
+			})
+		}()
+	}, mainWindow)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Страницы артиста, альбома и трека открываются в одном окне. Переход по ссылке добавляет
+// страницу в цепочку, хлебные крошки над страницей возвращают к любой из предыдущих.
+
+// pagePlaylist — страница плейлиста в той же цепочке; в каталоге такого вида записей нет
+const pagePlaylist = "playlist"
+
+type catalogPage struct {
+	kind  string
+	id    int
+	title string // заполняется при построении страницы
+}
+
+type catalogNavigator struct {
+	stack   []catalogPage
+	crumbs  *fyne.Container
+	content *fyne.Container
+}
+
+func showArtistPage(id int) { showCatalogPage(KindArtist, id) }
+func showAlbumPage(id int)  { showCatalogPage(KindAlbum, id) }
+func showTrackPage(id int)  { showCatalogPage(KindTrack, id) }
+
+func showCatalogPage(kind string, id int) {
+	nav := &catalogNavigator{crumbs: container.NewHBox(), content: container.NewStack()}
+	nav.open(kind, id)
+	d := dialog.NewCustom("Каталог", "Закрыть", container.NewBorder(nav.crumbs, nil, nil, nil, nav.content), mainWindow)
+	d.Resize(fyne.NewSize(720, 560))
+	d.Show()
+}
+
+// open показывает страницу; если она уже есть в цепочке — возвращается к ней
+func (n *catalogNavigator) open(kind string, id int) {
+	for i, p := range n.stack {
+		if p.kind == kind && p.id == id {
+			n.stack = n.stack[:i+1]
+			n.render()
+			return
+		}
+	}
+	n.stack = append(n.stack, catalogPage{kind: kind, id: id})
+	n.render()
+}
+
+func (n *catalogNavigator) back(to int) {
+	n.stack = n.stack[:to+1]
+	n.render()
+}
+
+func (n *catalogNavigator) render() {
+	page := &n.stack[len(n.stack)-1]
+	var obj fyne.CanvasObject
+	var err error
+	switch page.kind {
+	case KindArtist:
+		page.title, obj, err = n.artistPage(page.id)
+	case KindAlbum:
+		page.title, obj, err = n.albumPage(page.id)
+	case pagePlaylist:
+		page.title, obj, err = n.playlistPage(page.id)
+	default:
+		page.title, obj, err = n.trackPage(page.id)
+	}
+	if err != nil {
+		page.title = "Ошибка"
+		obj = widget.NewLabel(err.Error())
+	}
+	n.content.Objects = []fyne.CanvasObject{container.NewVScroll(obj)}
+	n.content.Refresh()
+
+	n.crumbs.RemoveAll()
+	backBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() { n.back(len(n.stack) - 2) })
+	setEnabled(backBtn, len(n.stack) > 1)
+	n.crumbs.Add(backBtn)
+	for i, p := range n.stack {
+		if i > 0 {
+			n.crumbs.Add(widget.NewLabel("›"))
+		}
+		if i == len(n.stack)-1 {
+			n.crumbs.Add(widget.NewLabelWithStyle(p.title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			continue
+		}
+		btn := widget.NewButton(p.title, func() { n.back(i) })
+		btn.Importance = widget.LowImportance
+		n.crumbs.Add(btn)
+	}
+}
+
+// link — ссылка на другую страницу каталога
+func (n *catalogNavigator) link(text, kind string, id int) *widget.Button {
+	return pageLink(text, func() { n.open(kind, id) })
+}
+
+// pageLink — кнопка, которая выглядит как ссылка
+func pageLink(text string, open func()) *widget.Button {
+	btn := widget.NewButton(text, open)
+	btn.Alignment = widget.ButtonAlignLeading
+	btn.Importance = widget.LowImportance
+	return btn
+}
+
+// newTrackLinks — ссылки на страницы артиста и альбома трека для списков вне каталога;
+// пока не вызван setTrackLinks, скрыты
+func newTrackLinks() *fyne.Container {
+	box := container.NewHBox(pageLink("", nil), pageLink("", nil))
+	box.Hide()
+	return box
+}
+
+// setTrackLinks направляет ссылки на артиста и альбом трека t; без альбома остаётся
+// только ссылка на артиста, без артиста ссылки скрываются
+func setTrackLinks(box *fyne.Container, t TrackInfo) {
+	if t.ArtistID == 0 {
+		box.Hide()
+		return
+	}
+	artist, album := box.Objects[0].(*widget.Button), box.Objects[1].(*widget.Button)
+	artist.SetText(t.ArtistName)
+	artist.OnTapped = func() { showArtistPage(t.ArtistID) }
+	if t.AlbumID != 0 {
+		album.SetText("«" + t.AlbumTitle + "»")
+		album.OnTapped = func() { showAlbumPage(t.AlbumID) }
+		album.Show()
+	} else {
+		album.Hide()
+	}
+	box.Show()
+}
+
+func sectionLabel(text string) *widget.Label {
+	return widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+// --- ARTIST ---
+
+func (n *catalogNavigator) artistPage(id int) (string, fyne.CanvasObject, error) {
+	d, err := getArtistDetails(id)
+	if err != nil {
+		return "", nil, err
+	}
+	tracks := 0
+	for _, a := range d.Albums {
+		tracks += a.Tracks
+	}
+	page := container.NewVBox(
+		widget.NewLabelWithStyle(d.Artist.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel(fmt.Sprintf("Альбомов: %d · треков: %d", len(d.Albums), tracks)),
+		sectionLabel("Дискография"),
+	)
+	if len(d.Albums) == 0 {
+		page.Add(widget.NewLabel("Альбомов нет"))
+	}
+	for _, group := range albumsByYear(d.Albums) {
+		year := "Год не указан"
+		if group[0].Year != 0 {
+			year = fmt.Sprint(group[0].Year)
+		}
+		page.Add(widget.NewLabel(year))
+		for _, a := range group {
+			page.Add(n.link(fmt.Sprintf("%s — треков: %d, %s", a.Title, a.Tracks, formatDuration(a.Duration)), KindAlbum, a.ID))
+		}
+	}
+
+	page.Add(sectionLabel("Популярные треки"))
+	if len(d.TopTracks) == 0 {
+		page.Add(widget.NewLabel("Треки артиста ещё не слушали"))
+	}
+	for i, t := range d.TopTracks {
+		page.Add(n.link(fmt.Sprintf("%d. %s — %s · прослушиваний: %d", i+1, t.Title, t.AlbumTitle, t.Plays), KindTrack, t.ID))
+	}
+
+	page.Add(sectionLabel("В плейлистах"))
+	if len(d.Playlists) == 0 {
+		page.Add(widget.NewLabel("Нет в доступных вам плейлистах"))
+	}
+	for _, p := range d.Playlists {
+		page.Add(n.link(fmt.Sprintf("%s (%s) — треков артиста: %d", p.Title, p.OwnerName, p.Tracks), pagePlaylist, p.ID))
+	}
+	return "Артист «" + d.Artist.Name + "»", page, nil
+}
+
+// --- ALBUM ---
+
+func (n *catalogNavigator) albumPage(id int) (string, fyne.CanvasObject, error) {
+	d, err := getAlbumDetails(id)
+	if err != nil {
+		return "", nil, err
+	}
+	var cover fyne.CanvasObject
+	if d.CoverPath != "" {
+		img := canvas.NewImageFromFile(d.CoverPath)
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(180, 180))
+		cover = img
+	} else {
+		icon := widget.NewIcon(theme.MediaMusicIcon())
+		cover = container.NewGridWrap(fyne.NewSize(180, 180), icon)
+	}
+
+	year := ""
+	if d.Year != 0 {
+		year = fmt.Sprint(d.Year)
+	}
+	credits := widget.NewLabel(orDash(d.Credits))
+	credits.Wrapping = fyne.TextWrapWord
+	form := widget.NewForm(
+		widget.NewFormItem("Альбом", widget.NewLabel(d.Title)),
+		widget.NewFormItem("Артист", n.link(d.ArtistName, KindArtist, d.ArtistID)),
+		widget.NewFormItem("Год", widget.NewLabel(orDash(year))),
+		widget.NewFormItem("Треков", widget.NewLabel(fmt.Sprint(len(d.Tracks)))),
+		widget.NewFormItem("Длительность", widget.NewLabel(formatDuration(d.Duration))),
+		widget.NewFormItem("Участники", credits),
+	)
+	info := container.NewVBox(form)
+	if canEditCatalog() {
+		info.Add(widget.NewButtonWithIcon("Изменить участников", theme.DocumentCreateIcon(), func() {
+			entry := widget.NewMultiLineEntry()
+			entry.SetText(d.Credits)
+			entry.SetPlaceHolder("Продюсер: …\nЗвукорежиссёр: …")
+			entry.SetMinRowsVisible(5)
+			dialog.ShowForm("Участники альбома", "Сохранить", "Отмена",
+				[]*widget.FormItem{widget.NewFormItem("", entry)},
+				func(ok bool) {
+					if !ok {
+						return
+					}
+					if err := setAlbumCredits(d.Album, entry.Text); err != nil {
+						dialog.ShowError(err, mainWindow)
+						return
+					}
+					notifyDataChanged()
+					n.render()
+				}, mainWindow)
+		}))
+	}
+
+	page := container.NewVBox(container.NewBorder(nil, nil, cover, nil, info), sectionLabel("Треки"))
+	if len(d.Tracks) == 0 {
+		page.Add(widget.NewLabel("Треков нет"))
+	}
+	for i, t := range d.Tracks {
+		number := t.Number
+		if number == 0 {
+			number = i + 1
+		}
+		page.Add(n.link(fmt.Sprintf("%d. %s (%s)", number, t.Title, formatDuration(t.Duration)), KindTrack, t.ID))
+	}
+	return "Альбом «" + d.Title + "»", page, nil
+}
+
+// --- PLAYLIST ---
+
+// playlistPage — состав плейлиста со ссылками на треки, артистов и альбомы
+func (n *catalogNavigator) playlistPage(id int) (string, fyne.CanvasObject, error) {
+	d, err := getPlaylistDetails(id)
+	if err != nil {
+		return "", nil, err
+	}
+	page := container.NewVBox(
+		widget.NewLabelWithStyle(d.Title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel(fmt.Sprintf("Треков: %d · %s · артистов: %d", len(d.Tracks), formatDuration(d.Duration), d.Artists)),
+		sectionLabel("Треки"),
+	)
+	if len(d.Tracks) == 0 {
+		page.Add(widget.NewLabel("Треков нет"))
+	}
+	for i, t := range d.Tracks {
+		page.Add(container.NewHBox(
+			n.link(fmt.Sprintf("%d. %s (%s)", i+1, t.Title, formatDuration(t.Duration)), KindTrack, t.ID),
+			n.link(t.ArtistName, KindArtist, t.ArtistID),
+			n.link("«"+t.AlbumTitle+"»", KindAlbum, t.AlbumID),
+		))
+	}
+	return "Плейлист «" + d.Title + "»", page, nil
+}
+
+// --- TRACK ---
+
+// trackPage — карточка трека: данные каталога, файл, громкость и оценка
+func (n *catalogNavigator) trackPage(id int) (string, fyne.CanvasObject, error) {
+	infos, err := repo.GetTrackInfos([]int{id})
+	if err != nil || len(infos) == 0 {
+		return "", nil, fmt.Errorf("трек не найден")
+	}
+	ti := infos[0]
+	audio, err := trackAudioInfo(id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	loudness := func(l *Loudness) string {
+		if l == nil {
+			return "не измерена"
+		}
+		return fmt.Sprintf("%.1f LUFS, пик %.1f dBTP, ReplayGain %+.2f dB", l.LUFS, l.TruePeak, l.Gain)
+	}
+	number := ""
+	if ti.Number > 0 {
+		number = fmt.Sprint(ti.Number)
+	}
+	tempo, key := "", keyLabel(ti.Key)
+	if ti.BPM > 0 {
+		tempo = fmt.Sprintf("%g BPM", ti.BPM)
+	}
+	year := ""
+	if ti.Year > 0 {
+		year = fmt.Sprint(ti.Year)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Название", widget.NewLabel(ti.Title)),
+		widget.NewFormItem("Артист", n.link(ti.ArtistName, KindArtist, ti.ArtistID)),
+		widget.NewFormItem("Альбом", n.link(ti.AlbumTitle, KindAlbum, ti.AlbumID)),
+		widget.NewFormItem("Год", widget.NewLabel(orDash(year))),
+		widget.NewFormItem("Номер", widget.NewLabel(orDash(number))),
+		widget.NewFormItem("Длительность", widget.NewLabel(formatDuration(ti.Duration))),
+		widget.NewFormItem("Жанр", widget.NewLabel(orDash(ti.Genre))),
+		widget.NewFormItem("Темп", widget.NewLabel(orDash(tempo))),
+		widget.NewFormItem("Тональность", widget.NewLabel(orDash(key))),
+		widget.NewFormItem("Файл", widget.NewLabel(orDash(audio.FilePath))),
+		widget.NewFormItem("Громкость трека", widget.NewLabel(loudness(audio.TrackLoudness))),
+		widget.NewFormItem("Громкость альбома", widget.NewLabel(loudness(audio.AlbumLoudness))),
+	)
+	// Оценка текущего пользователя сохраняется сразу
+	ratingOptions := []string{"—", "★", "★★", "★★★", "★★★★", "★★★★★"}
+	ratingSelect := widget.NewSelect(ratingOptions, nil)
+	ratingSelect.SetSelectedIndex(getTrackRating(id))
+	ratingSelect.OnChanged = func(string) {
+		if err := rateTrack(id, ratingSelect.SelectedIndex()); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		notifyDataChanged()
+	}
+	form.Append("Ваша оценка", ratingSelect)
+	return "Трек «" + ti.Title + "»", form, nil
+}
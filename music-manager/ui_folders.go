@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Очередь "воспроизвести всё" для папки с выбором порядка
+func showFolderPlayQueue(t *playlistTree, folderID int) {
+	var queue []TrackInfo
+	var list *widget.List
+	summary := widget.NewLabel("")
+
+	load := func(order string) {
+		var err error
+		queue, err = folderPlayQueue(t, folderID, order)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		total := 0
+		for _, tr := range queue {
+			total += tr.Duration
+		}
+		summary.SetText(fmt.Sprintf("Треков: %d, общая длительность %s", len(queue), formatDuration(total)))
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int { return len(queue) },
+		// Строка: номер и название, справа ссылки на артиста и альбом
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, newTrackLinks(), widget.NewLabel(""))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			tr := queue[i]
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%d. %s (%s)", i+1, tr.Title, formatDuration(tr.Duration)))
+			setTrackLinks(row.Objects[1].(*fyne.Container), tr)
+		},
+	)
+
+	// Выбор трека в очереди — его воспроизведение; прослушивание идёт в историю для рекомендаций
+	nowPlaying := widget.NewLabel("")
+	list.OnSelected = func(i widget.ListItemID) {
+		if i >= len(queue) {
+			return
+		}
+		tr := queue[i]
+		if err := recordPlay(tr.ID); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		nowPlaying.SetText("Сейчас играет: " + tr.ArtistName + " — " + tr.Title)
+	}
+
+	orderSelect := widget.NewSelect(playOrderOptions, func(order string) { load(order) })
+	orderSelect.SetSelected(PlayOrderSequential)
+
+	content := container.NewBorder(container.NewVBox(orderSelect, summary), nowPlaying, nil, nil, list)
+	d := dialog.NewCustom("Воспроизвести всё: "+t.folderPath(folderID), "Закрыть", content, mainWindow)
+	d.Resize(fyne.NewSize(760, 450))
+	d.Show()
+}
+
+// Экспорт одного плейлиста в M3U или XSPF
+func showExportPlaylist(p Playlist) {
+	formats := map[string]string{"M3U": ExportM3U, "XSPF": ExportXSPF}
+	formatRadio := widget.NewRadioGroup([]string{"M3U", "XSPF"}, nil)
+	formatRadio.SetSelected("M3U")
+	formatRadio.Required = true
+	dialog.ShowForm("Экспорт плейлиста", "Сохранить…", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("Формат", formatRadio)},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			format := formats[formatRadio.Selected]
+			save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+				if err != nil || w == nil {
+					return
+				}
+				defer w.Close()
+				if err := exportPlaylist(p, format, w); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+			}, mainWindow)
+			save.SetFileName(safeFileName(p.Title) + "." + format)
+			save.Show()
+		}, mainWindow)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showGeneratorDialog — условия плейлиста заданной длительности
+func showGeneratorDialog(onDone func()) {
+	tracks, _ := getTracks()
+	artists, artistNames := getArtists()
+
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder(durationPlaceholder)
+	targetEntry.SetText("45:00")
+	toleranceEntry := widget.NewEntry()
+	toleranceEntry.SetText("60")
+	minEntry := widget.NewEntry()
+	minEntry.SetPlaceHolder(durationPlaceholder)
+	maxEntry := widget.NewEntry()
+	maxEntry.SetPlaceHolder(durationPlaceholder)
+	gapEntry := widget.NewEntry()
+	gapEntry.SetText("3")
+	genreChecks := widget.NewCheckGroup(trackGenres(tracks), nil)
+	artistChecks := widget.NewCheckGroup(artistNames, nil)
+	scrolled := func(o fyne.CanvasObject) fyne.CanvasObject {
+		s := container.NewVScroll(o)
+		s.SetMinSize(fyne.NewSize(300, 100))
+		return s
+	}
+
+	dialog.ShowForm("Плейлист по длительности", "Подобрать", "Отмена", []*widget.FormItem{
+		widget.NewFormItem("Длительность", targetEntry),
+		widget.NewFormItem("Допуск, секунд", toleranceEntry),
+		widget.NewFormItem("Жанры", scrolled(genreChecks)),
+		widget.NewFormItem("Артисты", scrolled(artistChecks)),
+		widget.NewFormItem("Трек не короче", minEntry),
+		widget.NewFormItem("Трек не длиннее", maxEntry),
+		widget.NewFormItem("Артист не чаще чем", gapEntry),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		opts, err := parseGenerateOptions(targetEntry.Text, toleranceEntry.Text, minEntry.Text, maxEntry.Text, gapEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		opts.Genres = genreChecks.Selected
+		for i, n := range artistNames {
+			for _, s := range artistChecks.Selected {
+				if s == n {
+					opts.ArtistIDs = append(opts.ArtistIDs, artists[i].ID)
+				}
+			}
+		}
+		showGeneratedPreview(opts, onDone)
+	}, mainWindow)
+}
+
+func parseGenerateOptions(target, tolerance, minLen, maxLen, gap string) (GenerateOptions, error) {
+	var opts GenerateOptions
+	var err error
+	if opts.Target, err = parseDuration(target); err != nil {
+		return opts, err
+	}
+	if opts.Tolerance, err = strconv.Atoi(strings.TrimSpace(tolerance)); err != nil {
+		return opts, fmt.Errorf("допуск — целое число секунд")
+	}
+	if opts.MinLength, err = parseDuration(minLen); err != nil {
+		return opts, err
+	}
+	if opts.MaxLength, err = parseDuration(maxLen); err != nil {
+		return opts, err
+	}
+	if strings.TrimSpace(gap) != "" {
+		if opts.ArtistGap, err = strconv.Atoi(strings.TrimSpace(gap)); err != nil || opts.ArtistGap < 0 {
+			return opts, fmt.Errorf("повтор артиста — целое число треков")
+		}
+	}
+	return opts, nil
+}
+
+// showGeneratedPreview показывает подобранные треки; их можно подобрать заново или сохранить
+func showGeneratedPreview(opts GenerateOptions, onDone func()) {
+	var g *GeneratedPlaylist
+	summary := widget.NewLabel("")
+	summary.Wrapping = fyne.TextWrapWord
+	list := widget.NewList(
+		func() int {
+			if g == nil {
+				return 0
+			}
+			return len(g.Tracks)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			t := g.Tracks[i]
+			o.(*widget.Label).SetText(fmt.Sprintf("%d. %s — %s (%s)", i+1, t.ArtistName, t.Title, formatDuration(t.Duration)))
+		},
+	)
+	list.OnSelected = func(i widget.ListItemID) {
+		list.Unselect(i)
+		showTrackPage(g.Tracks[i].ID)
+	}
+	titleEntry := widget.NewEntry()
+	titleEntry.SetText("Плейлист на " + formatDuration(opts.Target))
+
+	generate := func() bool {
+		var err error
+		if g, err = generatePlaylist(opts); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return false
+		}
+		text := fmt.Sprintf("Треков: %d, общая длительность %s (цель %s).",
+			len(g.Tracks), formatDuration(g.Total), formatDuration(opts.Target))
+		if !g.Fits {
+			text += " Точнее подобрать не удалось — ослабьте условия или увеличьте допуск."
+		}
+		summary.SetText(text)
+		list.Refresh()
+		return true
+	}
+	if !generate() {
+		return
+	}
+
+	var d dialog.Dialog
+	againBtn := widget.NewButton("Подобрать заново", func() { generate() })
+	saveBtn := widget.NewButton("Сохранить", func() {
+		title, err := saveGeneratedPlaylist(titleEntry.Text, g)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		d.Hide()
+		onDone()
+		dialog.ShowInformation("Готово", "Создан плейлист «"+title+"»", mainWindow)
+	})
+	saveBtn.Importance = widget.HighImportance
+	bottom := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Название"), nil, titleEntry),
+		container.NewHBox(againBtn, saveBtn),
+	)
+	content := container.NewBorder(summary, bottom, nil, nil, list)
+	d = dialog.NewCustom("Предпросмотр", "Отмена", content, mainWindow)
+	d.Resize(fyne.NewSize(600, 500))
+	d.Show()
+}
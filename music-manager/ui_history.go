@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Длинные описания действий обрезаются, чтобы кнопки не раздвигали панель
+const historyLabelMax = 40
+
+func shortLabel(s string) string {
+	r := []rune(s)
+	if len(r) > historyLabelMax {
+		return string(r[:historyLabelMax-1]) + "…"
+	}
+	return s
+}
+
+// Сочетания клавиш работают и на экране входа, поэтому пустую историю молча пропускаем
+func undoAction() {
+	if undoLabel() == "" {
+		return
+	}
+	if err := undoLast(); err != nil {
+		dialog.ShowError(err, mainWindow)
+	}
+}
+
+func redoAction() {
+	if redoLabel() == "" {
+		return
+	}
+	if err := redoLast(); err != nil {
+		dialog.ShowError(err, mainWindow)
+	}
+}
+
+// historyToolbar — кнопки "Отменить"/"Повторить" с описанием действия
+func historyToolbar() fyne.CanvasObject {
+	undoBtn := widget.NewButtonWithIcon("Отменить", theme.ContentUndoIcon(), undoAction)
+	redoBtn := widget.NewButtonWithIcon("Повторить", theme.ContentRedoIcon(), redoAction)
+	update := func() {
+		undoBtn.SetText("Отменить")
+		if l := undoLabel(); l != "" {
+			undoBtn.SetText("Отменить: " + shortLabel(l))
+		}
+		redoBtn.SetText("Повторить")
+		if l := redoLabel(); l != "" {
+			redoBtn.SetText("Повторить: " + shortLabel(l))
+		}
+		setEnabled(undoBtn, undoLabel() != "")
+		setEnabled(redoBtn, redoLabel() != "")
+	}
+	onHistoryChanged(update)
+	update()
+	return container.NewHBox(layout.NewSpacer(), undoBtn, redoBtn)
+}
+
+// bindHistoryShortcuts — Ctrl+Z отменяет, Ctrl+Shift+Z (и Ctrl+Y) повторяет.
+// В поле ввода эти сочетания отменяют правку текста, а не действие с данными.
+func bindHistoryShortcuts() {
+	c := mainWindow.Canvas()
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { undoAction() })
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift},
+		func(fyne.Shortcut) { redoAction() })
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyY, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { redoAction() })
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showLoudnessDialog запускает измерение громкости в фоне, с индикатором хода
+func showLoudnessDialog(onDone func()) {
+	onlyMissing := widget.NewCheck("Только альбомы с неизмеренными треками", nil)
+	onlyMissing.SetChecked(true)
+	writeTags := widget.NewCheck("Записать теги ReplayGain в файлы", nil)
+	note := widget.NewLabel("Измеряются треки, к которым привязаны файлы (при снятии отпечатков).")
+	note.Wrapping = fyne.TextWrapWord
+
+	dialog.ShowCustomConfirm("Громкость и ReplayGain", "Измерить", "Отмена",
+		container.NewVBox(note, onlyMissing, writeTags),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			bar := widget.NewProgressBar()
+			progress := dialog.NewCustomWithoutButtons("Измерение громкости", bar, mainWindow)
+			progress.Show()
+			go func() {
+				rep, err := analyzeLoudnessJob(onlyMissing.Checked, writeTags.Checked, func(done, total int) {
+					fyne.Do(func() { bar.SetValue(float64(done) / float64(total)) })
+				})
+				fyne.Do(func() {
+					progress.Hide()
+					if err != nil {
+						dialog.ShowError(err, mainWindow)
+						return
+					}
+					text := widget.NewLabel(describeLoudnessReport(rep))
+					text.Wrapping = fyne.TextWrapWord
+					scroll := container.NewVScroll(text)
+					scroll.SetMinSize(fyne.NewSize(560, 320))
+					dialog.ShowCustom("Громкость", "Закрыть", scroll, mainWindow)
+					onDone()
+				})
+			}()
+		}, mainWindow)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showMetadataDialog — выбор альбома, поиск релиза в сервисе метаданных и приём правок по полям
+func showMetadataDialog(onDone func()) {
+	albums, _ := getAlbums()
+	artists, _ := getArtists()
+	artistNames := map[int]string{}
+	for _, a := range artists {
+		artistNames[a.ID] = a.Name
+	}
+	var labels []string
+	for _, a := range albums {
+		labels = append(labels, fmt.Sprintf("%s — %s (%d)", artistNames[a.ArtistID], a.Title, a.Year))
+	}
+	albumSelect := widget.NewSelect(labels, nil)
+	albumSelect.PlaceHolder = "Альбом"
+
+	releaseLabel := widget.NewLabel("")
+	releaseLabel.Wrapping = fyne.TextWrapWord
+	changesBox := container.NewVBox()
+	var checks []*widget.Check
+	var changes []MetadataChange
+
+	var d dialog.Dialog
+	applyBtn := widget.NewButton("Применить отмеченные", func() {
+		var accepted []MetadataChange
+		for i, c := range checks {
+			if c.Checked {
+				accepted = append(accepted, changes[i])
+			}
+		}
+		if err := applyMetadata(accepted); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		d.Hide()
+		onDone()
+	})
+	applyBtn.Importance = widget.HighImportance
+	applyBtn.Disable()
+
+	showMatch := func(m *MetadataMatch) {
+		changesBox.RemoveAll()
+		checks, changes = nil, nil
+		switch {
+		case m == nil:
+			releaseLabel.SetText("Ни релиз, ни артист, ни записи в сервисе не найдены.")
+		case len(m.Changes) == 0:
+			releaseLabel.SetText(m.source() + "\nДанные совпадают, править нечего.")
+		default:
+			releaseLabel.SetText(m.source())
+			changes = m.Changes
+			for _, c := range changes {
+				check := widget.NewCheck(describeMetadataChange(c), nil)
+				check.SetChecked(true)
+				checks = append(checks, check)
+				changesBox.Add(check)
+			}
+		}
+		setEnabled(applyBtn, len(changes) > 0)
+	}
+
+	// Сервис отвечает не сразу (и не чаще раза в секунду), поэтому ищем в фоне
+	findBtn := widget.NewButton("Найти", func() {
+		i := albumSelect.SelectedIndex()
+		if i < 0 {
+			return
+		}
+		albumID := albums[i].ID
+		progress := dialog.NewCustomWithoutButtons("Поиск", widget.NewProgressBarInfinite(), mainWindow)
+		progress.Show()
+		go func() {
+			m, err := proposeAlbumMetadata(albumID)
+			fyne.Do(func() {
+				progress.Hide()
+				if err != nil {
+					dialog.ShowError(err, mainWindow)
+					return
+				}
+				showMatch(m)
+			})
+		}()
+	})
+
+	content := container.NewBorder(
+		container.NewVBox(container.NewBorder(nil, nil, nil, findBtn, albumSelect), releaseLabel),
+		applyBtn, nil, nil,
+		container.NewVScroll(changesBox),
+	)
+	d = dialog.NewCustom("Уточнить метаданные", "Закрыть", content, mainWindow)
+	d.Resize(fyne.NewSize(640, 480))
+	d.Show()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showTempoDialog определяет темп и тональность треков в фоне, с индикатором хода
+func showTempoDialog(onDone func()) {
+	onlyMissing := widget.NewCheck("Только треки без темпа", nil)
+	onlyMissing.SetChecked(true)
+	note := widget.NewLabel("Анализируются треки, к которым привязаны файлы (при снятии отпечатков).")
+	note.Wrapping = fyne.TextWrapWord
+
+	dialog.ShowCustomConfirm("Темп и тональность", "Определить", "Отмена",
+		container.NewVBox(note, onlyMissing),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			bar := widget.NewProgressBar()
+			progress := dialog.NewCustomWithoutButtons("Определение темпа и тональности", bar, mainWindow)
+			progress.Show()
+			go func() {
+				rep, err := analyzeTempoJob(onlyMissing.Checked, func(done, total int) {
+					fyne.Do(func() { bar.SetValue(float64(done) / float64(total)) })
+				})
+				fyne.Do(func() {
+					progress.Hide()
+					if err != nil {
+						dialog.ShowError(err, mainWindow)
+						return
+					}
+					text := widget.NewLabel(describeTempoReport(rep))
+					text.Wrapping = fyne.TextWrapWord
+					scroll := container.NewVScroll(text)
+					scroll.SetMinSize(fyne.NewSize(560, 320))
+					dialog.ShowCustom("Темп и тональность", "Закрыть", scroll, mainWindow)
+					onDone()
+				})
+			}()
+		}, mainWindow)
+}
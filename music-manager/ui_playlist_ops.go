@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// accessiblePlaylists — все плейлисты дерева (свои, доступные и подписки) в алфавитном порядке
+func accessiblePlaylists(t *playlistTree) ([]Playlist, []string) {
+	seen := map[int]bool{}
+	var items []Playlist
+	for _, p := range t.playlists {
+		if !seen[p.ID] {
+			seen[p.ID] = true
+			items = append(items, p)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return playlistLabel(items[i]) < playlistLabel(items[j]) })
+	var names []string
+	for _, p := range items {
+		names = append(names, playlistLabel(p))
+	}
+	return items, names
+}
+
+// Меню операций над плейлистами: дублирование, слияние, разбиение, операции над множествами
+func showPlaylistOps(t *playlistTree, selected *Playlist, onDone func()) {
+	items, names := accessiblePlaylists(t)
+	done := func(err error, msg string) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		onDone()
+		dialog.ShowInformation("Готово", msg, mainWindow)
+	}
+
+	duplicateBtn := widget.NewButton("Дублировать выбранный", func() {
+		if selected == nil {
+			dialog.ShowInformation("Внимание", "Выберите плейлист", mainWindow)
+			return
+		}
+		title, err := duplicatePlaylist(*selected)
+		done(err, "Создан плейлист «"+title+"»")
+	})
+
+	mergeBtn := widget.NewButton("Объединить несколько…", func() {
+		sources := widget.NewCheckGroup(names, nil)
+		titleEntry := widget.NewEntry()
+		dedupCheck := widget.NewCheck("Убрать повторы (одинаковое название и артист)", nil)
+		dialog.ShowForm("Объединение плейлистов", "Объединить", "Отмена", []*widget.FormItem{
+			widget.NewFormItem("Плейлисты", sources),
+			widget.NewFormItem("Название", titleEntry),
+			widget.NewFormItem("", dedupCheck),
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			var chosen []Playlist
+			for i, n := range names {
+				for _, s := range sources.Selected {
+					if s == n {
+						chosen = append(chosen, items[i])
+					}
+				}
+			}
+			done(mergePlaylists(chosen, titleEntry.Text, dedupCheck.Checked), "Создан плейлист «"+titleEntry.Text+"»")
+		}, mainWindow)
+	})
+
+	splitBtn := widget.NewButton("Разбить выбранный…", func() {
+		if selected == nil {
+			dialog.ShowInformation("Внимание", "Выберите плейлист", mainWindow)
+			return
+		}
+		p := *selected
+		var modeNames []string
+		for _, m := range splitModeOptions {
+			modeNames = append(modeNames, splitModeLabels[m])
+		}
+		modeSelect := widget.NewSelect(modeNames, nil)
+		modeSelect.SetSelectedIndex(0)
+		limitEntry := widget.NewEntry()
+		limitEntry.SetPlaceHolder("Размер части")
+		dialog.ShowForm("Разбиение «"+p.Title+"»", "Разбить", "Отмена", []*widget.FormItem{
+			widget.NewFormItem("Способ", modeSelect),
+			widget.NewFormItem("Не больше", limitEntry),
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			limit, err := strconv.Atoi(limitEntry.Text)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("размер части должен быть числом"), mainWindow)
+				return
+			}
+			n, err := splitPlaylist(p, splitModeOptions[modeSelect.SelectedIndex()], limit)
+			done(err, fmt.Sprintf("Создано частей: %d", n))
+		}, mainWindow)
+	})
+
+	setOpBtn := widget.NewButton("Объединение / пересечение / разность…", func() {
+		aSelect := widget.NewSelect(names, nil)
+		bSelect := widget.NewSelect(names, nil)
+		if selected != nil {
+			aSelect.SetSelected(playlistLabel(*selected))
+		}
+		var opNames []string
+		for _, op := range setOperationOptions {
+			opNames = append(opNames, setOperationLabels[op])
+		}
+		opSelect := widget.NewSelect(opNames, nil)
+		opSelect.SetSelectedIndex(0)
+		titleEntry := widget.NewEntry()
+		dialog.ShowForm("Операции над плейлистами", "Создать", "Отмена", []*widget.FormItem{
+			widget.NewFormItem("A", aSelect),
+			widget.NewFormItem("B", bSelect),
+			widget.NewFormItem("Операция", opSelect),
+			widget.NewFormItem("Название", titleEntry),
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			if aSelect.SelectedIndex() < 0 || bSelect.SelectedIndex() < 0 {
+				dialog.ShowError(fmt.Errorf("выберите плейлисты A и B"), mainWindow)
+				return
+			}
+			err := setOperationPlaylists(items[aSelect.SelectedIndex()], items[bSelect.SelectedIndex()],
+				setOperationOptions[opSelect.SelectedIndex()], titleEntry.Text)
+			done(err, "Создан плейлист «"+titleEntry.Text+"»")
+		}, mainWindow)
+	})
+
+	var d dialog.Dialog
+	// после выбора действия окно меню закрывается, дальше работает диалог действия
+	for _, b := range []*widget.Button{duplicateBtn, mergeBtn, splitBtn, setOpBtn} {
+		action := b.OnTapped
+		b.OnTapped = func() {
+			d.Hide()
+			action()
+		}
+	}
+	d = dialog.NewCustom("Операции с плейлистами", "Закрыть",
+		container.NewVBox(duplicateBtn, mergeBtn, splitBtn, setOpBtn), mainWindow)
+	d.Show()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showSuggestions — "вам может понравиться" для плейлиста с добавлением отмеченных треков
+func showSuggestions(p *Playlist, onDone func(changedByOthers bool)) {
+	recs, err := suggestForPlaylist(p.ID, 30)
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	if len(recs) == 0 {
+		dialog.ShowInformation("Рекомендации",
+			"Пока нечего предложить: добавьте треки в плейлист, оцените треки или укажите жанры.", mainWindow)
+		return
+	}
+	// Отметка у каждой рекомендации своя — одноимённые треки не путаются; рядом ссылки на артиста и альбом
+	rows := container.NewVBox()
+	checks := make([]*widget.Check, len(recs))
+	canAdd := canPlaylist(p.Permission, PermAdd)
+	for i, r := range recs {
+		checks[i] = widget.NewCheck(r.Label, nil)
+		if !canAdd {
+			checks[i].Disable()
+		}
+		links := newTrackLinks()
+		setTrackLinks(links, r.Track)
+		rows.Add(container.NewBorder(nil, nil, nil, links, checks[i]))
+	}
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(760, 400))
+
+	// Без права добавлять треки рекомендации только показываются
+	if !canAdd {
+		dialog.ShowCustom("Вам может понравиться", "Закрыть", scroll, mainWindow)
+		return
+	}
+	dialog.ShowCustomConfirm("Вам может понравиться", "Добавить отмеченные", "Отмена", scroll, func(ok bool) {
+		var selected []int
+		for i, c := range checks {
+			if c.Checked {
+				selected = append(selected, recs[i].TrackID)
+			}
+		}
+		if !ok || len(selected) == 0 {
+			return
+		}
+		addTracksToPlaylist(p, selected, func(added, skipped int, changedByOthers bool) {
+			onDone(changedByOthers)
+			if added < len(selected) {
+				dialog.ShowInformation("Готово", describeBulkAdd(len(selected), added, skipped), mainWindow)
+			}
+		})
+	}, mainWindow)
+}
+
+// showRadioDialog создаёт плейлист-радио от выбранного трека или артиста
+func showRadioDialog(onDone func()) {
+	tracks, _ := getTracks()
+	artists, artistNames := getArtists()
+	var trackNames []string
+	for _, t := range tracks {
+		trackNames = append(trackNames, trackPickerLabel(t))
+	}
+
+	seedSelect := widget.NewSelect(trackNames, nil)
+	seedSelect.PlaceHolder = "Выберите…"
+	kindLabels := []string{"От трека", "От артиста"}
+	kinds := []string{RadioFromTrack, RadioFromArtist}
+	kindRadio := widget.NewRadioGroup(kindLabels, func(s string) {
+		seedSelect.ClearSelected()
+		if s == kindLabels[1] {
+			seedSelect.Options = artistNames
+		} else {
+			seedSelect.Options = trackNames
+		}
+		seedSelect.Refresh()
+	})
+	kindRadio.Horizontal = true
+	kindRadio.Required = true
+	kindRadio.SetSelected(kindLabels[0])
+	// Под выбранным образцом — ссылки на его артиста и альбом
+	links := newTrackLinks()
+	seedSelect.OnChanged = func(string) {
+		i := seedSelect.SelectedIndex()
+		switch {
+		case i < 0:
+			setTrackLinks(links, TrackInfo{})
+		case kindRadio.Selected == kindLabels[1]:
+			setTrackLinks(links, TrackInfo{ArtistID: artists[i].ID, ArtistName: artists[i].Name})
+		default:
+			infos, _ := repo.GetTrackInfos([]int{tracks[i].ID})
+			if len(infos) > 0 {
+				setTrackLinks(links, infos[0])
+			}
+		}
+	}
+	sizeEntry := widget.NewEntry()
+	sizeEntry.SetText("20")
+
+	dialog.ShowForm("Радио", "Создать", "Отмена", []*widget.FormItem{
+		widget.NewFormItem("Образец", kindRadio),
+		widget.NewFormItem("", seedSelect),
+		widget.NewFormItem("", links),
+		widget.NewFormItem("Треков", sizeEntry),
+	}, func(ok bool) {
+		i := seedSelect.SelectedIndex()
+		if !ok || i < 0 {
+			return
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(sizeEntry.Text))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("число треков должно быть целым"), mainWindow)
+			return
+		}
+		kind := kinds[0]
+		seedID := tracks[i].ID
+		if kindRadio.Selected == kindLabels[1] {
+			kind, seedID = kinds[1], artists[i].ID
+		}
+		title, err := createRadioPlaylist(kind, seedID, size)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		onDone()
+		dialog.ShowInformation("Радио", "Создан плейлист «"+title+"»", mainWindow)
+	}, mainWindow)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Участники плейлиста: приглашение по логину, смена прав и исключение
+func showCollaborators(p Playlist) {
+	var collaborators []Collaborator
+	var list *widget.List
+
+	refresh := func() {
+		var err error
+		collaborators, err = getCollaborators(p.ID)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int { return len(collaborators) },
+		func() fyne.CanvasObject {
+			permSelect := widget.NewSelect(permissionOptions, nil)
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			removeBtn.Importance = widget.LowImportance
+			return container.NewHBox(widget.NewLabel(""), layout.NewSpacer(), permSelect, removeBtn)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(collaborators) {
+				return
+			}
+			c := collaborators[i]
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(c.Username)
+
+			permSelect := row.Objects[2].(*widget.Select)
+			permSelect.OnChanged = nil
+			permSelect.SetSelected(c.Permission)
+			permSelect.OnChanged = func(perm string) {
+				if perm == c.Permission {
+					return
+				}
+				if err := inviteCollaborator(p.ID, c.Username, perm); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				refresh()
+			}
+
+			row.Objects[3].(*widget.Button).OnTapped = func() {
+				if err := removeCollaborator(p.ID, c.UserID); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				refresh()
+			}
+		},
+	)
+
+	usernameEntry := widget.NewEntry()
+	usernameEntry.SetPlaceHolder("Логин пользователя")
+	permSelect := widget.NewSelect(permissionOptions, nil)
+	permSelect.SetSelected(PermView)
+	inviteBtn := widget.NewButtonWithIcon("Пригласить", theme.ContentAddIcon(), func() {
+		if err := inviteCollaborator(p.ID, usernameEntry.Text, permSelect.Selected); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		usernameEntry.SetText("")
+		refresh()
+	})
+
+	refresh()
+
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel("view — просмотр, add — добавление треков, edit — полное редактирование"),
+			container.NewBorder(nil, nil, nil, container.NewHBox(permSelect, inviteBtn), usernameEntry),
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		list,
+	)
+	d := dialog.NewCustom("Участники: "+p.Title, "Закрыть", content, mainWindow)
+	d.Resize(fyne.NewSize(600, 400))
+	d.Show()
+}
+
+// Код ссылки на плейлист "по ссылке": владелец передаёт его тем, кому хочет показать плейлист
+func showPlaylistLink(p Playlist) {
+	token, err := getPlaylistLink(p.ID)
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	tokenEntry := widget.NewEntry()
+	tokenEntry.SetText(token)
+	copyBtn := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(token)
+	})
+	dialog.ShowCustom("Ссылка: "+p.Title, "Закрыть", container.NewVBox(
+		widget.NewLabel("Плейлист откроют по этому коду на вкладке «Обзор».\nЕсли снова сделать плейлист доступным по ссылке, код сменится."),
+		container.NewBorder(nil, nil, nil, copyBtn, tokenEntry),
+	), mainWindow)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// catalogTable — таблица каталога: флажок выбора, колонки данных и кнопки действий в последней колонке.
+// Клик по заголовку сортирует по колонке (повторный — в обратном порядке), ширина меняется
+// перетаскиванием границы в заголовке. Видимые колонки, ширины и сортировка сохраняются для пользователя.
+type catalogTable struct {
+	widget.Table
+	name    string
+	columns []tableColumn
+	visible []tableColumn
+	layout  TableLayout
+	rows    func() int
+	value   func(row int, key string) string
+	sel     *multiSelection
+	idAt    func(int) int
+	actions []tableAction
+	// шаблоны заголовков по номерам колонок — по их размеру читаются ширины после перетаскивания
+	headers map[int]*widget.Button
+
+	checkWidth, actionsWidth float32
+
+	OnSortChanged func()
+}
+
+type tableColumn struct {
+	Key    string
+	Title  string
+	Width  float32
+	Hidden bool // скрыта, пока пользователь не включит
+}
+
+// tableAction — кнопка в колонке действий
+type tableAction struct {
+	Icon     fyne.Resource
+	OnTapped func(row int)
+}
+
+func newCatalogTable(name string, columns []tableColumn, sel *multiSelection, idAt func(int) int,
+	rows func() int, value func(row int, key string) string, actions ...tableAction) *catalogTable {
+	t := &catalogTable{
+		name: name, columns: columns, sel: sel, idAt: idAt, rows: rows, value: value, actions: actions,
+		headers: map[int]*widget.Button{},
+	}
+	if l, err := loadTableLayout(name); err == nil && l != nil {
+		t.layout = *l
+	} else {
+		for _, c := range columns {
+			if c.Hidden {
+				t.layout.Hidden = append(t.layout.Hidden, c.Key)
+			}
+		}
+	}
+	if t.layout.Widths == nil {
+		t.layout.Widths = map[string]float32{}
+	}
+
+	t.checkWidth = widget.NewCheck("", nil).MinSize().Width
+	t.actionsWidth = t.actionButtons().MinSize().Width
+
+	t.Length = func() (int, int) { return t.rows(), t.columnCount() }
+	t.CreateCell = func() fyne.CanvasObject {
+		label := widget.NewLabel("")
+		label.Truncation = fyne.TextTruncateEllipsis
+		return container.NewStack(label, widget.NewCheck("", nil), t.actionButtons())
+	}
+	t.UpdateCell = t.updateCell
+	t.ShowHeaderRow = true
+	t.CreateHeader = func() fyne.CanvasObject {
+		btn := widget.NewButton("", nil)
+		btn.Alignment = widget.ButtonAlignLeading
+		btn.Importance = widget.LowImportance
+		return btn
+	}
+	t.UpdateHeader = t.updateHeader
+	t.OnSelected = func(id widget.TableCellID) {
+		if _, ok := t.dataColumn(id.Col); ok && id.Row < t.rows() {
+			t.sel.click(id.Row, t.idAt)
+		}
+		// подсветку заменяют флажки, поэтому стандартное выделение снимаем
+		t.Unselect(id)
+	}
+	t.ExtendBaseWidget(t)
+	t.applyLayout()
+	return t
+}
+
+func (t *catalogTable) actionButtons() *fyne.Container {
+	box := container.NewHBox()
+	for _, a := range t.actions {
+		btn := widget.NewButtonWithIcon("", a.Icon, nil)
+		btn.Importance = widget.LowImportance
+		box.Add(btn)
+	}
+	return box
+}
+
+// columnCount — флажки, видимые колонки данных и колонка действий, если они есть
+func (t *catalogTable) columnCount() int {
+	if len(t.actions) == 0 {
+		return len(t.visible) + 1
+	}
+	return len(t.visible) + 2
+}
+
+// dataColumn возвращает колонку данных по номеру колонки таблицы; 0 — флажки, последняя — действия
+func (t *catalogTable) dataColumn(col int) (tableColumn, bool) {
+	if col < 1 || col > len(t.visible) {
+		return tableColumn{}, false
+	}
+	return t.visible[col-1], true
+}
+
+func (t *catalogTable) updateCell(id widget.TableCellID, o fyne.CanvasObject) {
+	cell := o.(*fyne.Container)
+	label := cell.Objects[0].(*widget.Label)
+	check := cell.Objects[1].(*widget.Check)
+	buttons := cell.Objects[2].(*fyne.Container)
+	label.Hide()
+	check.Hide()
+	buttons.Hide()
+	if id.Row >= t.rows() {
+		return
+	}
+	row := id.Row
+	switch c, ok := t.dataColumn(id.Col); {
+	case ok:
+		label.SetText(t.value(row, c.Key))
+		label.Show()
+	case id.Col == 0:
+		itemID := t.idAt(row)
+		check.OnChanged = nil
+		check.SetChecked(t.sel.isSelected(itemID))
+		check.OnChanged = func(on bool) { t.sel.toggle(itemID, on) }
+		check.Show()
+	default:
+		for i, a := range t.actions {
+			btn := buttons.Objects[i].(*widget.Button)
+			btn.OnTapped = func() { a.OnTapped(row) }
+		}
+		buttons.Show()
+	}
+}
+
+func (t *catalogTable) updateHeader(id widget.TableCellID, o fyne.CanvasObject) {
+	btn := o.(*widget.Button)
+	// шаблон мог раньше показывать другую колонку
+	for col, h := range t.headers {
+		if h == btn {
+			delete(t.headers, col)
+		}
+	}
+	c, ok := t.dataColumn(id.Col)
+	if !ok {
+		btn.SetText("")
+		btn.OnTapped = nil
+		return
+	}
+	t.headers[id.Col] = btn
+	text := c.Title
+	if t.sortKey() == c.Key {
+		if t.layout.Desc {
+			text += " ▼"
+		} else {
+			text += " ▲"
+		}
+	}
+	btn.SetText(text)
+	btn.OnTapped = func() { t.sortBy(c.Key) }
+}
+
+// sortKey — колонка, по которой отсортирована таблица; по умолчанию — первая
+func (t *catalogTable) sortKey() string {
+	if t.layout.Sort != "" {
+		return t.layout.Sort
+	}
+	return t.columns[0].Key
+}
+
+func (t *catalogTable) sortBy(key string) {
+	if t.sortKey() == key {
+		t.layout.Desc = !t.layout.Desc
+	} else {
+		t.layout.Sort, t.layout.Desc = key, false
+	}
+	t.save()
+	if t.OnSortChanged != nil {
+		t.OnSortChanged()
+	}
+	t.Refresh()
+}
+
+// query — поиск search с текущей сортировкой таблицы
+func (t *catalogTable) query(search string) TableQuery {
+	return TableQuery{Search: search, Sort: t.sortKey(), Desc: t.layout.Desc}
+}
+
+func (t *catalogTable) isHidden(key string) bool {
+	for _, h := range t.layout.Hidden {
+		if h == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *catalogTable) columnWidth(c tableColumn) float32 {
+	if w, ok := t.layout.Widths[c.Key]; ok {
+		return w
+	}
+	return c.Width
+}
+
+// applyLayout перестраивает колонки по раскладке
+func (t *catalogTable) applyLayout() {
+	t.visible = nil
+	for _, c := range t.columns {
+		if !t.isHidden(c.Key) {
+			t.visible = append(t.visible, c)
+		}
+	}
+	t.headers = map[int]*widget.Button{}
+	t.SetColumnWidth(0, t.checkWidth)
+	for i, c := range t.visible {
+		t.SetColumnWidth(i+1, t.columnWidth(c))
+	}
+	if len(t.actions) > 0 {
+		t.SetColumnWidth(len(t.visible)+1, t.actionsWidth)
+	}
+	t.Refresh()
+}
+
+func (t *catalogTable) save() {
+	if err := saveTableLayout(t.name, &t.layout); err != nil {
+		dialog.ShowError(fmt.Errorf("не удалось сохранить вид таблицы: %w", err), mainWindow)
+	}
+}
+
+// DragEnd — конец перетаскивания границы колонки: запоминаем новые ширины
+func (t *catalogTable) DragEnd() {
+	t.Table.DragEnd()
+	changed := false
+	for col, h := range t.headers {
+		c, ok := t.dataColumn(col)
+		if w := h.Size().Width; ok && w > 0 && w != t.columnWidth(c) {
+			t.layout.Widths[c.Key] = w
+			changed = true
+		}
+	}
+	if changed {
+		t.save()
+	}
+}
+
+// columnsButton открывает выбор видимых колонок
+func (t *catalogTable) columnsButton() *widget.Button {
+	return widget.NewButtonWithIcon("Колонки", theme.ListIcon(), func() {
+		var titles, shown []string
+		keys := map[string]string{}
+		for _, c := range t.columns {
+			titles = append(titles, c.Title)
+			keys[c.Title] = c.Key
+			if !t.isHidden(c.Key) {
+				shown = append(shown, c.Title)
+			}
+		}
+		checks := widget.NewCheckGroup(titles, nil)
+		checks.SetSelected(shown)
+		dialog.ShowCustomConfirm("Колонки таблицы", "Применить", "Отмена", checks, func(ok bool) {
+			if !ok {
+				return
+			}
+			if len(checks.Selected) == 0 {
+				dialog.ShowError(fmt.Errorf("должна остаться хотя бы одна колонка"), mainWindow)
+				return
+			}
+			selected := map[string]bool{}
+			for _, title := range checks.Selected {
+				selected[keys[title]] = true
+			}
+			t.layout.Hidden = nil
+			for _, c := range t.columns {
+				if !selected[c.Key] {
+					t.layout.Hidden = append(t.layout.Hidden, c.Key)
+				}
+			}
+			t.save()
+			t.applyLayout()
+		}, mainWindow)
+	})
+}
@@ -60,3 +60,293 @@ CREATE TABLE playlist_tracks (
     track_id INTEGER REFERENCES tracks(id) ON DELETE CASCADE,
     PRIMARY KEY (playlist_id, track_id)
 );
+
+-- ================= LOGIN SECURITY =================
+-- Счётчики неудачных входов: ключ вида 'user:<логин>' или 'source:<хост>'.
+-- Время с часовым поясом: окна блокировки считаются от nowFunc() и не зависят от TZ сервера
+CREATE TABLE login_failures (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ
+);
+
+-- Журнал попыток входа
+CREATE TABLE login_attempts (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    source TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    reason TEXT,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX login_attempts_username_idx ON login_attempts (username, attempted_at);
+
+-- ================= SESSIONS =================
+-- Токены "запомнить меня": в базе хранится только SHA-256 от токена
+CREATE TABLE sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    device TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked BOOLEAN NOT NULL DEFAULT false
+);
+
+-- ================= TWO-FACTOR AUTH =================
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT false;
+
+-- Резервные коды хранятся как bcrypt-хеши и одноразовы
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP
+);
+
+-- ================= COLLABORATIVE PLAYLISTS =================
+-- version увеличивается при каждом изменении состава плейлиста
+ALTER TABLE playlists ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+
+-- Кто и когда добавил трек в плейлист
+ALTER TABLE playlist_tracks ADD COLUMN added_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE playlist_tracks ADD COLUMN added_at TIMESTAMP NOT NULL DEFAULT now();
+
+-- Права участника: view — просмотр, add — добавление треков, edit — полное редактирование
+CREATE TABLE playlist_collaborators (
+    playlist_id INTEGER REFERENCES playlists(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL CHECK (permission IN ('view', 'add', 'edit')),
+    PRIMARY KEY (playlist_id, user_id)
+);
+
+-- ================= PUBLIC PLAYLISTS & FOLLOWING =================
+-- private — владелец и участники, unlisted — любой по номеру плейлиста, public — виден в обзоре
+ALTER TABLE playlists ADD COLUMN visibility TEXT NOT NULL DEFAULT 'private'
+    CHECK (visibility IN ('private', 'unlisted', 'public'));
+
+CREATE TABLE playlist_follows (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    playlist_id INTEGER REFERENCES playlists(id) ON DELETE CASCADE,
+    followed_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, playlist_id)
+);
+
+CREATE TABLE user_follows (
+    follower_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    followee_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    followed_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+-- ================= PLAYLIST FOLDERS =================
+-- Папки пользователя; parent_id = NULL — корень
+CREATE TABLE playlist_folders (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    parent_id INTEGER REFERENCES playlist_folders(id) ON DELETE CASCADE
+);
+
+ALTER TABLE playlists ADD COLUMN folder_id INTEGER REFERENCES playlist_folders(id) ON DELETE SET NULL;
+
+-- ================= GENRES & TAGS =================
+ALTER TABLE tracks ADD COLUMN genre TEXT;
+
+CREATE TABLE track_tags (
+    track_id INTEGER REFERENCES tracks(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (track_id, tag)
+);
+
+-- ================= UNDO HISTORY =================
+-- Стек отмены/повтора команд; session_key — сеанс работы, в рамках которого история сохраняется
+CREATE TABLE command_history (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_key TEXT NOT NULL,
+    stack TEXT NOT NULL CHECK (stack IN ('undo', 'redo')),
+    seq INTEGER NOT NULL,
+    op TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX command_history_session_idx ON command_history (user_id, session_key);
+
+-- ================= AUDIT LOG =================
+-- Журнал изменений данных. Пишется триггерами, поэтому попадают и каскадные удаления.
+-- Автор берётся из настройки транзакции app.actor_id/app.actor_name, которую
+-- выставляет приложение; внешнего ключа на users нет, чтобы запись пережила удаление автора.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER,
+    username TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    operation TEXT NOT NULL CHECK (operation IN ('insert', 'update', 'delete')),
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER,
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX audit_log_created_idx ON audit_log (created_at);
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id);
+
+-- Аргумент триггера — столбец, значение которого пишется в entity_id.
+-- Хеш пароля, секрет 2FA, код ссылки на плейлист и акустические отпечатки в журнал не попадают.
+CREATE FUNCTION audit_row() RETURNS trigger AS $$
+DECLARE
+    actor INTEGER := NULLIF(NULLIF(current_setting('app.actor_id', true), ''), '0')::INTEGER;
+    old_row JSONB;
+    new_row JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD) - 'password_hash' - 'totp_secret' - 'share_token' - 'fingerprint';
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW) - 'password_hash' - 'totp_secret' - 'share_token' - 'fingerprint';
+    END IF;
+    INSERT INTO audit_log (user_id, username, operation, entity_type, entity_id, before, after)
+    VALUES (actor, NULLIF(current_setting('app.actor_name', true), ''), lower(TG_OP), TG_TABLE_NAME,
+            (COALESCE(new_row, old_row) ->> TG_ARGV[0])::INTEGER, old_row, new_row);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_users AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION audit_row('id');
+CREATE TRIGGER audit_artists AFTER INSERT OR UPDATE OR DELETE ON artists
+    FOR EACH ROW EXECUTE FUNCTION audit_row('id');
+CREATE TRIGGER audit_albums AFTER INSERT OR UPDATE OR DELETE ON albums
+    FOR EACH ROW EXECUTE FUNCTION audit_row('id');
+CREATE TRIGGER audit_tracks AFTER INSERT OR UPDATE OR DELETE ON tracks
+    FOR EACH ROW EXECUTE FUNCTION audit_row('id');
+CREATE TRIGGER audit_track_tags AFTER INSERT OR UPDATE OR DELETE ON track_tags
+    FOR EACH ROW EXECUTE FUNCTION audit_row('track_id');
+CREATE TRIGGER audit_playlists AFTER INSERT OR UPDATE OR DELETE ON playlists
+    FOR EACH ROW EXECUTE FUNCTION audit_row('id');
+CREATE TRIGGER audit_playlist_tracks AFTER INSERT OR UPDATE OR DELETE ON playlist_tracks
+    FOR EACH ROW EXECUTE FUNCTION audit_row('playlist_id');
+CREATE TRIGGER audit_playlist_collaborators AFTER INSERT OR UPDATE OR DELETE ON playlist_collaborators
+    FOR EACH ROW EXECUTE FUNCTION audit_row('playlist_id');
+CREATE TRIGGER audit_playlist_follows AFTER INSERT OR UPDATE OR DELETE ON playlist_follows
+    FOR EACH ROW EXECUTE FUNCTION audit_row('playlist_id');
+CREATE TRIGGER audit_user_follows AFTER INSERT OR UPDATE OR DELETE ON user_follows
+    FOR EACH ROW EXECUTE FUNCTION audit_row('followee_id');
+CREATE TRIGGER audit_playlist_folders AFTER INSERT OR UPDATE OR DELETE ON playlist_folders
+    FOR EACH ROW EXECUTE FUNCTION audit_row('id');
+
+-- ================= METADATA ENRICHMENT =================
+ALTER TABLE tracks ADD COLUMN track_number INTEGER;
+
+-- Кэш ответов сервиса метаданных (MusicBrainz или совместимого); ключ — полный адрес запроса
+CREATE TABLE metadata_cache (
+    url TEXT PRIMARY KEY,
+    body TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- ================= AUDIO FINGERPRINTS =================
+-- Акустический отпечаток трека: по 32-битному слову на кадр, little-endian
+CREATE TABLE track_fingerprints (
+    track_id INTEGER PRIMARY KEY REFERENCES tracks(id) ON DELETE CASCADE,
+    fingerprint BYTEA NOT NULL,
+    file_path TEXT NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- ================= LOUDNESS =================
+-- Громкость по EBU R128 (LUFS), true peak (dBTP) и поправка ReplayGain 2.0 (дБ до -18 LUFS)
+ALTER TABLE tracks ADD COLUMN loudness_lufs REAL;
+ALTER TABLE tracks ADD COLUMN true_peak_db REAL;
+ALTER TABLE tracks ADD COLUMN replay_gain_db REAL;
+ALTER TABLE albums ADD COLUMN loudness_lufs REAL;
+ALTER TABLE albums ADD COLUMN true_peak_db REAL;
+ALTER TABLE albums ADD COLUMN replay_gain_db REAL;
+
+-- ================= TEMPO AND KEY =================
+-- Темп (BPM) и тональность в нотации Camelot ("8A" — ля минор) по привязанному файлу
+ALTER TABLE tracks ADD COLUMN bpm REAL;
+ALTER TABLE tracks ADD COLUMN musical_key VARCHAR(3);
+
+-- ================= RATINGS AND PLAYS =================
+-- Оценки треков пользователями (1–5) и история прослушиваний — для рекомендаций
+CREATE TABLE track_ratings (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    track_id INTEGER REFERENCES tracks(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    rated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, track_id)
+);
+
+CREATE TABLE track_plays (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    played_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX track_plays_user_idx ON track_plays (user_id, track_id);
+
+-- ================= PLAYLIST TIMESTAMPS =================
+-- updated_at меняется вместе с version и при смене видимости
+ALTER TABLE playlists ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT now();
+ALTER TABLE playlists ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT now();
+
+-- ================= USER SETTINGS =================
+-- Настройки интерфейса пользователя (раскладка таблиц и т.п.), значение — JSON
+CREATE TABLE user_settings (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+-- ================= ALBUM CREDITS =================
+-- Участники альбома свободным текстом ("Продюсер: …"); совместные релизы заполняются из сервиса метаданных
+ALTER TABLE albums ADD COLUMN credits TEXT;
+
+-- ================= ROLES =================
+-- Новый пользователь — слушатель; первый зарегистрированный становится администратором (см. RegisterUser)
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'listener' CHECK (role IN ('admin', 'editor', 'listener'));
+-- В уже заполненной базе администратором становится самый ранний пользователь.
+-- Восстановить доступ позже можно командой music-app set-role.
+UPDATE users SET role = 'admin'
+WHERE id = (SELECT MIN(id) FROM users) AND NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin');
+
+-- ================= TOTP REPLAY =================
+-- Шаг последнего принятого TOTP-кода: код того же или более раннего шага повторно не принимается
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT;
+
+-- ================= SHARE LINKS =================
+-- Плейлист "по ссылке" открывается по случайному коду, а не по последовательному номеру.
+-- Код есть у каждого плейлиста и меняется при каждом переводе в режим "по ссылке".
+ALTER TABLE playlists ADD COLUMN share_token TEXT NOT NULL UNIQUE DEFAULT replace(gen_random_uuid()::text, '-', '');
+
+-- ================= AUDIT: LATER TABLES =================
+-- Журнал для таблиц, появившихся после журнала изменений
+CREATE TRIGGER audit_track_ratings AFTER INSERT OR UPDATE OR DELETE ON track_ratings
+    FOR EACH ROW EXECUTE FUNCTION audit_row('track_id');
+CREATE TRIGGER audit_track_plays AFTER INSERT OR UPDATE OR DELETE ON track_plays
+    FOR EACH ROW EXECUTE FUNCTION audit_row('track_id');
+CREATE TRIGGER audit_track_fingerprints AFTER INSERT OR UPDATE OR DELETE ON track_fingerprints
+    FOR EACH ROW EXECUTE FUNCTION audit_row('track_id');
+CREATE TRIGGER audit_user_settings AFTER INSERT OR UPDATE OR DELETE ON user_settings
+    FOR EACH ROW EXECUTE FUNCTION audit_row('user_id');
+
+-- ================= PLAYLIST ORDER =================
+-- Порядок треков в плейлисте задаёт position, а added_at остаётся настоящим временем добавления.
+-- В уже заполненной базе позиции выставляются по прежнему порядку — по времени добавления.
+ALTER TABLE playlist_tracks ADD COLUMN position INTEGER;
+UPDATE playlist_tracks pt SET position = o.n
+FROM (SELECT playlist_id, track_id, ROW_NUMBER() OVER (PARTITION BY playlist_id ORDER BY added_at, track_id) AS n
+      FROM playlist_tracks) o
+WHERE o.playlist_id = pt.playlist_id AND o.track_id = pt.track_id;
+ALTER TABLE playlist_tracks ALTER COLUMN position SET NOT NULL;
+CREATE INDEX playlist_tracks_position_idx ON playlist_tracks (playlist_id, position);